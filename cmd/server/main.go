@@ -27,17 +27,26 @@ func main() {
 }
 
 func run() error {
-	zlog.Logger().Info("starting goph-keeper server...")
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
-
 	configPath := flag.String("c", getenv("CONFIG_FILE", "config.yaml"), "Path config file")
+	flag.Parse()
 
 	config, err := yaml.ReadYaml[config.Config](*configPath)
 	if err != nil {
 		return fmt.Errorf("read config, err=%w", err)
 	}
 
+	if args := flag.Args(); len(args) != 0 {
+		if args[0] != "migrate" {
+			return fmt.Errorf("unknown command=%s, %s", args[0], migrateUsage)
+		}
+
+		return migrate(config, args[1:])
+	}
+
+	zlog.Logger().Info("starting goph-keeper server...")
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+
 	zlog.Logger().Infof("configPath: %s; config: %+v", *configPath, config)
 
 	srvr, err := server.StartNew(config)
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/config"
+	"github.com/kuzhukin/goph-keeper/internal/server/sql"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+)
+
+const migrateUsage = "usage: server [-c config.yaml] migrate up|down|status"
+
+// migrate applies or reverts migrations without starting the server
+func migrate(config *config.Config, args []string) (err error) {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := openMigrator(config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := migrator.Down(ctx); err != nil {
+			return err
+		}
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("schema version: %d\n", version)
+
+	return nil
+}
+
+func openMigrator(config *config.Config) (*sql.Migrator, error) {
+	switch config.StorageDriver {
+	case storage.DriverPostgres, "":
+		return sql.OpenMigrator(config.DataSourceName)
+	case storage.DriverSQLite:
+		return sql.OpenSQLiteMigrator(config.DataSourceName)
+	default:
+		return nil, fmt.Errorf("storage driver=%s doesn't have migrations", config.StorageDriver)
+	}
+}
+
+func printMigrationStatus(ctx context.Context, migrator *sql.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied at " + s.AppliedAt.Local().Format(time.DateTime)
+		}
+
+		if s.Unknown {
+			state += " (unknown, applied by newer server)"
+		}
+
+		fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+	}
+
+	return nil
+}
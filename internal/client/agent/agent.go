@@ -0,0 +1,397 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/config"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+const socketFileName = "agent.sock"
+
+// delays between reconnections to the server's changes stream
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// SyncClient receives user's items from the server
+type SyncClient interface {
+	transport.ChangesClient
+	transport.BinaryDataClient
+	transport.WalletClient
+	transport.SecretDataClient
+	transport.SSHKeyClient
+}
+
+// Agent keeps local storage synchronized with the server and serves local reads through the unix socket
+type Agent struct {
+	user       *storage.User
+	storage    storage.Storage
+	client     SyncClient
+	socketPath string
+}
+
+func New(user *storage.User, s storage.Storage, client SyncClient, socketPath string) *Agent {
+	return &Agent{
+		user:       user,
+		storage:    s,
+		client:     client,
+		socketPath: socketPath,
+	}
+}
+
+// DefaultSocketPath returns path of agent's socket in the application's directory
+func DefaultSocketPath() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homedir, config.DefaultAppDirName, socketFileName), nil
+}
+
+// Run serves the socket and applies server's changes to the local storage until ctx is done
+func (a *Agent) Run(ctx context.Context) error {
+	listener, err := ListenSocket(a.socketPath)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: newSocketHandler(a.user, a.storage)}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	zlog.Logger().Infof("agent is listening socket=%s", a.socketPath)
+
+	a.syncLoop(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if serveErr := <-served; serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	return err
+}
+
+func (a *Agent) syncLoop(ctx context.Context) {
+	delay := minReconnectDelay
+
+	for {
+		start := time.Now()
+
+		err := a.sync(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		zlog.Logger().Infof("changes stream is interrupted, err=%s", err)
+
+		// connection was stable, so it's a new problem
+		if time.Since(start) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// sync pulls changes which were missed while agent was disconnected and then watches new changes
+func (a *Agent) sync(ctx context.Context) error {
+	if err := a.resync(ctx); err != nil {
+		return err
+	}
+
+	return a.client.WatchChanges(ctx, a.user, func(change *storage.Change) error {
+		if err := applyChange(ctx, a.user, a.storage, change); err != nil {
+			return fmt.Errorf("apply %s %s %s, err=%w", change.Operation, change.Kind, change.Key, err)
+		}
+
+		zlog.Logger().Infof("applied change: %s %s %s", change.Operation, change.Kind, change.Key)
+
+		return nil
+	})
+}
+
+// remoteItem is an item which exists on the server, outdated item is downloaded by resync
+type remoteItem struct {
+	key      string
+	outdated bool
+}
+
+// resync applies changes which were made on the server while the agent didn't watch changes.
+// Items with local operations in the outbox are skipped, the outbox sends them to the server.
+func (a *Agent) resync(ctx context.Context) error {
+	ops, err := a.storage.ListPendingOperations(ctx, a.user)
+	if err != nil {
+		return fmt.Errorf("list pending operations, err=%w", err)
+	}
+
+	pending := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		pending[op.Kind+"/"+op.Key] = true
+	}
+
+	resyncs := []struct {
+		kind   string
+		resync func(ctx context.Context, pending map[string]bool) error
+	}{
+		{storage.ItemKindData, a.resyncData},
+		{storage.ItemKindCard, a.resyncCards},
+		{storage.ItemKindSecret, a.resyncSecrets},
+		{storage.ItemKindSSHKey, a.resyncSSHKeys},
+	}
+
+	for _, r := range resyncs {
+		if err := r.resync(ctx, pending); err != nil {
+			return fmt.Errorf("resync %s, err=%w", r.kind, err)
+		}
+	}
+
+	return nil
+}
+
+// resyncData downloads records with another revision
+func (a *Agent) resyncData(ctx context.Context, pending map[string]bool) error {
+	remote, err := transport.ListAll(func(cursor string) ([]*handler.DataInfo, string, error) {
+		return a.client.ListBinaryData(ctx, a.user, nil, cursor)
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := a.storage.ListData(ctx, a.user)
+	if err != nil {
+		return err
+	}
+
+	localRevisions := make(map[string]uint64, len(local))
+	localKeys := make([]string, 0, len(local))
+
+	for _, r := range local {
+		localRevisions[r.Name] = r.Revision
+		localKeys = append(localKeys, r.Name)
+	}
+
+	remoteItems := make([]*remoteItem, 0, len(remote))
+	for _, info := range remote {
+		revision, ok := localRevisions[info.Name]
+		remoteItems = append(remoteItems, &remoteItem{key: info.Name, outdated: !ok || revision != info.Revision})
+	}
+
+	return a.resyncItems(storage.ItemKindData, remoteItems, localKeys, pending,
+		func(key string) error {
+			r, err := a.client.DownloadBinaryData(ctx, a.user, key)
+			if err != nil {
+				return err
+			}
+
+			return a.storage.SaveData(ctx, a.user, r)
+		},
+		func(key string) error {
+			return a.storage.DeleteData(ctx, a.user, key)
+		},
+	)
+}
+
+// resyncCards downloads missing cards, cards aren't changed on the server, they're deleted and created again
+func (a *Agent) resyncCards(ctx context.Context, pending map[string]bool) error {
+	remote, err := transport.ListAll(func(cursor string) ([]*handler.CardInfo, string, error) {
+		return a.client.ListCardData(ctx, a.user.Token, nil, cursor)
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := a.storage.ListCard(ctx, a.user)
+	if err != nil {
+		return err
+	}
+
+	localKeys := make([]string, 0, len(local))
+	for _, c := range local {
+		localKeys = append(localKeys, c.Number)
+	}
+
+	remoteItems := make([]*remoteItem, 0, len(remote))
+	for _, info := range remote {
+		remoteItems = append(remoteItems, &remoteItem{key: info.Number, outdated: !slices.Contains(localKeys, info.Number)})
+	}
+
+	return a.resyncItems(storage.ItemKindCard, remoteItems, localKeys, pending,
+		func(key string) error {
+			data, err := a.client.GetCardData(ctx, a.user.Token, key)
+			if err != nil {
+				return err
+			}
+
+			return replaceCard(ctx, a.user, a.storage, &storage.Change{Kind: storage.ItemKindCard, Key: key, Data: data})
+		},
+		func(key string) error {
+			return a.storage.DeleteCard(ctx, a.user, key)
+		},
+	)
+}
+
+// resyncSecrets downloads missing secrets, secrets like cards are deleted and created again
+func (a *Agent) resyncSecrets(ctx context.Context, pending map[string]bool) error {
+	remote, err := transport.ListAll(func(cursor string) ([]*handler.SecretInfo, string, error) {
+		return a.client.ListSecrets(ctx, a.user.Token, nil, cursor)
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := a.storage.ListSecrets(ctx, a.user)
+	if err != nil {
+		return err
+	}
+
+	localKeys := make([]string, 0, len(local))
+	for _, s := range local {
+		localKeys = append(localKeys, s.Name)
+	}
+
+	remoteItems := make([]*remoteItem, 0, len(remote))
+	for _, info := range remote {
+		remoteItems = append(remoteItems, &remoteItem{key: info.Key, outdated: !slices.Contains(localKeys, info.Key)})
+	}
+
+	return a.resyncItems(storage.ItemKindSecret, remoteItems, localKeys, pending,
+		func(key string) error {
+			// server returns secret's encrypted data as the value
+			secret, err := a.client.GetSecret(ctx, a.user.Token, key)
+			if err != nil {
+				return err
+			}
+
+			return replaceSecret(ctx, a.user, a.storage, &storage.Change{Kind: storage.ItemKindSecret, Key: key, Data: secret.Value})
+		},
+		func(key string) error {
+			return a.storage.DeleteSecret(ctx, a.user, key)
+		},
+	)
+}
+
+// resyncSSHKeys downloads missing ssh keys, so ssh-agent serves keys which were added by other devices
+func (a *Agent) resyncSSHKeys(ctx context.Context, pending map[string]bool) error {
+	remote, err := transport.ListAll(func(cursor string) ([]*handler.SSHKeyInfo, string, error) {
+		return a.client.ListSSHKeys(ctx, a.user.Token, nil, cursor)
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := a.storage.ListSSHKeys(ctx, a.user)
+	if err != nil {
+		return err
+	}
+
+	localKeys := make([]string, 0, len(local))
+	for _, k := range local {
+		localKeys = append(localKeys, k.Name)
+	}
+
+	remoteItems := make([]*remoteItem, 0, len(remote))
+	for _, info := range remote {
+		remoteItems = append(remoteItems, &remoteItem{key: info.Name, outdated: !slices.Contains(localKeys, info.Name)})
+	}
+
+	return a.resyncItems(storage.ItemKindSSHKey, remoteItems, localKeys, pending,
+		func(key string) error {
+			data, err := a.client.GetSSHKey(ctx, a.user.Token, key)
+			if err != nil {
+				return err
+			}
+
+			return replaceSSHKey(ctx, a.user, a.storage, &storage.Change{Kind: storage.ItemKindSSHKey, Key: key, Data: data})
+		},
+		func(key string) error {
+			return a.storage.DeleteSSHKey(ctx, a.user, key)
+		},
+	)
+}
+
+// resyncItems downloads outdated remote items and deletes local items which were deleted on the server
+func (a *Agent) resyncItems(
+	kind string,
+	remote []*remoteItem,
+	local []string,
+	pending map[string]bool,
+	download func(key string) error,
+	remove func(key string) error,
+) error {
+	remoteKeys := make(map[string]bool, len(remote))
+
+	for _, item := range remote {
+		remoteKeys[item.key] = true
+
+		if !item.outdated || pending[kind+"/"+item.key] {
+			continue
+		}
+
+		if err := download(item.key); err != nil {
+			return fmt.Errorf("download %s, err=%w", item.key, err)
+		}
+
+		zlog.Logger().Infof("resynced %s %s", kind, item.key)
+	}
+
+	for _, key := range local {
+		if remoteKeys[key] || pending[kind+"/"+key] {
+			continue
+		}
+
+		if err := remove(key); err != nil {
+			return fmt.Errorf("delete %s, err=%w", key, err)
+		}
+
+		zlog.Logger().Infof("deleted %s %s which was deleted on server", kind, key)
+	}
+
+	return nil
+}
+
+// ListenSocket listens unix socket which is available for the current user only
+func ListenSocket(socketPath string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("socket=%s is already used by running agent", socketPath)
+	}
+
+	// socket is left by stopped agent
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := listenPrivateSocket(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("listen socket=%s, err=%w", socketPath, err)
+	}
+
+	return listener, nil
+}
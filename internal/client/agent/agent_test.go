@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+type testSyncClient struct {
+	*transport.MockChangesClient
+	*transport.MockBinaryDataClient
+	*transport.MockWalletClient
+	*transport.MockSecretDataClient
+	*transport.MockSSHKeyClient
+}
+
+func newTestSyncClient(ctrl *gomock.Controller) *testSyncClient {
+	return &testSyncClient{
+		MockChangesClient:    transport.NewMockChangesClient(ctrl),
+		MockBinaryDataClient: transport.NewMockBinaryDataClient(ctrl),
+		MockWalletClient:     transport.NewMockWalletClient(ctrl),
+		MockSecretDataClient: transport.NewMockSecretDataClient(ctrl),
+		MockSSHKeyClient:     transport.NewMockSSHKeyClient(ctrl),
+	}
+}
+
+// expectNoItems expects resync of kinds which don't have items
+func expectNoItems(ctx context.Context, user *storage.User, s *storage.MockStorage, client *testSyncClient) {
+	client.MockWalletClient.EXPECT().ListCardData(ctx, user.Token, nil, "").Return(nil, "", nil).AnyTimes()
+	s.EXPECT().ListCard(ctx, user).Return(nil, nil).AnyTimes()
+	client.MockSecretDataClient.EXPECT().ListSecrets(ctx, user.Token, nil, "").Return(nil, "", nil).AnyTimes()
+	s.EXPECT().ListSecrets(ctx, user).Return(nil, nil).AnyTimes()
+	client.MockSSHKeyClient.EXPECT().ListSSHKeys(ctx, user.Token, nil, "").Return(nil, "", nil).AnyTimes()
+	s.EXPECT().ListSSHKeys(ctx, user).Return(nil, nil).AnyTimes()
+}
+
+func TestAgentSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+	client := newTestSyncClient(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	mockStorage.EXPECT().ListPendingOperations(ctx, user).Return(nil, nil)
+	expectNoItems(ctx, user, mockStorage, client)
+
+	changed := &storage.Record{Name: "changed", Data: "new", Revision: 2}
+	same := &storage.Record{Name: "same", Data: "same", Revision: 1}
+	streamErr := errors.New("stream is closed")
+
+	gomock.InOrder(
+		client.MockBinaryDataClient.EXPECT().ListBinaryData(ctx, user, nil, "").Return([]*handler.DataInfo{
+			{Name: "changed", Revision: 2},
+			{Name: "same", Revision: 1},
+		}, "", nil),
+		mockStorage.EXPECT().ListData(ctx, user).Return([]*storage.Record{{Name: "changed", Revision: 1}, same}, nil),
+		client.MockBinaryDataClient.EXPECT().DownloadBinaryData(ctx, user, "changed").Return(changed, nil),
+		mockStorage.EXPECT().SaveData(ctx, user, changed).Return(nil),
+		client.MockChangesClient.EXPECT().WatchChanges(ctx, user, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ *storage.User, onChange func(*storage.Change) error) error {
+				require.NoError(t, onChange(&storage.Change{Kind: storage.ItemKindData, Operation: storage.OperationDelete, Key: "same"}))
+
+				return streamErr
+			},
+		),
+	)
+	mockStorage.EXPECT().DeleteData(ctx, user, "same").Return(nil)
+
+	a := New(user, mockStorage, client, "")
+
+	err := a.sync(ctx)
+	require.ErrorIs(t, err, streamErr)
+}
+
+func TestAgentResyncDeletedWhileDisconnected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+	client := newTestSyncClient(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	serializer, err := newSerializer(user)
+	require.NoError(t, err)
+
+	secretData, err := serializer.SerializeSecret(&storage.Secret{Name: "new", Key: "k", Value: "v"})
+	require.NoError(t, err)
+
+	sshKey := &storage.SSHKey{Name: "deploy", PrivateKey: []byte("pem"), Comment: "ci"}
+	sshKeyData, err := serializer.SerializeSSHKey(sshKey)
+	require.NoError(t, err)
+
+	// local changes which weren't sent yet
+	mockStorage.EXPECT().ListPendingOperations(ctx, user).Return([]*storage.PendingOperation{
+		{Kind: storage.ItemKindData, Operation: storage.OperationCreate, Key: "unsent"},
+		{Kind: storage.ItemKindCard, Operation: storage.OperationDelete, Key: "5678"},
+	}, nil)
+
+	client.MockBinaryDataClient.EXPECT().ListBinaryData(ctx, user, nil, "").Return([]*handler.DataInfo{{Name: "kept", Revision: 1}}, "", nil)
+	mockStorage.EXPECT().ListData(ctx, user).Return([]*storage.Record{{Name: "kept", Revision: 1}, {Name: "deleted", Revision: 1}, {Name: "unsent"}}, nil)
+	mockStorage.EXPECT().DeleteData(ctx, user, "deleted").Return(nil)
+
+	client.MockWalletClient.EXPECT().ListCardData(ctx, user.Token, nil, "").Return([]*handler.CardInfo{{Number: "5678"}}, "", nil)
+	mockStorage.EXPECT().ListCard(ctx, user).Return([]*storage.BankCard{{Number: "1234"}}, nil)
+	mockStorage.EXPECT().DeleteCard(ctx, user, "1234").Return(nil)
+
+	client.MockSecretDataClient.EXPECT().ListSecrets(ctx, user.Token, nil, "").Return([]*handler.SecretInfo{{Key: "new"}}, "", nil)
+	mockStorage.EXPECT().ListSecrets(ctx, user).Return([]*storage.Secret{{Name: "deleted"}}, nil)
+	client.MockSecretDataClient.EXPECT().GetSecret(ctx, user.Token, "new").Return(&storage.Secret{Key: "new", Value: secretData}, nil)
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Times(2)
+	mockStorage.EXPECT().DeleteSecret(ctx, user, "new").Return(nil)
+	mockStorage.EXPECT().CreateSecret(ctx, user, &storage.Secret{Name: "new", Key: "k", Value: "v"}).Return(secretData, nil)
+	mockStorage.EXPECT().DeleteSecret(ctx, user, "deleted").Return(nil)
+
+	// fresh client db gets keys which were added by other devices
+	client.MockSSHKeyClient.EXPECT().ListSSHKeys(ctx, user.Token, nil, "").Return([]*handler.SSHKeyInfo{{Name: "deploy"}}, "", nil)
+	mockStorage.EXPECT().ListSSHKeys(ctx, user).Return(nil, nil)
+	client.MockSSHKeyClient.EXPECT().GetSSHKey(ctx, user.Token, "deploy").Return(sshKeyData, nil)
+	mockStorage.EXPECT().DeleteSSHKey(ctx, user, "deploy").Return(nil)
+	mockStorage.EXPECT().CreateSSHKey(ctx, user, sshKey).Return(sshKeyData, nil)
+
+	a := New(user, mockStorage, client, "")
+
+	require.NoError(t, a.resync(ctx))
+}
+
+func TestApplyChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	serializer, err := newSerializer(user)
+	require.NoError(t, err)
+
+	card := &storage.BankCard{Number: "1234", Owner: "IVAN PETROV", CvvCode: "123"}
+	cardData, err := serializer.SerializeBankCard(card)
+	require.NoError(t, err)
+
+	secret := &storage.Secret{Name: "secret", Key: "key", Value: "value"}
+	secretData, err := serializer.SerializeSecret(secret)
+	require.NoError(t, err)
+
+	mockStorage.EXPECT().SaveData(ctx, user, &storage.Record{Name: "data", Data: "d", Revision: 3, Metainfo: "m"}).Return(nil)
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Times(2)
+	gomock.InOrder(
+		mockStorage.EXPECT().DeleteCard(ctx, user, card.Number).Return(nil),
+		mockStorage.EXPECT().CreateCard(ctx, user, gomock.Any()).DoAndReturn(func(_ context.Context, _ *storage.User, c *storage.BankCard) (string, error) {
+			require.Equal(t, card.Number, c.Number)
+			require.Equal(t, card.Owner, c.Owner)
+
+			return cardData, nil
+		}),
+	)
+	gomock.InOrder(
+		mockStorage.EXPECT().DeleteSecret(ctx, user, secret.Name).Return(nil),
+		mockStorage.EXPECT().CreateSecret(ctx, user, secret).Return(secretData, nil),
+	)
+
+	changes := []*storage.Change{
+		{Kind: storage.ItemKindData, Operation: storage.OperationUpdate, Key: "data", Data: "d", Revision: 3, Metainfo: "m"},
+		{Kind: storage.ItemKindCard, Operation: storage.OperationCreate, Key: card.Number, Data: cardData},
+		{Kind: storage.ItemKindSecret, Operation: storage.OperationCreate, Key: secret.Name, Data: secretData},
+	}
+
+	for _, c := range changes {
+		require.NoError(t, applyChange(ctx, user, mockStorage, c))
+	}
+
+	err = applyChange(ctx, user, mockStorage, &storage.Change{Kind: storage.ItemKindCard, Operation: storage.OperationUpdate, Key: "1234"})
+	require.Error(t, err)
+}
+
+func makeTestUser(t *testing.T) *storage.User {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	return &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+)
+
+// applyChange makes the server's change in the local storage.
+// Changes which were made by this device are received too, so applying must be idempotent.
+func applyChange(ctx context.Context, user *storage.User, s storage.Storage, c *storage.Change) error {
+	switch c.Kind + "/" + c.Operation {
+	case storage.ItemKindData + "/" + storage.OperationCreate, storage.ItemKindData + "/" + storage.OperationUpdate:
+		return s.SaveData(ctx, user, &storage.Record{Name: c.Key, Data: c.Data, Revision: c.Revision, Metainfo: c.Metainfo})
+	case storage.ItemKindData + "/" + storage.OperationDelete:
+		return s.DeleteData(ctx, user, c.Key)
+	case storage.ItemKindCard + "/" + storage.OperationCreate:
+		return replaceCard(ctx, user, s, c)
+	case storage.ItemKindCard + "/" + storage.OperationDelete:
+		return s.DeleteCard(ctx, user, c.Key)
+	case storage.ItemKindSecret + "/" + storage.OperationCreate:
+		return replaceSecret(ctx, user, s, c)
+	case storage.ItemKindSecret + "/" + storage.OperationDelete:
+		return s.DeleteSecret(ctx, user, c.Key)
+	case storage.ItemKindSSHKey + "/" + storage.OperationCreate:
+		return replaceSSHKey(ctx, user, s, c)
+	case storage.ItemKindSSHKey + "/" + storage.OperationDelete:
+		return s.DeleteSSHKey(ctx, user, c.Key)
+	default:
+		return fmt.Errorf("unknown operation %s with %s", c.Operation, c.Kind)
+	}
+}
+
+func replaceCard(ctx context.Context, user *storage.User, s storage.Storage, c *storage.Change) error {
+	serializer, err := newSerializer(user)
+	if err != nil {
+		return err
+	}
+
+	card, err := serializer.DeserializeBankCard(c.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.DeleteCard(ctx, user, c.Key); err != nil {
+			return err
+		}
+
+		_, err := s.CreateCard(ctx, user, card)
+
+		return err
+	})
+}
+
+func replaceSecret(ctx context.Context, user *storage.User, s storage.Storage, c *storage.Change) error {
+	serializer, err := newSerializer(user)
+	if err != nil {
+		return err
+	}
+
+	secret, err := serializer.DeserializeSecret(c.Data)
+	if err != nil {
+		return err
+	}
+
+	secret.Name = c.Key
+
+	return s.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.DeleteSecret(ctx, user, c.Key); err != nil {
+			return err
+		}
+
+		_, err := s.CreateSecret(ctx, user, secret)
+
+		return err
+	})
+}
+
+func replaceSSHKey(ctx context.Context, user *storage.User, s storage.Storage, c *storage.Change) error {
+	serializer, err := newSerializer(user)
+	if err != nil {
+		return err
+	}
+
+	key, err := serializer.DeserializeSSHKey(c.Data)
+	if err != nil {
+		return err
+	}
+
+	key.Name = c.Key
+
+	return s.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.DeleteSSHKey(ctx, user, c.Key); err != nil {
+			return err
+		}
+
+		_, err := s.CreateSSHKey(ctx, user, key)
+
+		return err
+	})
+}
+
+func newSerializer(user *storage.User) (*sqlstorage.DbSerializer, error) {
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlstorage.NewSerializer(crypto), nil
+}
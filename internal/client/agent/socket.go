@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// socket's endpoints
+const (
+	// GET ?key= - data's record
+	dataPath = "/data"
+	// GET - all data's records
+	dataListPath = "/data/list"
+	// GET ?name= - decrypted secret
+	secretPath = "/secret"
+	// GET - all decrypted cards
+	cardsPath = "/cards"
+)
+
+// header with login of the user which reads items, agent serves the own user only
+const loginHeader = "login"
+
+type socketHandler struct {
+	user    *storage.User
+	storage storage.Storage
+}
+
+func newSocketHandler(user *storage.User, s storage.Storage) http.Handler {
+	h := &socketHandler{user: user, storage: s}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(dataPath, h.handleGetData)
+	mux.HandleFunc(dataListPath, h.handleListData)
+	mux.HandleFunc(secretPath, h.handleGetSecret)
+	mux.HandleFunc(cardsPath, h.handleListCards)
+
+	return h.checkUser(mux)
+}
+
+func (h *socketHandler) checkUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if r.Header.Get(loginHeader) != h.user.Login {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *socketHandler) handleGetData(w http.ResponseWriter, r *http.Request) {
+	record, err := h.storage.LoadData(r.Context(), h.user, r.URL.Query().Get("key"))
+	writeSocketResponse(w, record, err)
+}
+
+func (h *socketHandler) handleListData(w http.ResponseWriter, r *http.Request) {
+	records, err := h.storage.ListData(r.Context(), h.user)
+	writeSocketResponse(w, records, err)
+}
+
+func (h *socketHandler) handleGetSecret(w http.ResponseWriter, r *http.Request) {
+	secret, err := h.storage.GetSecret(r.Context(), h.user, r.URL.Query().Get("name"))
+	writeSocketResponse(w, secret, err)
+}
+
+func (h *socketHandler) handleListCards(w http.ResponseWriter, r *http.Request) {
+	cards, err := h.storage.ListCard(r.Context(), h.user)
+	writeSocketResponse(w, cards, err)
+}
+
+func writeSocketResponse[T any](w http.ResponseWriter, response T, err error) {
+	if err != nil {
+		if errors.Is(err, sqlstorage.ErrDataNotExist) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			zlog.Logger().Infof("storage err=%s", err)
+
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zlog.Logger().Infof("write response err=%s", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package agent
+
+import "net"
+
+// listenPrivateSocket relies on the default permissions, access to the socket is limited by its directory
+func listenPrivateSocket(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
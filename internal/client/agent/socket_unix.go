@@ -0,0 +1,25 @@
+//go:build unix
+
+package agent
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes listens, umask is the process' state
+var umaskMu sync.Mutex
+
+// listenPrivateSocket creates the socket with 0600 permissions at once,
+// socket which is chmod'ed after creation is available for other users until chmod.
+// Files which are created by other goroutines meanwhile get stricter permissions only.
+func listenPrivateSocket(socketPath string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	previous := syscall.Umask(0177)
+	defer syscall.Umask(previous)
+
+	return net.Listen("unix", socketPath)
+}
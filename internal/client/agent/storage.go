@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+)
+
+// timeout of agent's request, local storage is used when agent doesn't answer
+const socketRequestTimeout = time.Second * 2
+
+var _ storage.Storage = &Storage{}
+
+// Storage reads user's items through the running agent.
+// Local storage is used for writes and for reads when agent isn't running.
+type Storage struct {
+	storage.Storage
+
+	client *http.Client
+}
+
+func NewStorage(s storage.Storage, socketPath string) *Storage {
+	dialer := &net.Dialer{}
+
+	return &Storage{
+		Storage: s,
+		client: &http.Client{
+			Timeout: socketRequestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+func (s *Storage) LoadData(ctx context.Context, u *storage.User, name string) (*storage.Record, error) {
+	r, err := getFromAgent[storage.Record](ctx, s.client, u, dataPath, url.Values{"key": {name}})
+	if err != nil {
+		return s.Storage.LoadData(ctx, u, name)
+	}
+
+	return r, nil
+}
+
+func (s *Storage) ListData(ctx context.Context, u *storage.User) ([]*storage.Record, error) {
+	records, err := getFromAgent[[]*storage.Record](ctx, s.client, u, dataListPath, nil)
+	if err != nil {
+		return s.Storage.ListData(ctx, u)
+	}
+
+	return *records, nil
+}
+
+func (s *Storage) GetSecret(ctx context.Context, u *storage.User, secretKey string) (*storage.Secret, error) {
+	secret, err := getFromAgent[storage.Secret](ctx, s.client, u, secretPath, url.Values{"name": {secretKey}})
+	if err != nil {
+		return s.Storage.GetSecret(ctx, u, secretKey)
+	}
+
+	return secret, nil
+}
+
+func (s *Storage) ListCard(ctx context.Context, u *storage.User) ([]*storage.BankCard, error) {
+	cards, err := getFromAgent[[]*storage.BankCard](ctx, s.client, u, cardsPath, nil)
+	if err != nil {
+		return s.Storage.ListCard(ctx, u)
+	}
+
+	return *cards, nil
+}
+
+func getFromAgent[T any](
+	ctx context.Context,
+	client *http.Client,
+	u *storage.User,
+	path string,
+	query url.Values,
+) (*T, error) {
+	uri := url.URL{Scheme: "http", Host: "agent", Path: path, RawQuery: query.Encode()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(loginHeader, u.Login)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("agent request failed code=%d", resp.StatusCode)
+	}
+
+	obj := new(T)
+	if err := json.NewDecoder(resp.Body).Decode(obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
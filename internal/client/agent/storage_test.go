@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageReadsThroughAgent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	agentStorage := storage.NewMockStorage(ctrl)
+	localStorage := storage.NewMockStorage(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	socketPath := makeTestSocketPath(t)
+
+	listener, err := ListenSocket(socketPath)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: newSocketHandler(user, agentStorage)}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	record := &storage.Record{Name: "data", Data: "d", Revision: 2, Metainfo: "m"}
+	secret := &storage.Secret{Name: "secret", Key: "key", Value: "value"}
+
+	agentStorage.EXPECT().LoadData(gomock.Any(), user, record.Name).Return(record, nil)
+	agentStorage.EXPECT().ListData(gomock.Any(), user).Return([]*storage.Record{record}, nil)
+	agentStorage.EXPECT().GetSecret(gomock.Any(), user, secret.Name).Return(secret, nil)
+
+	s := NewStorage(localStorage, socketPath)
+
+	loaded, err := s.LoadData(ctx, user, record.Name)
+	require.NoError(t, err)
+	require.Equal(t, record, loaded)
+
+	list, err := s.ListData(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, []*storage.Record{record}, list)
+
+	loadedSecret, err := s.GetSecret(ctx, user, secret.Name)
+	require.NoError(t, err)
+	require.Equal(t, secret, loadedSecret)
+
+	// agent doesn't serve other users
+	otherUser := &storage.User{Login: "other"}
+	localStorage.EXPECT().LoadData(ctx, otherUser, record.Name).Return(nil, sqlstorage.ErrDataNotExist)
+
+	_, err = s.LoadData(ctx, otherUser, record.Name)
+	require.ErrorIs(t, err, sqlstorage.ErrDataNotExist)
+}
+
+func TestStorageWithoutAgent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	localStorage := storage.NewMockStorage(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	cards := []*storage.BankCard{{Number: "1234"}}
+	localStorage.EXPECT().ListCard(ctx, user).Return(cards, nil)
+
+	s := NewStorage(localStorage, makeTestSocketPath(t))
+
+	loaded, err := s.ListCard(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, cards, loaded)
+}
+
+func TestListenSocketWhenAgentIsRunning(t *testing.T) {
+	socketPath := makeTestSocketPath(t)
+
+	listener, err := ListenSocket(socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	_, err = ListenSocket(socketPath)
+	require.Error(t, err)
+}
+
+func TestListenSocketIsPrivate(t *testing.T) {
+	socketPath := makeTestSocketPath(t)
+
+	listener, err := ListenSocket(socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// makeTestSocketPath returns short path because unix socket's path length is limited
+func makeTestSocketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	return filepath.Join(dir, socketFileName)
+}
@@ -0,0 +1,60 @@
+// Package chunker splits data into content-defined chunks, so a local change of a file changes only its nearby chunks
+package chunker
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+)
+
+// sizes of chunks, boundaries are found by gear rolling hash between min and max sizes, AvgSize is a power of two
+const (
+	MinSize = 16 << 10
+	AvgSize = 64 << 10
+	MaxSize = 256 << 10
+)
+
+// boundary is found when masked bits of the hash are zero, so it's expected after AvgSize bytes behind MinSize
+const mask = AvgSize - 1
+
+// gear must be the same on every client, otherwise chunks of the same file differ and aren't reused
+var gear = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		sum := sha256.Sum256([]byte("goph-keeper gear " + strconv.Itoa(i)))
+		table[i] = binary.LittleEndian.Uint64(sum[:8])
+	}
+
+	return table
+}()
+
+// Split returns chunks of the data, chunks reference the data
+func Split(data []byte) [][]byte {
+	chunks := make([][]byte, 0, len(data)/AvgSize+1)
+
+	for len(data) > 0 {
+		size := boundary(data)
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+
+	return chunks
+}
+
+func boundary(data []byte) int {
+	if len(data) <= MinSize {
+		return len(data)
+	}
+
+	limit := min(len(data), MaxSize)
+
+	var hash uint64
+	for i := MinSize; i < limit; i++ {
+		hash = hash<<1 + gear[data[i]]
+		if hash&mask == 0 {
+			return i + 1
+		}
+	}
+
+	return limit
+}
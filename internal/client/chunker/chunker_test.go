@@ -0,0 +1,55 @@
+package chunker
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	return data
+}
+
+func TestSplit(t *testing.T) {
+	require.Empty(t, Split(nil))
+	require.Equal(t, [][]byte{[]byte("small")}, Split([]byte("small")))
+
+	data := randomData(4 << 20)
+	chunks := Split(data)
+
+	require.Equal(t, data, bytes.Join(chunks, nil))
+
+	for _, chunk := range chunks[:len(chunks)-1] {
+		require.GreaterOrEqual(t, len(chunk), MinSize)
+		require.LessOrEqual(t, len(chunk), MaxSize)
+	}
+}
+
+func TestSplitAfterInsertion(t *testing.T) {
+	data := randomData(4 << 20)
+
+	changed := append(append(append([]byte{}, data[:1<<20]...), []byte("inserted line\n")...), data[1<<20:]...)
+
+	original := make(map[string]struct{})
+	for _, chunk := range Split(data) {
+		original[string(chunk)] = struct{}{}
+	}
+
+	chunks := Split(changed)
+
+	newChunks := 0
+	for _, chunk := range chunks {
+		if _, ok := original[string(chunk)]; !ok {
+			newChunks++
+		}
+	}
+
+	// boundaries are resynchronized after the insertion
+	require.LessOrEqual(t, newChunks, 2)
+	require.Greater(t, len(chunks), 10)
+}
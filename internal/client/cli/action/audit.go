@@ -0,0 +1,53 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// AuditAction prints user's latest audit events from the server, the newest is the first
+func AuditAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.AuditClient,
+	limit int,
+) error {
+	events, err := client.ListAuditEvents(ctx, user.Token, limit)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		fmt.Println(formatAuditEvent(e))
+	}
+
+	return nil
+}
+
+func formatAuditEvent(e *handler.AuditEvent) string {
+	item := "-"
+	if len(e.Kind) != 0 {
+		item = e.Kind
+		if len(e.Key) != 0 {
+			item += "=" + e.Key
+		}
+	}
+
+	actor := "user"
+	if len(e.ServiceAccount) != 0 {
+		actor = "service-account=" + e.ServiceAccount
+	}
+
+	device := e.Device
+	if len(device) == 0 {
+		device = "-"
+	}
+
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\tip: %s; device: %s",
+		e.Time.Local().Format(time.DateTime), e.Action, item, e.Result, actor, e.IP, device)
+}
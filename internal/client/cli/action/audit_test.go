@@ -0,0 +1,43 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := transport.NewMockAuditClient(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	mockClient.EXPECT().ListAuditEvents(ctx, user.Token, 10).Return([]*handler.AuditEvent{{Action: handler.AuditActionLogin}}, nil)
+
+	require.NoError(t, AuditAction(ctx, user, mockClient, 10))
+}
+
+func TestFormatAuditEvent(t *testing.T) {
+	e := &handler.AuditEvent{
+		Time:           time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local),
+		ServiceAccount: "ci",
+		Action:         handler.AuditActionRead,
+		Kind:           handler.ItemKindSecret,
+		Key:            "ci/password",
+		IP:             "10.0.0.1",
+		Result:         handler.AuditResultOK,
+	}
+
+	require.Equal(t,
+		"2024-01-02 03:04:05\tread\tsecret=ci/password\tok\tservice-account=ci\tip: 10.0.0.1; device: -",
+		formatAuditEvent(e),
+	)
+}
@@ -2,36 +2,65 @@ package action
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/kuzhukin/goph-keeper/internal/client/chunker"
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
 	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
 	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 )
 
 func CreateDataAction(
 	ctx context.Context,
 	user *storage.User,
 	s storage.DataStorage,
+	outbox storage.OutboxStorage,
 	client transport.BinaryDataClient,
 	filename string,
+	compression string,
 ) error {
-	r, err := readDataFromFile(filename, user)
+	key, _, err := findFileRecord(ctx, user, s, client, filename)
 	if err != nil {
-		return fmt.Errorf("read data from file, err=%w", err)
+		return err
 	}
 
-	err = s.CreateData(ctx, user, r)
-	if err != nil && !errors.Is(err, sqlstorage.ErrAlreadyExist) {
-		return err
+	r, err := readDataFromFile(filename, key, user, compression, nil)
+	if err != nil {
+		return fmt.Errorf("read data from file, err=%w", err)
 	}
 
-	r.Revision = 1
+	err = runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			if err := s.CreateData(ctx, user, r); err != nil {
+				if errors.Is(err, sqlstorage.ErrAlreadyExist) {
+					return fmt.Errorf("data=%s already exist, use update command, err=%w", r.Name, err)
+				}
+
+				return err
+			}
 
-	err = client.UploadBinaryData(ctx, user, r)
+			return nil
+		},
+		remote: func(ctx context.Context) error {
+			return client.UploadBinaryData(ctx, user, r)
+		},
+		compensate: func(ctx context.Context) error {
+			return client.DeleteBinaryData(ctx, user, r.Name)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeDataOperation(storage.OperationCreate, r)
+		},
+	})
 	if err != nil {
 		return err
 	}
@@ -47,21 +76,22 @@ func GetDataAction(
 	s storage.DataStorage,
 	client transport.BinaryDataClient,
 	filename string,
+	outputDir string,
+	toStdout bool,
+	force bool,
 ) error {
-	var r *storage.Record
-
-	r, err := s.LoadData(ctx, user, filename)
+	key, r, err := findFileRecord(ctx, user, s, client, filename)
 	if err != nil {
-		if errors.Is(err, sqlstorage.ErrDataNotExist) {
-			r, err = client.DownloadBinaryData(ctx, user, filename)
-			if err != nil {
-				return err
-			}
+		return err
+	}
 
-			if err = s.CreateData(ctx, user, r); err != nil {
-				return err
-			}
-		} else {
+	if r == nil {
+		r, err = client.DownloadBinaryData(ctx, user, key)
+		if err != nil {
+			return err
+		}
+
+		if err = s.CreateData(ctx, user, r); err != nil {
 			return err
 		}
 	}
@@ -75,17 +105,47 @@ func GetDataAction(
 		return err
 	}
 
-	fmt.Println(string(decryptedData))
+	if toStdout {
+		_, err = os.Stdout.Write(decryptedData)
+
+		return err
+	}
+
+	path, err := writeDataToFile(user, r, decryptedData, outputDir, force)
+	if err != nil {
+		return fmt.Errorf("write data to file, err=%w", err)
+	}
+
+	fmt.Printf("Data is saved to file=%s\n", path)
 
 	return nil
 }
 
+// ListDataAction prints local records or pages of records on the server when opts is set
 func ListDataAction(
 	ctx context.Context,
 	user *storage.User,
 	s storage.DataStorage,
-	_ transport.BinaryDataClient,
+	client transport.BinaryDataClient,
+	opts *transport.ListOptions,
 ) error {
+	if opts != nil {
+		count, err := printPages(func(cursor string) ([]*handler.DataInfo, string, error) {
+			return client.ListBinaryData(ctx, user, opts, cursor)
+		}, func(info *handler.DataInfo) {
+			fmt.Printf("\t%s (%d) %s\n", info.Name, info.Revision, formatUpdateTime(info.UpdatedAt))
+		})
+		if err != nil {
+			return fmt.Errorf("list data on server, err=%w", err)
+		}
+
+		if count == 0 {
+			fmt.Println("Records isn't exist")
+		}
+
+		return nil
+	}
+
 	records, err := s.ListData(ctx, user)
 	if err != nil {
 		return err
@@ -106,67 +166,341 @@ func UpdateAction(
 	ctx context.Context,
 	user *storage.User,
 	s storage.DataStorage,
+	outbox storage.OutboxStorage,
 	client transport.BinaryDataClient,
 	filename string,
+	compression string,
 ) error {
-	r, err := readDataFromFile(filename, user)
+	// unchanged chunks of the previous revision are reused, so they aren't uploaded again
+	key, previous, err := findFileRecord(ctx, user, s, client, filename)
 	if err != nil {
-		return fmt.Errorf("read data from file, err=%w", err)
+		return fmt.Errorf("load data, err=%w", err)
 	}
 
-	rev, needUpload, err := s.UpdateData(ctx, user, r)
+	if previous == nil {
+		return fmt.Errorf("load data, err=%w", sqlstorage.ErrDataNotExist)
+	}
+
+	r, err := readDataFromFile(filename, key, user, compression, previous)
 	if err != nil {
-		return err
+		return fmt.Errorf("read data from file, err=%w", err)
 	}
 
-	if needUpload {
-		r.Revision = rev
+	err = runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			rev, needUpload, err := s.UpdateData(ctx, user, r)
+			if err != nil {
+				return err
+			}
 
-		err = client.UpdateBinaryData(ctx, user, r)
-		if err != nil {
-			return err
-		}
-	} else {
-		fmt.Println("Nothing for updating")
-	}
+			if !needUpload {
+				fmt.Println("Nothing for updating")
 
-	return nil
+				return errSkipRemote
+			}
+
+			r.Revision = rev
+
+			return nil
+		},
+		remote: func(ctx context.Context) error {
+			return client.UpdateBinaryDataDelta(ctx, user, r, previous.Data)
+		},
+		compensate: func(ctx context.Context) error {
+			// server has incremented revision after update
+			previous.Revision = r.Revision + 1
+
+			return client.UpdateBinaryData(ctx, user, previous)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeDataOperation(storage.OperationUpdate, r)
+		},
+	})
+
+	return err
 }
 
 func DeleteBinaryDataAction(
 	ctx context.Context,
 	user *storage.User,
 	s storage.DataStorage,
+	outbox storage.OutboxStorage,
 	client transport.BinaryDataClient,
 	name string,
 ) error {
-	if err := s.DeleteData(ctx, user, name); err != nil {
+	name, _, err := findFileRecord(ctx, user, s, client, name)
+	if err != nil {
 		return err
 	}
 
-	if err := client.DeleteBinaryData(ctx, user, name); err != nil {
-		return err
+	var previous *storage.Record
+
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
+
+			previous, err = s.LoadData(ctx, user, name)
+			if err != nil && !errors.Is(err, sqlstorage.ErrDataNotExist) {
+				return err
+			}
+
+			return s.DeleteData(ctx, user, name)
+		},
+		remote: func(ctx context.Context) error {
+			return client.DeleteBinaryData(ctx, user, name)
+		},
+		compensate: func(ctx context.Context) error {
+			if previous == nil {
+				return fmt.Errorf("data=%s isn't stored locally", name)
+			}
+
+			return client.UploadBinaryData(ctx, user, previous)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeDataOperation(storage.OperationDelete, &storage.Record{Name: name})
+		},
+	})
+}
+
+func makeDataOperation(operation string, r *storage.Record) *storage.PendingOperation {
+	return &storage.PendingOperation{
+		Kind:      storage.ItemKindData,
+		Operation: operation,
+		Key:       r.Name,
+		Data:      r.Data,
+		Revision:  r.Revision,
+		Metainfo:  r.Metainfo,
 	}
+}
 
-	return nil
+// findFileRecord returns key and local record of the file's data, record is nil when it isn't stored locally.
+// Older clients keyed records by raw paths, e.g. ./file.txt, so the raw path is used when only it has a record
+// locally or on the server, the normalized key is used otherwise
+func findFileRecord(
+	ctx context.Context,
+	user *storage.User,
+	s storage.DataStorage,
+	client transport.BinaryDataClient,
+	filename string,
+) (string, *storage.Record, error) {
+	key := normalizeKey(filename)
+
+	for _, name := range []string{key, filename} {
+		r, err := s.LoadData(ctx, user, name)
+		if err == nil {
+			return name, r, nil
+		}
+
+		if !errors.Is(err, sqlstorage.ErrDataNotExist) {
+			return "", nil, err
+		}
+
+		if key == filename {
+			return key, nil, nil
+		}
+	}
+
+	exists, err := remoteDataExists(ctx, user, client, filename)
+	if err != nil && !errors.Is(err, transport.ErrServerUnavailable) {
+		return "", nil, err
+	}
+
+	if exists {
+		return filename, nil, nil
+	}
+
+	return key, nil, nil
 }
 
-func readDataFromFile(filename string, user *storage.User) (*storage.Record, error) {
+// remoteDataExists checks that the server has the record, the name is the first one of records with its prefix
+func remoteDataExists(ctx context.Context, user *storage.User, client transport.BinaryDataClient, name string) (bool, error) {
+	list, _, err := client.ListBinaryData(ctx, user, &transport.ListOptions{Prefix: name, PageSize: 1}, "")
+	if err != nil {
+		return false, fmt.Errorf("list data on server, err=%w", err)
+	}
+
+	return len(list) > 0 && list[0].Name == name, nil
+}
+
+// normalizeKey makes record's key from file's path: cleaned relative path with slash separators
+func normalizeKey(path string) string {
+	key := filepath.ToSlash(filepath.Clean(path))
+
+	return strings.TrimPrefix(key, "/")
+}
+
+// readDataFromFile encrypts file's data which is compressed by the algorithm when it's set,
+// unchanged chunks of the previous revision are kept when it's set
+func readDataFromFile(
+	filename string, key string, user *storage.User, compression string, previous *storage.Record,
+) (*storage.Record, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	encryptedData, err := encryptUserData(user, data)
+	encryptedData, chunks, err := encryptUserData(user, data, compression, previous)
 	if err != nil {
 		return nil, err
 	}
 
-	r := &storage.Record{Name: filename, Data: string(encryptedData), Revision: 1}
+	metainfo, err := encryptFileInfo(user, &storage.FileInfo{
+		Path:    key,
+		Mode:    stat.Mode().Perm(),
+		ModTime: stat.ModTime(),
+		Hash:    contentHash(data),
+		Chunks:  chunks,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &storage.Record{
+		Name:        key,
+		Data:        string(encryptedData),
+		Revision:    1,
+		Metainfo:    metainfo,
+		ContentHash: gophcrypto.ContentHash(user.CryptoKey, data),
+	}
 
 	return r, nil
 }
 
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// writeDataToFile restores record's file in output directory by the path from record's metainfo
+func writeDataToFile(
+	user *storage.User,
+	r *storage.Record,
+	data []byte,
+	outputDir string,
+	overwrite bool,
+) (string, error) {
+	info, err := recordFileInfo(user, r)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := makeOutputPath(outputDir, filepath.FromSlash(info.Path))
+	if err != nil {
+		return "", err
+	}
+
+	return path, writeFile(path, data, info, overwrite)
+}
+
+// recordFileInfo decrypts record's metainfo, records without it were uploaded by older clients
+func recordFileInfo(user *storage.User, r *storage.Record) (*storage.FileInfo, error) {
+	const defaultFileMode os.FileMode = 0600
+
+	if len(r.Metainfo) == 0 {
+		return &storage.FileInfo{Path: r.Name, Mode: defaultFileMode}, nil
+	}
+
+	info, err := decryptFileInfo(user, r.Metainfo)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt metainfo, err=%w", err)
+	}
+
+	return info, nil
+}
+
+// writeFile writes data with permissions and modification time from the file info,
+// existing file is only replaced when overwrite is set
+func writeFile(path string, data []byte, info *storage.FileInfo, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	f, err := os.OpenFile(path, flags, info.Mode)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("file=%s exists, use --force to overwrite it, err=%w", path, err)
+		}
+
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// file mode of existing file isn't changed by OpenFile
+	if err := os.Chmod(path, info.Mode); err != nil {
+		return err
+	}
+
+	if !info.ModTime.IsZero() {
+		if err := os.Chtimes(path, info.ModTime, info.ModTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// makeOutputPath places the file into output directory or current directory when it's empty,
+// path which escapes from the directory is replaced by its base name
+func makeOutputPath(outputDir string, originalPath string) (string, error) {
+	relPath := filepath.Clean(originalPath)
+	if !filepath.IsLocal(relPath) {
+		relPath = filepath.Base(relPath)
+	}
+
+	if !filepath.IsLocal(relPath) || relPath == "." {
+		return "", fmt.Errorf("file path=%s can't be placed in output directory", originalPath)
+	}
+
+	return filepath.Join(outputDir, relPath), nil
+}
+
+func encryptFileInfo(user *storage.User, info *storage.FileInfo) (string, error) {
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	if err != nil {
+		return "", err
+	}
+
+	return sqlstorage.NewSerializer(crypto).SerializeFileInfo(info)
+}
+
+func decryptFileInfo(user *storage.User, metainfo string) (*storage.FileInfo, error) {
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlstorage.NewSerializer(crypto).DeserializeFileInfo(metainfo)
+}
+
+// chunkedDataMinSize is size of files which are encrypted by chunks, so their updates upload only changed chunks
+const chunkedDataMinSize = 1 << 20
+
+// chunked data formats, chunks of the first format were encrypted with the fixed nonce by older clients
+const (
+	chunksFormatFixedNonce  = "c1"
+	chunksFormatRandomNonce = "c2"
+)
+
+// decryptUserData decrypts chunks of chunked data and decompresses data which was compressed before encryption
 func decryptUserData(
 	user *storage.User,
 	data []byte,
@@ -176,17 +510,120 @@ func decryptUserData(
 		return nil, err
 	}
 
-	return crypto.Decrypt(data)
+	format, chunks, ok := handler.SplitChunks(string(data))
+	if !ok {
+		return decryptPart(crypto.Decrypt, data)
+	}
+
+	var decrypt func([]byte) ([]byte, error)
+
+	switch format {
+	case chunksFormatFixedNonce:
+		decrypt = crypto.Decrypt
+	case chunksFormatRandomNonce:
+		decrypt = crypto.DecryptWithRandomNonce
+	default:
+		return nil, fmt.Errorf("unknown chunks format=%s", format)
+	}
+
+	decrypted := make([]byte, 0, len(data))
+	for i, chunk := range chunks {
+		part, err := decryptPart(decrypt, []byte(chunk))
+		if err != nil {
+			return nil, fmt.Errorf("chunk=%d, err=%w", i, err)
+		}
+
+		decrypted = append(decrypted, part...)
+	}
+
+	return decrypted, nil
 }
 
+func decryptPart(decrypt func([]byte) ([]byte, error), data []byte) ([]byte, error) {
+	decrypted, err := decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return compress.Unpack(decrypted)
+}
+
+// encryptUserData encrypts large data by chunks, every chunk is compressed separately and encrypted with a random
+// nonce, chunks which have the same keyed hash in the previous revision aren't encrypted again.
+// Keyed hashes of the chunks are returned for the record's metainfo
 func encryptUserData(
 	user *storage.User,
 	data []byte,
-) ([]byte, error) {
+	compression string,
+	previous *storage.Record,
+) ([]byte, []string, error) {
 	crypto, err := gophcrypto.New([]byte(user.CryptoKey))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if len(data) < chunkedDataMinSize {
+		packed, err := compress.Pack(data, compression)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []byte(crypto.Encrypt(packed)), nil, nil
+	}
+
+	previousChunks := makePreviousChunks(user, previous)
+	parts := chunker.Split(data)
+
+	chunks := make([]string, 0, len(parts))
+	hashes := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		hash := gophcrypto.ChunkHash(user.CryptoKey, part)
+		hashes = append(hashes, hash)
+
+		if chunk, ok := previousChunks[hash]; ok {
+			chunks = append(chunks, chunk)
+
+			continue
+		}
+
+		packed, err := compress.Pack(part, compression)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encrypted, err := crypto.EncryptWithRandomNonce(packed)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		chunks = append(chunks, encrypted)
+	}
+
+	return []byte(handler.JoinChunks(chunksFormatRandomNonce, chunks)), hashes, nil
+}
+
+// makePreviousChunks returns encrypted chunks of the previous revision by keyed hashes of their plain data,
+// revision without hashes in metainfo is encrypted again
+func makePreviousChunks(user *storage.User, previous *storage.Record) map[string]string {
+	if previous == nil || len(previous.Metainfo) == 0 {
+		return nil
+	}
+
+	format, chunks, ok := handler.SplitChunks(previous.Data)
+	if !ok || format != chunksFormatRandomNonce {
+		return nil
+	}
+
+	info, err := decryptFileInfo(user, previous.Metainfo)
+	if err != nil || len(info.Chunks) != len(chunks) {
+		return nil
+	}
+
+	byHash := make(map[string]string, len(chunks))
+	for i, hash := range info.Chunks {
+		byHash[hash] = chunks[i]
 	}
 
-	return []byte(crypto.Encrypt(data)), nil
+	return byHash
 }
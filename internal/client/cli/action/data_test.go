@@ -2,18 +2,33 @@ package action
 
 import (
 	"context"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
+	"github.com/kuzhukin/goph-keeper/internal/client/config"
 	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
 	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/middleware"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage/memstorage"
 	"github.com/stretchr/testify/require"
 )
 
-const testFileName = "./test.txt"
+const (
+	testFileName = "./test.txt"
+	testFileKey  = "test.txt"
+)
 
 func TestCreateData(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -21,18 +36,23 @@ func TestCreateData(t *testing.T) {
 
 	mockDataStorage := storage.NewMockDataStorage(ctrl)
 	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, data := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
-	record := &storage.Record{Name: testFileName, Data: data, Revision: 1}
+	record := &storage.Record{
+		Name: testFileKey, Data: data, Revision: 1, Metainfo: getFileMetainfo(t, user), ContentHash: getFileContentHash(t, user),
+	}
 
 	ctx := context.Background()
 
+	expectNewFileKey(ctx, user, mockDataStorage, mockClient)
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
 	mockDataStorage.EXPECT().CreateData(ctx, user, record).Return(nil)
 	mockClient.EXPECT().UploadBinaryData(ctx, user, record).Return(nil)
 
-	err := CreateDataAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	err := CreateDataAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName, compress.AlgorithmNone)
 	require.NoError(t, err)
 }
 
@@ -46,13 +66,13 @@ func TestGetExistingData(t *testing.T) {
 	key, data := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
-	record := &storage.Record{Name: testFileName, Data: data, Revision: 1}
+	record := &storage.Record{Name: testFileKey, Data: data, Revision: 1}
 
 	ctx := context.Background()
 
-	mockDataStorage.EXPECT().LoadData(ctx, user, testFileName).Return(record, nil)
+	mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(record, nil)
 
-	err := GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	err := GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName, "", true, false)
 	require.NoError(t, err)
 }
 
@@ -66,16 +86,108 @@ func TestGetDataFromServer(t *testing.T) {
 	key, data := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
-	record := &storage.Record{Name: testFileName, Data: data, Revision: 1}
+	record := &storage.Record{Name: testFileKey, Data: data, Revision: 1}
 
 	ctx := context.Background()
 
-	mockDataStorage.EXPECT().LoadData(ctx, user, testFileName).Return(nil, sqlstorage.ErrDataNotExist)
-	mockClient.EXPECT().DownloadBinaryData(ctx, user, testFileName).Return(record, nil)
+	expectNewFileKey(ctx, user, mockDataStorage, mockClient)
+	mockClient.EXPECT().DownloadBinaryData(ctx, user, testFileKey).Return(record, nil)
 	mockDataStorage.EXPECT().CreateData(ctx, user, record).Return(nil)
 
-	err := GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	err := GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName, "", true, false)
+	require.NoError(t, err)
+}
+
+func TestGetDataToFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+
+	key, data := getCryptoKeyAndData(t)
+
+	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
+
+	modTime := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	metainfo, err := encryptFileInfo(user, &storage.FileInfo{Path: "dir/file.txt", Mode: 0640, ModTime: modTime})
+	require.NoError(t, err)
+
+	record := &storage.Record{Name: "dir/file.txt", Data: data, Revision: 1, Metainfo: metainfo}
+
+	ctx := context.Background()
+	outputDir := t.TempDir()
+
+	mockDataStorage.EXPECT().LoadData(ctx, user, record.Name).Return(record, nil)
+
+	err = GetDataAction(ctx, user, mockDataStorage, mockClient, record.Name, outputDir, false, false)
+	require.NoError(t, err)
+
+	path := filepath.Join(outputDir, "dir", "file.txt")
+
+	expected, err := os.ReadFile(testFileName)
+	require.NoError(t, err)
+
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	require.True(t, modTime.Equal(stat.ModTime()))
+
+	// existing file is only overwritten with force
+	require.NoError(t, os.WriteFile(path, []byte("changed"), 0640))
+
+	mockDataStorage.EXPECT().LoadData(ctx, user, record.Name).Return(record, nil).Times(2)
+
+	err = GetDataAction(ctx, user, mockDataStorage, mockClient, record.Name, outputDir, false, false)
+	require.ErrorIs(t, err, fs.ErrExist)
+
+	actual, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []byte("changed"), actual)
+
+	err = GetDataAction(ctx, user, mockDataStorage, mockClient, record.Name, outputDir, false, true)
 	require.NoError(t, err)
+
+	actual, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestNormalizeKey(t *testing.T) {
+	require.Equal(t, "test.txt", normalizeKey("./test.txt"))
+	require.Equal(t, "dir/test.txt", normalizeKey("dir/../dir/test.txt"))
+	require.Equal(t, "etc/test.txt", normalizeKey("/etc/test.txt"))
+}
+
+func TestMakeOutputPath(t *testing.T) {
+	tests := []struct {
+		outputDir string
+		path      string
+		expected  string
+	}{
+		{outputDir: "", path: "./dir/file.txt", expected: "dir/file.txt"},
+		{outputDir: "", path: "../file.txt", expected: "file.txt"},
+		{outputDir: "", path: "/etc/file.txt", expected: "file.txt"},
+		{outputDir: "out", path: "dir/file.txt", expected: "out/dir/file.txt"},
+		{outputDir: "out", path: "/etc/file.txt", expected: "out/file.txt"},
+		{outputDir: "out", path: "../file.txt", expected: "out/file.txt"},
+		{outputDir: "out", path: "dir/../../file.txt", expected: "out/file.txt"},
+	}
+
+	for _, test := range tests {
+		path, err := makeOutputPath(test.outputDir, test.path)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, path)
+	}
+
+	for _, path := range []string{"..", "/", "."} {
+		_, err := makeOutputPath("", path)
+		require.Error(t, err, path)
+	}
 }
 
 func TestListDataAction(t *testing.T) {
@@ -89,14 +201,36 @@ func TestListDataAction(t *testing.T) {
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
 	records := []*storage.Record{
-		{Name: testFileName, Data: data, Revision: 1},
+		{Name: testFileKey, Data: data, Revision: 1},
 	}
 
 	ctx := context.Background()
 
 	mockDataStorage.EXPECT().ListData(ctx, user).Return(records, nil)
 
-	err := ListDataAction(ctx, user, mockDataStorage, mockClient)
+	err := ListDataAction(ctx, user, mockDataStorage, mockClient, nil)
+	require.NoError(t, err)
+}
+
+func TestListRemoteDataAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+
+	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true}
+	opts := &transport.ListOptions{Prefix: "dir/", PageSize: 1}
+
+	ctx := context.Background()
+
+	// pages are requested until the last one
+	gomock.InOrder(
+		mockClient.EXPECT().ListBinaryData(ctx, user, opts, "").Return([]*handler.DataInfo{{Name: "dir/a", Revision: 1}}, "next", nil),
+		mockClient.EXPECT().ListBinaryData(ctx, user, opts, "next").Return([]*handler.DataInfo{{Name: "dir/b", Revision: 2}}, "", nil),
+	)
+
+	err := ListDataAction(ctx, user, mockDataStorage, mockClient, opts)
 	require.NoError(t, err)
 }
 
@@ -106,23 +240,26 @@ func TestUpdateData(t *testing.T) {
 
 	mockDataStorage := storage.NewMockDataStorage(ctrl)
 	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, data := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
 	record := &storage.Record{
-		Name: testFileName, Data: data, Revision: 1,
+		Name: testFileKey, Data: data, Revision: 1, Metainfo: getFileMetainfo(t, user), ContentHash: getFileContentHash(t, user),
 	}
 
 	ctx := context.Background()
 
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(record, nil)
 	mockDataStorage.EXPECT().UpdateData(ctx, user, record).Return(uint64(2), true, nil)
 	sendingRecord := &storage.Record{
-		Name: testFileName, Data: data, Revision: 2,
+		Name: testFileKey, Data: data, Revision: 2, Metainfo: record.Metainfo, ContentHash: record.ContentHash,
 	}
-	mockClient.EXPECT().UpdateBinaryData(ctx, user, sendingRecord).Return(nil)
+	mockClient.EXPECT().UpdateBinaryDataDelta(ctx, user, sendingRecord, data).Return(nil)
 
-	err := UpdateAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	err := UpdateAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName, compress.AlgorithmNone)
 	require.NoError(t, err)
 }
 
@@ -132,19 +269,22 @@ func TestUpdateDataNotNeed(t *testing.T) {
 
 	mockDataStorage := storage.NewMockDataStorage(ctrl)
 	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, data := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
 	record := &storage.Record{
-		Name: testFileName, Data: data, Revision: 1,
+		Name: testFileKey, Data: data, Revision: 1, Metainfo: getFileMetainfo(t, user), ContentHash: getFileContentHash(t, user),
 	}
 
 	ctx := context.Background()
 
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(record, nil)
 	mockDataStorage.EXPECT().UpdateData(ctx, user, record).Return(uint64(1), false, nil)
 
-	err := UpdateAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	err := UpdateAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName, compress.AlgorithmNone)
 	require.NoError(t, err)
 }
 
@@ -154,17 +294,93 @@ func TestDeleteData(t *testing.T) {
 
 	mockDataStorage := storage.NewMockDataStorage(ctrl)
 	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, _ := getCryptoKeyAndData(t)
 
 	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
 	ctx := context.Background()
 
-	mockDataStorage.EXPECT().DeleteData(ctx, user, testFileName).Return(nil)
-	mockClient.EXPECT().DeleteBinaryData(ctx, user, testFileName).Return(nil)
+	expectNewFileKey(ctx, user, mockDataStorage, mockClient)
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(nil, sqlstorage.ErrDataNotExist)
+	mockDataStorage.EXPECT().DeleteData(ctx, user, testFileKey).Return(nil)
+	mockClient.EXPECT().DeleteBinaryData(ctx, user, testFileKey).Return(nil)
+
+	err := DeleteBinaryDataAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName)
+	require.NoError(t, err)
+}
+
+func TestRawPathKeyedData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	key, data := getCryptoKeyAndData(t)
+
+	user := &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
+	ctx := context.Background()
+
+	// records of older clients are keyed by raw paths
+	record := &storage.Record{Name: testFileName, Data: data, Revision: 1}
+
+	t.Run("stored locally", func(t *testing.T) {
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(nil, sqlstorage.ErrDataNotExist)
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileName).Return(record, nil)
+
+		require.NoError(t, GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName, "", true, false))
+
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(nil, sqlstorage.ErrDataNotExist)
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileName).Return(record, nil).Times(2)
+		mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+		mockDataStorage.EXPECT().DeleteData(ctx, user, testFileName).Return(nil)
+		mockClient.EXPECT().DeleteBinaryData(ctx, user, testFileName).Return(nil)
+
+		require.NoError(t, DeleteBinaryDataAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName))
+	})
+
+	t.Run("stored on server", func(t *testing.T) {
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileKey).Return(nil, sqlstorage.ErrDataNotExist)
+		mockDataStorage.EXPECT().LoadData(ctx, user, testFileName).Return(nil, sqlstorage.ErrDataNotExist)
+		mockClient.EXPECT().ListBinaryData(ctx, user, &transport.ListOptions{Prefix: testFileName, PageSize: 1}, "").
+			Return([]*handler.DataInfo{{Name: testFileName, Revision: 1}}, "", nil)
+		mockClient.EXPECT().DownloadBinaryData(ctx, user, testFileName).Return(record, nil)
+		mockDataStorage.EXPECT().CreateData(ctx, user, record).Return(nil)
+
+		require.NoError(t, GetDataAction(ctx, user, mockDataStorage, mockClient, testFileName, "", true, false))
+	})
+}
+
+// expectNewFileKey expects lookups of the test file's record which isn't stored by older clients
+func expectNewFileKey(ctx context.Context, user *storage.User, s *storage.MockDataStorage, client *transport.MockBinaryDataClient) {
+	s.EXPECT().LoadData(ctx, user, testFileKey).Return(nil, sqlstorage.ErrDataNotExist)
+	s.EXPECT().LoadData(ctx, user, testFileName).Return(nil, sqlstorage.ErrDataNotExist)
+	client.EXPECT().ListBinaryData(ctx, user, &transport.ListOptions{Prefix: testFileName, PageSize: 1}, "").Return(nil, "", nil)
+}
+
+func getFileMetainfo(t *testing.T, user *storage.User) string {
+	stat, err := os.Stat(testFileName)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(testFileName)
+	require.NoError(t, err)
+
+	info := &storage.FileInfo{Path: testFileKey, Mode: stat.Mode().Perm(), ModTime: stat.ModTime(), Hash: contentHash(data)}
 
-	err := DeleteBinaryDataAction(ctx, user, mockDataStorage, mockClient, testFileName)
+	metainfo, err := encryptFileInfo(user, info)
+	require.NoError(t, err)
+
+	return metainfo
+}
+
+func getFileContentHash(t *testing.T, user *storage.User) string {
+	data, err := os.ReadFile(testFileName)
 	require.NoError(t, err)
+
+	return gophcrypto.ContentHash(user.CryptoKey, data)
 }
 
 func getCryptoKeyAndData(t *testing.T) ([]byte, string) {
@@ -179,3 +395,168 @@ func getCryptoKeyAndData(t *testing.T) ([]byte, string) {
 
 	return key, c.Encrypt(data)
 }
+
+func TestCompressedUserData(t *testing.T) {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	user := &storage.User{Login: "login", CryptoKey: key}
+	data := []byte(strings.Repeat("key = value\n", 100))
+
+	plain, _, err := encryptUserData(user, data, compress.AlgorithmNone, nil)
+	require.NoError(t, err)
+
+	for _, algorithm := range []string{compress.AlgorithmGzip, compress.AlgorithmZstd} {
+		encrypted, _, err := encryptUserData(user, data, algorithm, nil)
+		require.NoError(t, err)
+		require.Less(t, len(encrypted), len(plain))
+
+		decrypted, err := decryptUserData(user, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+	}
+
+	// data which was encrypted without envelope is read as is
+	decrypted, err := decryptUserData(user, plain)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+}
+
+func TestChunkedUserData(t *testing.T) {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	user := &storage.User{Login: "login", CryptoKey: key}
+
+	data := make([]byte, 4*chunkedDataMinSize)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	encrypted, hashes, err := encryptUserData(user, data, compress.AlgorithmZstd, nil)
+	require.NoError(t, err)
+
+	format, chunks, ok := handler.SplitChunks(string(encrypted))
+	require.True(t, ok)
+	require.Equal(t, chunksFormatRandomNonce, format)
+	require.Len(t, hashes, len(chunks))
+
+	decrypted, err := decryptUserData(user, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	// equal data isn't encrypted to equal chunks without the previous revision
+	again, _, err := encryptUserData(user, data, compress.AlgorithmZstd, nil)
+	require.NoError(t, err)
+
+	_, againChunks, _ := handler.SplitChunks(string(again))
+	for i := range chunks {
+		require.NotEqual(t, chunks[i], againChunks[i])
+	}
+
+	metainfo, err := encryptFileInfo(user, &storage.FileInfo{Path: "key", Chunks: hashes})
+	require.NoError(t, err)
+
+	previous := &storage.Record{Name: "key", Data: string(encrypted), Metainfo: metainfo}
+
+	// changed line changes only its chunk, other chunks of the previous revision are kept
+	copy(data[len(data)/2:], "changed line\n")
+
+	changed, changedHashes, err := encryptUserData(user, data, compress.AlgorithmZstd, previous)
+	require.NoError(t, err)
+
+	_, changedChunks, ok := handler.SplitChunks(string(changed))
+	require.True(t, ok)
+	require.Len(t, changedChunks, len(chunks))
+
+	differs := 0
+	for i := range chunks {
+		if chunks[i] != changedChunks[i] {
+			differs++
+
+			require.NotEqual(t, hashes[i], changedHashes[i])
+		}
+	}
+	require.Equal(t, 1, differs)
+
+	decrypted, err = decryptUserData(user, changed)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+}
+
+func TestUploadEqualFilesOnce(t *testing.T) {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	user := &storage.User{Login: "login", Password: "password", Token: "token", CryptoKey: key}
+
+	ctx := context.Background()
+
+	serverStorage := memstorage.New()
+	require.NoError(t, serverStorage.Register(ctx, &handler.User{Login: user.Login, Password: user.Password, Token: user.Token}))
+
+	dataHandler := handler.NewDataHandler(
+		serverStorage, handler.NewChangeNotifier(), serverStorage, handler.NewQuotaController(&handler.Quota{}, serverStorage),
+	)
+
+	uploads := 0
+	srvr := httptest.NewServer(middleware.NewAuthMiddleware(serverStorage, serverStorage).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uploads++
+			dataHandler.ServeHTTP(w, r)
+		}),
+	))
+	defer srvr.Close()
+
+	client := transport.NewClient(&config.Config{Hostport: srvr.URL})
+
+	data := make([]byte, 2*chunkedDataMinSize)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "file.bin", string(data))
+
+	// chunks are encrypted with random nonces and compressed differently, but content is the same
+	first, err := readDataFromFile(filepath.Join(dir, "file.bin"), "first.bin", user, compress.AlgorithmNone, nil)
+	require.NoError(t, err)
+
+	second, err := readDataFromFile(filepath.Join(dir, "file.bin"), "second.bin", user, compress.AlgorithmZstd, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Data, second.Data)
+
+	require.NoError(t, client.UploadBinaryData(ctx, user, first))
+	require.NoError(t, client.UploadBinaryData(ctx, user, second))
+
+	// the first data is uploaded after its hash isn't found, the second one is only referenced by the hash
+	require.Equal(t, 3, uploads)
+
+	usage, err := serverStorage.Usage(ctx, user.Token)
+	require.NoError(t, err)
+	require.Equal(t, 2, usage.Items)
+	require.Equal(t, int64(len(first.Data)), usage.Bytes)
+
+	downloaded, err := client.DownloadBinaryData(ctx, user, "second.bin")
+	require.NoError(t, err)
+
+	decrypted, err := decryptUserData(user, []byte(downloaded.Data))
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+}
+
+func TestFixedNonceChunkedUserData(t *testing.T) {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	user := &storage.User{Login: "login", CryptoKey: key}
+
+	c, err := gophcrypto.New(key)
+	require.NoError(t, err)
+
+	// chunks of older clients are decrypted with the fixed nonce
+	encrypted := handler.JoinChunks(chunksFormatFixedNonce, []string{c.Encrypt([]byte("first ")), c.Encrypt([]byte("second"))})
+
+	decrypted, err := decryptUserData(user, []byte(encrypted))
+	require.NoError(t, err)
+	require.Equal(t, []byte("first second"), decrypted)
+
+	_, err = decryptUserData(user, []byte(handler.JoinChunks("c0", []string{c.Encrypt([]byte("first"))})))
+	require.Error(t, err)
+}
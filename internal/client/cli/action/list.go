@@ -0,0 +1,37 @@
+package action
+
+import (
+	"fmt"
+	"time"
+)
+
+// remoteTimeFormat is a format of items' update times on the server
+const remoteTimeFormat = time.DateTime
+
+// printPages prints pages of the server's list while they are received, it returns the number of printed items
+func printPages[T any](listPage func(cursor string) ([]T, string, error), print func(T)) (int, error) {
+	count := 0
+	cursor := ""
+
+	for {
+		page, next, err := listPage(cursor)
+		if err != nil {
+			return count, err
+		}
+
+		for _, item := range page {
+			print(item)
+		}
+
+		count += len(page)
+		if len(next) == 0 {
+			return count, nil
+		}
+
+		cursor = next
+	}
+}
+
+func formatUpdateTime(t time.Time) string {
+	return fmt.Sprintf("updated: %s", t.Local().Format(remoteTimeFormat))
+}
@@ -0,0 +1,203 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+)
+
+// StatusAction prints operations which are waiting for sending to the server and operations rejected by it,
+// rejected operations are removed from the outbox when discardFailed is set
+func StatusAction(
+	ctx context.Context,
+	user *storage.User,
+	outbox storage.OutboxStorage,
+	discardFailed bool,
+) error {
+	ops, err := outbox.ListPendingOperations(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	pending := make([]*storage.PendingOperation, 0, len(ops))
+	failed := make([]*storage.PendingOperation, 0)
+
+	for _, op := range ops {
+		if len(op.Error) > 0 {
+			failed = append(failed, op)
+		} else {
+			pending = append(pending, op)
+		}
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("All changes are synchronized with server")
+	} else {
+		fmt.Printf("Pending operations (%d):\n", len(pending))
+
+		for _, op := range pending {
+			fmt.Printf("\t%d: %s %s %s (%s)\n", op.ID, op.Operation, op.Kind, op.Key, op.CreatedAt.Format(time.DateTime))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Operations rejected by server (%d):\n", len(failed))
+
+	for _, op := range failed {
+		fmt.Printf("\t%d: %s %s %s (%s): %s\n", op.ID, op.Operation, op.Kind, op.Key, op.CreatedAt.Format(time.DateTime), op.Error)
+
+		if discardFailed {
+			if err := outbox.DeletePendingOperation(ctx, user, op.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if discardFailed {
+		fmt.Println("Rejected operations are discarded")
+	} else {
+		fmt.Println("Use --discard-failed to remove them")
+	}
+
+	return nil
+}
+
+// ReplayOutboxAction sends pending operations to the server in order of their creation.
+// Replaying is stopped when the server isn't available, unsent operations stay in the outbox.
+// Operations rejected by the server are marked as failed and don't block the next ones.
+func ReplayOutboxAction(
+	ctx context.Context,
+	user *storage.User,
+	outbox storage.OutboxStorage,
+	client transport.ItemsClient,
+) error {
+	ops, err := outbox.ListPendingOperations(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if len(op.Error) > 0 {
+			continue
+		}
+
+		err := sendPendingOperation(ctx, user, client, op)
+		if errors.Is(err, transport.ErrServerUnavailable) {
+			return nil
+		}
+
+		if err != nil && !isAppliedOperation(op, err) {
+			if !isRejectedOperation(err) {
+				return fmt.Errorf("replay %s %s %s, err=%w", op.Operation, op.Kind, op.Key, err)
+			}
+
+			if err := outbox.FailPendingOperation(ctx, user, op.ID, err.Error()); err != nil {
+				return err
+			}
+
+			fmt.Printf("Pending operation is rejected by server: %s %s %s: %s\n", op.Operation, op.Kind, op.Key, err)
+
+			continue
+		}
+
+		if err := outbox.DeletePendingOperation(ctx, user, op.ID); err != nil {
+			return err
+		}
+
+		fmt.Printf("Pending operation is sent to server: %s %s %s\n", op.Operation, op.Kind, op.Key)
+	}
+
+	return nil
+}
+
+// isAppliedOperation checks that server already has the operation's result,
+// e.g. the operation was applied, but its response was lost
+func isAppliedOperation(op *storage.PendingOperation, err error) bool {
+	switch op.Operation {
+	case storage.OperationCreate:
+		return errors.Is(err, transport.ErrAlreadyExists)
+	case storage.OperationDelete:
+		return errors.Is(err, transport.ErrNotFound)
+	default:
+		return false
+	}
+}
+
+// isRejectedOperation checks that the operation will never be accepted by the server,
+// authentication and rate limit errors aren't operation's errors, so replay is retried later
+func isRejectedOperation(err error) bool {
+	apiErr := &transport.APIError{}
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusTooManyRequests:
+		return false
+	default:
+		return apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+	}
+}
+
+func sendPendingOperation(
+	ctx context.Context,
+	user *storage.User,
+	client transport.ItemsClient,
+	op *storage.PendingOperation,
+) error {
+	record := &storage.Record{Name: op.Key, Data: op.Data, Revision: op.Revision, Metainfo: op.Metainfo}
+
+	switch op.Kind + "/" + op.Operation {
+	case storage.ItemKindData + "/" + storage.OperationCreate:
+		return client.UploadBinaryData(ctx, user, record)
+	case storage.ItemKindData + "/" + storage.OperationUpdate:
+		return client.UpdateBinaryData(ctx, user, record)
+	case storage.ItemKindData + "/" + storage.OperationDelete:
+		return client.DeleteBinaryData(ctx, user, op.Key)
+	case storage.ItemKindCard + "/" + storage.OperationCreate:
+		return client.CreateCardData(ctx, user.Token, op.Key, op.Data)
+	case storage.ItemKindCard + "/" + storage.OperationDelete:
+		return client.DeleteCardData(ctx, user.Token, op.Key)
+	case storage.ItemKindSecret + "/" + storage.OperationCreate:
+		return client.CreateSecret(ctx, user.Token, op.Key, op.Data)
+	case storage.ItemKindSecret + "/" + storage.OperationDelete:
+		return client.DeleteSecret(ctx, user.Token, op.Key)
+	case storage.ItemKindSSHKey + "/" + storage.OperationCreate:
+		return client.CreateSSHKey(ctx, user.Token, op.Key, op.Data)
+	case storage.ItemKindSSHKey + "/" + storage.OperationDelete:
+		return client.DeleteSSHKey(ctx, user.Token, op.Key)
+	default:
+		return fmt.Errorf("unknown operation %s with %s", op.Operation, op.Kind)
+	}
+}
+
+// queueIfUnavailable saves the operation to the outbox when the server isn't available
+func queueIfUnavailable(
+	ctx context.Context,
+	user *storage.User,
+	outbox storage.OutboxStorage,
+	op *storage.PendingOperation,
+	err error,
+) error {
+	if err == nil || !errors.Is(err, transport.ErrServerUnavailable) {
+		return err
+	}
+
+	op.CreatedAt = time.Now()
+
+	if err := outbox.AddPendingOperation(ctx, user, op); err != nil {
+		return fmt.Errorf("add pending operation, err=%w", err)
+	}
+
+	fmt.Println("Server is unavailable, operation will be sent on next connection. See status command.")
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestUnavailable = fmt.Errorf("request error: %w", transport.ErrServerUnavailable)
+
+func TestCreateCardQueuedWhenServerUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockWalletStorage(ctrl)
+	mockClient := transport.NewMockWalletClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+	c := &storage.BankCard{Number: "xxxx"}
+
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockStorage.EXPECT().CreateCard(ctx, u, c).Return("crypted_data", nil)
+	mockClient.EXPECT().CreateCardData(ctx, u.Token, c.Number, "crypted_data").Return(errTestUnavailable)
+	mockOutbox.EXPECT().AddPendingOperation(ctx, u, gomock.Any()).DoAndReturn(func(_ context.Context, _ *storage.User, op *storage.PendingOperation) error {
+		require.Equal(t, storage.ItemKindCard, op.Kind)
+		require.Equal(t, storage.OperationCreate, op.Operation)
+		require.Equal(t, c.Number, op.Key)
+		require.Equal(t, "crypted_data", op.Data)
+
+		return nil
+	})
+
+	err := CreateCardActionHandler(ctx, u, mockStorage, mockOutbox, mockClient, c)
+	require.NoError(t, err)
+}
+
+func TestCreateCardNotQueuedOnServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockWalletStorage(ctrl)
+	mockClient := transport.NewMockWalletClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+	c := &storage.BankCard{Number: "xxxx"}
+
+	serverErr := errors.New("request failed code=500")
+
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockStorage.EXPECT().CreateCard(ctx, u, c).Return("crypted_data", nil)
+	mockClient.EXPECT().CreateCardData(ctx, u.Token, c.Number, "crypted_data").Return(serverErr)
+
+	err := CreateCardActionHandler(ctx, u, mockStorage, mockOutbox, mockClient, c)
+	require.ErrorIs(t, err, serverErr)
+}
+
+func TestReplayOutbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+	mockClient := transport.NewMockItemsClient(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	ops := []*storage.PendingOperation{
+		{ID: 1, Kind: storage.ItemKindData, Operation: storage.OperationCreate, Key: "file", Data: "data", Revision: 1, Metainfo: "meta"},
+		{ID: 2, Kind: storage.ItemKindSecret, Operation: storage.OperationDelete, Key: "secret"},
+		{ID: 3, Kind: storage.ItemKindCard, Operation: storage.OperationCreate, Key: "1234", Data: "card"},
+	}
+
+	gomock.InOrder(
+		mockOutbox.EXPECT().ListPendingOperations(ctx, u).Return(ops, nil),
+		mockClient.EXPECT().UploadBinaryData(ctx, u, &storage.Record{Name: "file", Data: "data", Revision: 1, Metainfo: "meta"}).Return(nil),
+		mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(1)).Return(nil),
+		mockClient.EXPECT().DeleteSecret(ctx, u.Token, "secret").Return(nil),
+		mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(2)).Return(nil),
+		// server became unavailable, last operation stays in outbox
+		mockClient.EXPECT().CreateCardData(ctx, u.Token, "1234", "card").Return(errTestUnavailable),
+	)
+
+	err := ReplayOutboxAction(ctx, u, mockOutbox, mockClient)
+	require.NoError(t, err)
+}
+
+func TestReplayOutboxContinuesAfterRejectedOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+	mockClient := transport.NewMockItemsClient(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	ops := []*storage.PendingOperation{
+		{ID: 1, Kind: storage.ItemKindData, Operation: storage.OperationUpdate, Key: "old", Data: "data", Revision: 1},
+		{ID: 2, Kind: storage.ItemKindSecret, Operation: storage.OperationCreate, Key: "sent", Data: "secret"},
+		{ID: 3, Kind: storage.ItemKindCard, Operation: storage.OperationDelete, Key: "1234"},
+		{ID: 4, Kind: storage.ItemKindCard, Operation: storage.OperationCreate, Key: "5678", Data: "card"},
+		{ID: 5, Kind: storage.ItemKindSecret, Operation: storage.OperationDelete, Key: "failed", Error: "rejected earlier"},
+	}
+
+	conflict := fmt.Errorf("update, err=%w", &transport.APIError{StatusCode: 409, Code: transport.ErrRevisionConflict.Code, Message: "conflict"})
+	exists := &transport.APIError{StatusCode: 409, Code: transport.ErrAlreadyExists.Code, Message: "exists"}
+	notFound := &transport.APIError{StatusCode: 404, Code: transport.ErrNotFound.Code, Message: "not found"}
+
+	gomock.InOrder(
+		mockOutbox.EXPECT().ListPendingOperations(ctx, u).Return(ops, nil),
+		// rejected operation doesn't block the next ones
+		mockClient.EXPECT().UpdateBinaryData(ctx, u, gomock.Any()).Return(conflict),
+		mockOutbox.EXPECT().FailPendingOperation(ctx, u, int64(1), conflict.Error()).Return(nil),
+		// created on server, but response was lost
+		mockClient.EXPECT().CreateSecret(ctx, u.Token, "sent", "secret").Return(exists),
+		mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(2)).Return(nil),
+		mockClient.EXPECT().DeleteCardData(ctx, u.Token, "1234").Return(notFound),
+		mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(3)).Return(nil),
+		mockClient.EXPECT().CreateCardData(ctx, u.Token, "5678", "card").Return(nil),
+		mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(4)).Return(nil),
+	)
+
+	err := ReplayOutboxAction(ctx, u, mockOutbox, mockClient)
+	require.NoError(t, err)
+}
+
+func TestReplayOutboxStopsOnServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+	mockClient := transport.NewMockItemsClient(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	ops := []*storage.PendingOperation{
+		{ID: 1, Kind: storage.ItemKindSecret, Operation: storage.OperationCreate, Key: "first", Data: "secret"},
+		{ID: 2, Kind: storage.ItemKindSecret, Operation: storage.OperationCreate, Key: "second", Data: "secret"},
+	}
+
+	gomock.InOrder(
+		mockOutbox.EXPECT().ListPendingOperations(ctx, u).Return(ops, nil),
+		mockClient.EXPECT().CreateSecret(ctx, u.Token, "first", "secret").Return(transport.ErrTooManyRequests),
+	)
+
+	err := ReplayOutboxAction(ctx, u, mockOutbox, mockClient)
+	require.ErrorIs(t, err, transport.ErrTooManyRequests)
+}
+
+func TestStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	ops := []*storage.PendingOperation{
+		{ID: 1, Kind: storage.ItemKindData, Operation: storage.OperationCreate, Key: "file", CreatedAt: time.Now()},
+	}
+
+	mockOutbox.EXPECT().ListPendingOperations(ctx, u).Return(ops, nil)
+
+	err := StatusAction(ctx, u, mockOutbox, false)
+	require.NoError(t, err)
+}
+
+func TestStatusDiscardFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	ops := []*storage.PendingOperation{
+		{ID: 1, Kind: storage.ItemKindData, Operation: storage.OperationCreate, Key: "file", CreatedAt: time.Now()},
+		{ID: 2, Kind: storage.ItemKindData, Operation: storage.OperationUpdate, Key: "old", CreatedAt: time.Now(), Error: "conflict"},
+	}
+
+	mockOutbox.EXPECT().ListPendingOperations(ctx, u).Return(ops, nil)
+	mockOutbox.EXPECT().DeletePendingOperation(ctx, u, int64(2)).Return(nil)
+
+	err := StatusAction(ctx, u, mockOutbox, true)
+	require.NoError(t, err)
+}
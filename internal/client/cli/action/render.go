@@ -0,0 +1,94 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+)
+
+// permissions of rendered file, it contains secrets
+const renderedFileMode = 0600
+
+// RenderAction renders text/template from input file to output file.
+// Template's functions read vault's items: secret "name", card "number" "field" and data "key".
+func RenderAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.Storage,
+	input string,
+	output string,
+) error {
+	text, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("read template, err=%w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(input)).
+		Option("missingkey=error").
+		Funcs(makeVaultFuncs(ctx, newVaultResolver(user, s))).
+		Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("parse template, err=%w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, nil); err != nil {
+		return fmt.Errorf("render template, err=%w", err)
+	}
+
+	if err := writeFileAtomic(output, buf.Bytes(), renderedFileMode); err != nil {
+		return fmt.Errorf("write rendered file, err=%w", err)
+	}
+
+	fmt.Printf("Template is rendered to file=%s\n", output)
+
+	return nil
+}
+
+func makeVaultFuncs(ctx context.Context, resolver *vaultResolver) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(name string) (string, error) {
+			return resolver.secret(ctx, name)
+		},
+		"card": func(number string, field string) (string, error) {
+			return resolver.cardField(ctx, number, field)
+		},
+		"data": func(key string) (string, error) {
+			return resolver.dataContent(ctx, key)
+		},
+	}
+}
+
+// writeFileAtomic writes file through temporary file, so partially rendered file doesn't appear
+// and existing file gets the mode even if it had another one
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
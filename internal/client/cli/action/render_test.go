@@ -0,0 +1,76 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	card := &storage.BankCard{Number: "1234567812345678", ExpiryDate: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), CvvCode: "123"}
+
+	mockStorage.EXPECT().GetSecret(ctx, user, "db/password").Return(&storage.Secret{Name: "db/password", Value: "p@ss"}, nil)
+	mockStorage.EXPECT().ListCard(ctx, user).Return([]*storage.BankCard{card}, nil).Times(2)
+	mockStorage.EXPECT().LoadData(ctx, user, "ca.pem").Return(makeTestRecord(t, user, "ca.pem", "CA", 1), nil)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "app.tmpl",
+		`password={{ secret "db/password" }}
+cvv={{ card "1234567812345678" "cvv" }}
+expiration={{ card "1234567812345678" "expiration" }}
+ca={{ data "ca.pem" }}
+`)
+
+	output := filepath.Join(dir, "app.conf")
+	// existing file gets restricted permissions
+	require.NoError(t, os.WriteFile(output, []byte("old"), 0644))
+
+	err := RenderAction(ctx, user, mockStorage, filepath.Join(dir, "app.tmpl"), output)
+	require.NoError(t, err)
+
+	requireFileContent(t, output, "password=p@ss\ncvv=123\nexpiration=2030-01-02\nca=CA\n")
+
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRenderTemplateWithUnknownSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	mockStorage.EXPECT().GetSecret(ctx, user, "unknown").Return(nil, sqlstorage.ErrDataNotExist)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "app.tmpl", `password={{ secret "unknown" }}`)
+
+	output := filepath.Join(dir, "app.conf")
+
+	err := RenderAction(ctx, user, mockStorage, filepath.Join(dir, "app.tmpl"), output)
+	require.ErrorIs(t, err, sqlstorage.ErrDataNotExist)
+	require.NoFileExists(t, output)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
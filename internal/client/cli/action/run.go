@@ -0,0 +1,86 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+)
+
+// RunAction starts command with environment variables resolved from the vault.
+// Values are passed to the child process only, they aren't written to disk or stdout.
+// Error of the command is *exec.ExitError, so caller can exit with the same code.
+func RunAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.Storage,
+	envRefs []string,
+	command []string,
+) error {
+	if len(command) == 0 {
+		return errors.New("command isn't specified")
+	}
+
+	env, err := resolveEnv(ctx, newVaultResolver(user, s), envRefs)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s, err=%w", command[0], err)
+	}
+
+	// child decides how to handle signals, so they are forwarded instead of killing us before the child
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+// resolveEnv converts NAME=reference pairs to NAME=value pairs
+func resolveEnv(ctx context.Context, resolver *vaultResolver, envRefs []string) ([]string, error) {
+	env := make([]string, 0, len(envRefs))
+
+	for _, envRef := range envRefs {
+		name, ref, ok := strings.Cut(envRef, "=")
+		if !ok || len(name) == 0 {
+			return nil, fmt.Errorf("bad env=%s, use NAME=secret:name or NAME=data:key", envRef)
+		}
+
+		value, err := resolver.resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolve env=%s, err=%w", name, err)
+		}
+
+		env = append(env, name+"="+value)
+	}
+
+	return env, nil
+}
@@ -0,0 +1,73 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInjectsEnv(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	record := makeTestRecord(t, user, "tls/key.pem", "private key", 1)
+
+	mockStorage.EXPECT().GetSecret(ctx, user, "db/password").Return(&storage.Secret{Name: "db/password", Value: "p@ss"}, nil)
+	mockStorage.EXPECT().LoadData(ctx, user, "tls/key.pem").Return(record, nil)
+
+	env := []string{"DB_PASS=secret:db/password", "TLS_KEY=data:tls/key.pem"}
+	command := []string{"sh", "-c", `test "$DB_PASS" = "p@ss" && test "$TLS_KEY" = "private key"`}
+
+	err := RunAction(ctx, user, mockStorage, env, command)
+	require.NoError(t, err)
+}
+
+func TestRunReturnsExitCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	err := RunAction(context.Background(), makeTestUser(t), mockStorage, nil, []string{"sh", "-c", "exit 3"})
+
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, 3, exitErr.ExitCode())
+}
+
+func TestRunDoesntStartWithUnresolvedEnv(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockStorage(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	mockStorage.EXPECT().GetSecret(ctx, user, "unknown").Return(nil, sqlstorage.ErrDataNotExist)
+
+	dir := t.TempDir()
+	command := []string{"touch", dir + "/started"}
+
+	err := RunAction(ctx, user, mockStorage, []string{"DB_PASS=secret:unknown"}, command)
+	require.ErrorIs(t, err, sqlstorage.ErrDataNotExist)
+	require.NoFileExists(t, dir+"/started")
+
+	for _, env := range []string{"DB_PASS", "=secret:x", "DB_PASS=file:x"} {
+		err = RunAction(ctx, user, mockStorage, []string{env}, command)
+		require.Error(t, err)
+	}
+
+	require.NoFileExists(t, dir+"/started")
+}
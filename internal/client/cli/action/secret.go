@@ -5,28 +5,48 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
 	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 )
 
 func CreateSecretAction(
 	ctx context.Context,
 	user *storage.User,
-	storage storage.SecretStorage,
+	s storage.SecretStorage,
+	outbox storage.OutboxStorage,
 	client transport.SecretDataClient,
 	secret *storage.Secret,
 ) error {
-	cryptedSecret, err := storage.CreateSecret(ctx, user, secret)
-	if err != nil && !errors.Is(err, sqlstorage.ErrAlreadyExist) {
-		return err
-	}
+	var cryptedSecret string
 
-	if err = client.CreateSecret(ctx, user.Token, secret.Name, cryptedSecret); err != nil {
-		return err
-	}
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
 
-	return nil
+			cryptedSecret, err = s.CreateSecret(ctx, user, secret)
+			if err != nil {
+				if errors.Is(err, sqlstorage.ErrAlreadyExist) {
+					return fmt.Errorf("secret=%s already exist, err=%w", secret.Name, err)
+				}
+
+				return err
+			}
+
+			return nil
+		},
+		remote: func(ctx context.Context) error {
+			return client.CreateSecret(ctx, user.Token, secret.Name, cryptedSecret)
+		},
+		compensate: func(ctx context.Context) error {
+			return client.DeleteSecret(ctx, user.Token, secret.Name)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeSecretOperation(storage.OperationCreate, secret.Name, cryptedSecret)
+		},
+	})
 }
 
 func GetSecretAction(
@@ -60,17 +80,86 @@ func GetSecretAction(
 func DeleteSecretAction(
 	ctx context.Context,
 	user *storage.User,
-	storage storage.SecretStorage,
+	s storage.SecretStorage,
+	outbox storage.OutboxStorage,
 	client transport.SecretDataClient,
 	key string,
 ) error {
-	// we are firstly deleting data on the server
-	if err := client.DeleteSecret(ctx, user.Token, key); err != nil {
-		return err
+	var previousSecret string
+
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
+
+			previousSecret, err = findCryptedSecret(ctx, user, s, key)
+			if err != nil {
+				return err
+			}
+
+			return s.DeleteSecret(ctx, user, key)
+		},
+		remote: func(ctx context.Context) error {
+			return client.DeleteSecret(ctx, user.Token, key)
+		},
+		compensate: func(ctx context.Context) error {
+			if len(previousSecret) == 0 {
+				return fmt.Errorf("secret=%s isn't stored locally", key)
+			}
+
+			return client.CreateSecret(ctx, user.Token, key, previousSecret)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeSecretOperation(storage.OperationDelete, key, "")
+		},
+	})
+}
+
+// findCryptedSecret returns encrypted secret which is sent to the server or empty string if secret isn't stored locally
+func findCryptedSecret(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SecretStorage,
+	name string,
+) (string, error) {
+	secret, err := s.GetSecret(ctx, user, name)
+	if err != nil {
+		if errors.Is(err, sqlstorage.ErrDataNotExist) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	if err != nil {
+		return "", err
+	}
+
+	return sqlstorage.NewSerializer(crypto).SerializeSecret(secret)
+}
+
+func makeSecretOperation(operation string, name string, data string) *storage.PendingOperation {
+	return &storage.PendingOperation{Kind: storage.ItemKindSecret, Operation: operation, Key: name, Data: data}
+}
+
+// ListSecretsAction prints pages of secrets' names on the server, secrets' values are got by their names
+func ListSecretsAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.SecretDataClient,
+	opts *transport.ListOptions,
+) error {
+	count, err := printPages(func(cursor string) ([]*handler.SecretInfo, string, error) {
+		return client.ListSecrets(ctx, user.Token, opts, cursor)
+	}, func(info *handler.SecretInfo) {
+		fmt.Printf("\t%s %s\n", info.Key, formatUpdateTime(info.UpdatedAt))
+	})
+	if err != nil {
+		return fmt.Errorf("list secrets on server, err=%w", err)
 	}
 
-	if err := storage.DeleteSecret(ctx, user, key); err != nil {
-		return err
+	if count == 0 {
+		fmt.Println("Secrets aren't exist")
 	}
 
 	return nil
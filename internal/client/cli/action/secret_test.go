@@ -17,6 +17,7 @@ func TestCreateSecret(t *testing.T) {
 
 	mockStorage := storage.NewMockSecretStorage(ctrl)
 	mockClient := transport.NewMockSecretDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, data := getCryptoKeyAndData(t)
 
@@ -26,10 +27,11 @@ func TestCreateSecret(t *testing.T) {
 
 	mustBeCryptedSecret := "crypted_data"
 
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
 	mockStorage.EXPECT().CreateSecret(gomock.Any(), user, secret).Return(mustBeCryptedSecret, nil)
 	mockClient.EXPECT().CreateSecret(ctx, user.Token, secret.Name, mustBeCryptedSecret).Return(nil)
 
-	err := CreateSecretAction(ctx, user, mockStorage, mockClient, secret)
+	err := CreateSecretAction(ctx, user, mockStorage, mockOutbox, mockClient, secret)
 	require.NoError(t, err)
 }
 
@@ -81,6 +83,7 @@ func TestDeleteSecret(t *testing.T) {
 
 	mockStorage := storage.NewMockSecretStorage(ctrl)
 	mockClient := transport.NewMockSecretDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	key, data := getCryptoKeyAndData(t)
 
@@ -89,9 +92,11 @@ func TestDeleteSecret(t *testing.T) {
 
 	ctx := context.Background()
 
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockStorage.EXPECT().GetSecret(ctx, user, "key").Return(nil, sqlstorage.ErrDataNotExist)
 	mockStorage.EXPECT().DeleteSecret(ctx, user, "key").Return(nil)
 	mockClient.EXPECT().DeleteSecret(ctx, user.Token, secret.Key).Return(nil)
 
-	err := DeleteSecretAction(ctx, user, mockStorage, mockClient, "key")
+	err := DeleteSecretAction(ctx, user, mockStorage, mockOutbox, mockClient, "key")
 	require.NoError(t, err)
 }
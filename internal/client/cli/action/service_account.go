@@ -0,0 +1,85 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// CreateServiceAccountAction creates service account and prints its token, server doesn't store the token
+func CreateServiceAccountAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.ServiceAccountClient,
+	name string,
+	scope *handler.Scope,
+) error {
+	token, err := client.CreateServiceAccount(ctx, user.Token, name, scope)
+	if err != nil {
+		return fmt.Errorf("create service account=%s, err=%w", name, err)
+	}
+
+	fmt.Printf("Service account=%s is created with %s\n", name, formatScope(scope))
+	fmt.Printf("token: %s\n", token)
+	fmt.Println("Save the token, it can't be shown again")
+
+	return nil
+}
+
+func ListServiceAccountsAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.ServiceAccountClient,
+) error {
+	accounts, err := client.ListServiceAccounts(ctx, user.Token)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		fmt.Printf("\tname: %s; %s; created: %s\n", account.Name, formatScope(account.Scope), account.CreatedAt.Format(time.DateTime))
+	}
+
+	return nil
+}
+
+// RevokeServiceAccountAction deletes service account, its token isn't accepted by server after that
+func RevokeServiceAccountAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.ServiceAccountClient,
+	name string,
+) error {
+	if err := client.DeleteServiceAccount(ctx, user.Token, name); err != nil {
+		return fmt.Errorf("revoke service account=%s, err=%w", name, err)
+	}
+
+	fmt.Printf("Service account=%s is revoked\n", name)
+
+	return nil
+}
+
+func formatScope(scope *handler.Scope) string {
+	kinds, prefixes, permission := "all", "all", "read-write"
+
+	if scope != nil {
+		if len(scope.Kinds) != 0 {
+			kinds = strings.Join(scope.Kinds, ",")
+		}
+
+		if len(scope.Prefixes) != 0 {
+			prefixes = strings.Join(scope.Prefixes, ",")
+		}
+
+		if scope.ReadOnly {
+			permission = "read-only"
+		}
+	}
+
+	return fmt.Sprintf("kinds: %s; prefixes: %s; %s", kinds, prefixes, permission)
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateServiceAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := transport.NewMockServiceAccountClient(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+	scope := &handler.Scope{Kinds: []string{handler.ItemKindSecret}, ReadOnly: true}
+
+	mockClient.EXPECT().CreateServiceAccount(ctx, user.Token, "ci", scope).Return("service_token", nil)
+
+	err := CreateServiceAccountAction(ctx, user, mockClient, "ci", scope)
+	require.NoError(t, err)
+}
+
+func TestRevokeServiceAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := transport.NewMockServiceAccountClient(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	mockClient.EXPECT().DeleteServiceAccount(ctx, user.Token, "ci").Return(errors.New("failed"))
+
+	err := RevokeServiceAccountAction(ctx, user, mockClient, "ci")
+	require.Error(t, err)
+}
+
+func TestFormatScope(t *testing.T) {
+	require.Equal(t, "kinds: all; prefixes: all; read-write", formatScope(&handler.Scope{}))
+	require.Equal(t,
+		"kinds: secret,data; prefixes: ci/; read-only",
+		formatScope(&handler.Scope{Kinds: []string{"secret", "data"}, Prefixes: []string{"ci/"}, ReadOnly: true}),
+	)
+}
@@ -0,0 +1,285 @@
+package action
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/kuzhukin/goph-keeper/internal/client/sshagent"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"golang.org/x/crypto/ssh"
+)
+
+// CreateSSHKeyAction reads private key from the file and saves it without passphrase to the vault
+func CreateSSHKeyAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+	outbox storage.OutboxStorage,
+	client transport.SSHKeyClient,
+	key *storage.SSHKey,
+	filename string,
+	passphrase string,
+) error {
+	privateKey, err := readSSHPrivateKey(filename, passphrase, key.Comment)
+	if err != nil {
+		return err
+	}
+
+	key.PrivateKey = privateKey
+
+	var data string
+
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
+
+			data, err = s.CreateSSHKey(ctx, user, key)
+			if err != nil {
+				if errors.Is(err, sqlstorage.ErrAlreadyExist) {
+					return fmt.Errorf("ssh key=%s already exist, err=%w", key.Name, err)
+				}
+
+				return err
+			}
+
+			return nil
+		},
+		remote: func(ctx context.Context) error {
+			return client.CreateSSHKey(ctx, user.Token, key.Name, data)
+		},
+		compensate: func(ctx context.Context) error {
+			return client.DeleteSSHKey(ctx, user.Token, key.Name)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeSSHKeyOperation(storage.OperationCreate, key.Name, data)
+		},
+	})
+}
+
+func DeleteSSHKeyAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+	outbox storage.OutboxStorage,
+	client transport.SSHKeyClient,
+	name string,
+) error {
+	var previousData string
+
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
+
+			previousData, err = findSSHKeyData(ctx, user, s, name)
+			if err != nil {
+				return err
+			}
+
+			return s.DeleteSSHKey(ctx, user, name)
+		},
+		remote: func(ctx context.Context) error {
+			return client.DeleteSSHKey(ctx, user.Token, name)
+		},
+		compensate: func(ctx context.Context) error {
+			if len(previousData) == 0 {
+				return fmt.Errorf("ssh key=%s isn't stored locally", name)
+			}
+
+			return client.CreateSSHKey(ctx, user.Token, name, previousData)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeSSHKeyOperation(storage.OperationDelete, name, "")
+		},
+	})
+}
+
+// ListSSHKeysAction prints keys' names and fingerprints, private keys aren't printed
+func ListSSHKeysAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+) error {
+	keys, err := s.ListSSHKeys(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		signer, err := ssh.ParsePrivateKey(key.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("parse ssh key=%s, err=%w", key.Name, err)
+		}
+
+		fmt.Printf("name: %s; type: %s; fingerprint: %s; confirm: %v; lifetime: %v\n",
+			key.Name, signer.PublicKey().Type(), ssh.FingerprintSHA256(signer.PublicKey()), key.Confirm, key.Lifetime)
+	}
+
+	return nil
+}
+
+// PullSSHKeysAction downloads keys which were added by other devices, so ssh-agent serves them,
+// keys aren't changed on the server, so stored keys aren't downloaded again
+func PullSSHKeysAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+	client transport.SSHKeyClient,
+) error {
+	remote, err := transport.ListAll(func(cursor string) ([]*handler.SSHKeyInfo, string, error) {
+		return client.ListSSHKeys(ctx, user.Token, nil, cursor)
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := s.ListSSHKeys(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	stored := make(map[string]bool, len(local))
+	for _, key := range local {
+		stored[key.Name] = true
+	}
+
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	if err != nil {
+		return err
+	}
+
+	serializer := sqlstorage.NewSerializer(crypto)
+
+	for _, info := range remote {
+		if stored[info.Name] {
+			continue
+		}
+
+		data, err := client.GetSSHKey(ctx, user.Token, info.Name)
+		if err != nil {
+			return fmt.Errorf("download ssh key=%s, err=%w", info.Name, err)
+		}
+
+		key, err := serializer.DeserializeSSHKey(data)
+		if err != nil {
+			return fmt.Errorf("decrypt ssh key=%s, err=%w", info.Name, err)
+		}
+
+		key.Name = info.Name
+
+		if _, err = s.CreateSSHKey(ctx, user, key); err != nil {
+			return err
+		}
+
+		fmt.Printf("\tdownloaded: %s\n", info.Name)
+	}
+
+	return nil
+}
+
+// SSHAgentAction serves vault's ssh keys with ssh-agent protocol until ctx is done.
+// confirmAll and defaultLifetime are applied to keys which don't have own constraints.
+func SSHAgentAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+	listener net.Listener,
+	confirmer sshagent.Confirmer,
+	confirmAll bool,
+	defaultLifetime time.Duration,
+) error {
+	keys, err := s.ListSSHKeys(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	a := sshagent.New(confirmer)
+
+	for _, key := range keys {
+		key.Confirm = key.Confirm || confirmAll
+		if key.Lifetime == 0 {
+			key.Lifetime = defaultLifetime
+		}
+
+		if err := a.AddVaultKey(key); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", listener.Addr().String())
+	fmt.Printf("Agent serves %d key(s)\n", len(keys))
+
+	return a.Serve(ctx, listener)
+}
+
+// readSSHPrivateKey returns PEM of the key without passphrase
+func readSSHPrivateKey(filename string, passphrase string, comment string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read ssh key, err=%w", err)
+	}
+
+	var privateKey any
+
+	if len(passphrase) != 0 {
+		privateKey, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		privateKey, err = ssh.ParseRawPrivateKey(data)
+	}
+
+	if err != nil {
+		var missingErr *ssh.PassphraseMissingError
+		if errors.As(err, &missingErr) {
+			return nil, errors.New("ssh key is protected by passphrase, use --passphrase")
+		}
+
+		return nil, fmt.Errorf("parse ssh key, err=%w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return nil, fmt.Errorf("marshal ssh key, err=%w", err)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// findSSHKeyData returns encrypted key which is sent to the server or empty string if key isn't stored locally
+func findSSHKeyData(
+	ctx context.Context,
+	user *storage.User,
+	s storage.SSHKeyStorage,
+	name string,
+) (string, error) {
+	keys, err := s.ListSSHKeys(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range keys {
+		if key.Name != name {
+			continue
+		}
+
+		crypto, err := gophcrypto.New(user.CryptoKey)
+		if err != nil {
+			return "", err
+		}
+
+		return sqlstorage.NewSerializer(crypto).SerializeSSHKey(key)
+	}
+
+	return "", nil
+}
+
+func makeSSHKeyOperation(operation string, name string, data string) *storage.PendingOperation {
+	return &storage.PendingOperation{Kind: storage.ItemKindSSHKey, Operation: operation, Key: name, Data: data}
+}
@@ -0,0 +1,116 @@
+package action
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCreateSSHKeyRemovesPassphrase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockSSHKeyStorage(ctrl)
+	mockClient := transport.NewMockSSHKeyClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "id_ed25519", string(pem.EncodeToMemory(block)))
+
+	key := &storage.SSHKey{Name: "deploy", Confirm: true}
+
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockStorage.EXPECT().CreateSSHKey(gomock.Any(), user, key).DoAndReturn(
+		func(_ context.Context, _ *storage.User, key *storage.SSHKey) (string, error) {
+			// key is stored without passphrase
+			_, err := ssh.ParsePrivateKey(key.PrivateKey)
+			require.NoError(t, err)
+
+			return "crypted_key", nil
+		},
+	)
+	mockClient.EXPECT().CreateSSHKey(ctx, user.Token, key.Name, "crypted_key").Return(nil)
+
+	err = CreateSSHKeyAction(ctx, user, mockStorage, mockOutbox, mockClient, key, dir+"/id_ed25519", "passphrase")
+	require.NoError(t, err)
+}
+
+func TestCreateProtectedSSHKeyWithoutPassphrase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockSSHKeyStorage(ctrl)
+	mockClient := transport.NewMockSSHKeyClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "id_ed25519", string(pem.EncodeToMemory(block)))
+
+	key := &storage.SSHKey{Name: "deploy"}
+
+	err = CreateSSHKeyAction(context.Background(), makeTestUser(t), mockStorage, mockOutbox, mockClient, key, dir+"/id_ed25519", "")
+	require.Error(t, err)
+}
+
+func TestPullSSHKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockSSHKeyStorage(ctrl)
+	mockClient := transport.NewMockSSHKeyClient(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	crypto, err := gophcrypto.New(user.CryptoKey)
+	require.NoError(t, err)
+
+	remoteKey := &storage.SSHKey{PrivateKey: []byte("private key"), Comment: "laptop", Confirm: true}
+	data, err := sqlstorage.NewSerializer(crypto).SerializeSSHKey(remoteKey)
+	require.NoError(t, err)
+
+	mockClient.EXPECT().ListSSHKeys(ctx, user.Token, nil, "").Return(
+		[]*handler.SSHKeyInfo{{Name: "deploy"}, {Name: "laptop"}}, "", nil,
+	)
+	mockStorage.EXPECT().ListSSHKeys(ctx, user).Return([]*storage.SSHKey{{Name: "deploy"}}, nil)
+	mockClient.EXPECT().GetSSHKey(ctx, user.Token, "laptop").Return(data, nil)
+	mockStorage.EXPECT().CreateSSHKey(ctx, user, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *storage.User, key *storage.SSHKey) (string, error) {
+			require.Equal(t, "laptop", key.Name)
+			require.Equal(t, remoteKey.PrivateKey, key.PrivateKey)
+			require.Equal(t, remoteKey.Comment, key.Comment)
+			require.True(t, key.Confirm)
+
+			return data, nil
+		},
+	)
+
+	err = PullSSHKeysAction(ctx, user, mockStorage, mockClient)
+	require.NoError(t, err)
+}
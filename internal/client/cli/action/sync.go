@@ -0,0 +1,373 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// PushDirAction uploads new and changed files of the directory to the server.
+// Records which don't have files in the directory are deleted when deleteRemoved is set, otherwise they are reported.
+func PushDirAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.DataStorage,
+	client transport.BinaryDataClient,
+	dir string,
+	deleteRemoved bool,
+	compression string,
+) error {
+	localFiles, err := listDirFiles(dir)
+	if err != nil {
+		return fmt.Errorf("list directory=%s, err=%w", dir, err)
+	}
+
+	remoteRecords, err := listRemoteRecords(ctx, user, client)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(localFiles) {
+		r, err := readDataFromFile(localFiles[key], key, user, compression, nil)
+		if err != nil {
+			return fmt.Errorf("read data from file, err=%w", err)
+		}
+
+		remote, ok := remoteRecords[key]
+		if !ok {
+			if err = client.UploadBinaryData(ctx, user, r); err != nil {
+				return fmt.Errorf("upload %s, err=%w", key, err)
+			}
+
+			if err = s.SaveData(ctx, user, r); err != nil {
+				return err
+			}
+
+			fmt.Printf("\tuploaded: %s\n", key)
+
+			continue
+		}
+
+		changed, err := isRecordChanged(ctx, user, s, client, r, remote)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			continue
+		}
+
+		// chunks of the previous revision aren't uploaded again
+		if err = loadRemoteData(ctx, user, s, client, remote); err != nil {
+			return err
+		}
+
+		if r, err = readDataFromFile(localFiles[key], key, user, compression, remote); err != nil {
+			return fmt.Errorf("read data from file, err=%w", err)
+		}
+
+		r.Revision = remote.Revision
+		if err = client.UpdateBinaryDataDelta(ctx, user, r, remote.Data); err != nil {
+			return fmt.Errorf("update %s, err=%w", key, err)
+		}
+
+		r.Revision = remote.Revision + 1
+		if err = s.SaveData(ctx, user, r); err != nil {
+			return err
+		}
+
+		fmt.Printf("\tupdated: %s\n", key)
+	}
+
+	for _, key := range sortedKeys(remoteRecords) {
+		if _, ok := localFiles[key]; ok {
+			continue
+		}
+
+		if !deleteRemoved {
+			fmt.Printf("\tremoved locally: %s\n", key)
+
+			continue
+		}
+
+		if err = client.DeleteBinaryData(ctx, user, key); err != nil {
+			return fmt.Errorf("delete %s, err=%w", key, err)
+		}
+
+		if err = s.DeleteData(ctx, user, key); err != nil {
+			return err
+		}
+
+		fmt.Printf("\tdeleted: %s\n", key)
+	}
+
+	return nil
+}
+
+// PullDirAction downloads new and changed records from the server to the directory.
+// Files which don't have records on the server are deleted when deleteRemoved is set, otherwise they are reported.
+func PullDirAction(
+	ctx context.Context,
+	user *storage.User,
+	s storage.DataStorage,
+	client transport.BinaryDataClient,
+	dir string,
+	deleteRemoved bool,
+) error {
+	localFiles, err := listDirFiles(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("list directory=%s, err=%w", dir, err)
+	}
+
+	remoteRecords, err := listRemoteRecords(ctx, user, client)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(remoteRecords) {
+		remote := remoteRecords[key]
+
+		path, ok := dirFilePath(dir, key)
+		if !ok {
+			fmt.Printf("\tskipped: %s, it can't be placed in the directory\n", key)
+
+			continue
+		}
+
+		if _, ok := localFiles[key]; ok {
+			// local record is only compared by content's hash
+			local, err := readDataFromFile(path, key, user, compress.AlgorithmNone, nil)
+			if err != nil {
+				return fmt.Errorf("read data from file, err=%w", err)
+			}
+
+			changed, err := isRecordChanged(ctx, user, s, client, local, remote)
+			if err != nil {
+				return err
+			}
+
+			if !changed {
+				continue
+			}
+		}
+
+		if err = loadRemoteData(ctx, user, s, client, remote); err != nil {
+			return err
+		}
+
+		data, err := decryptUserData(user, []byte(remote.Data))
+		if err != nil {
+			return fmt.Errorf("decrypt %s, err=%w", key, err)
+		}
+
+		info, err := recordFileInfo(user, remote)
+		if err != nil {
+			return fmt.Errorf("%s, err=%w", key, err)
+		}
+
+		// file is placed by the record's key which is listed back by listDirFiles
+		if err = writeFile(path, data, info, true); err != nil {
+			return fmt.Errorf("write %s, err=%w", key, err)
+		}
+
+		if err = s.SaveData(ctx, user, remote); err != nil {
+			return err
+		}
+
+		fmt.Printf("\tdownloaded: %s\n", key)
+	}
+
+	for _, key := range sortedKeys(localFiles) {
+		if _, ok := remoteRecords[key]; ok {
+			continue
+		}
+
+		if !deleteRemoved {
+			fmt.Printf("\tremoved on server: %s\n", key)
+
+			continue
+		}
+
+		if err = os.Remove(localFiles[key]); err != nil {
+			return err
+		}
+
+		if err = s.DeleteData(ctx, user, key); err != nil {
+			return err
+		}
+
+		fmt.Printf("\tdeleted: %s\n", key)
+	}
+
+	return nil
+}
+
+// listDirFiles returns regular files of the directory and it's subdirectories by their record's keys
+func listDirFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		files[normalizeKey(relPath)] = path
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// dirFilePath returns path of the record's file in the directory,
+// key which escapes from the directory or isn't listed back by listDirFiles can't be placed
+func dirFilePath(dir string, key string) (string, bool) {
+	relPath := filepath.FromSlash(key)
+	if !filepath.IsLocal(relPath) || normalizeKey(relPath) != key {
+		return "", false
+	}
+
+	return filepath.Join(dir, relPath), true
+}
+
+// listRemoteRecords returns records on the server without data, data is loaded by loadRemoteData when it's needed
+func listRemoteRecords(
+	ctx context.Context,
+	user *storage.User,
+	client transport.BinaryDataClient,
+) (map[string]*storage.Record, error) {
+	list, err := transport.ListAll(func(cursor string) ([]*handler.DataInfo, string, error) {
+		return client.ListBinaryData(ctx, user, nil, cursor)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list data on server, err=%w", err)
+	}
+
+	records := make(map[string]*storage.Record, len(list))
+	for _, info := range list {
+		records[info.Name] = &storage.Record{Name: info.Name, Revision: info.Revision, Metainfo: info.Metainfo}
+	}
+
+	return records, nil
+}
+
+// loadRemoteData sets data of the remote record, local record of the same revision is used instead of downloading
+func loadRemoteData(
+	ctx context.Context,
+	user *storage.User,
+	s storage.DataStorage,
+	client transport.BinaryDataClient,
+	remote *storage.Record,
+) error {
+	if len(remote.Data) > 0 {
+		return nil
+	}
+
+	if local, err := s.LoadData(ctx, user, remote.Name); err == nil && local.Revision == remote.Revision {
+		remote.Data = local.Data
+
+		return nil
+	}
+
+	downloaded, err := client.DownloadBinaryData(ctx, user, remote.Name)
+	if err != nil {
+		return fmt.Errorf("download %s, err=%w", remote.Name, err)
+	}
+
+	*remote = *downloaded
+
+	return nil
+}
+
+// isRecordChanged compares content hashes of local and remote records,
+// data of the remote record is loaded when its metainfo doesn't have the hash
+func isRecordChanged(
+	ctx context.Context,
+	user *storage.User,
+	s storage.DataStorage,
+	client transport.BinaryDataClient,
+	local *storage.Record,
+	remote *storage.Record,
+) (bool, error) {
+	localHash, err := recordHash(user, local)
+	if err != nil {
+		return false, err
+	}
+
+	remoteHash, err := metainfoHash(user, remote)
+	if err != nil {
+		return false, err
+	}
+
+	if len(remoteHash) == 0 {
+		if err = loadRemoteData(ctx, user, s, client, remote); err != nil {
+			return false, err
+		}
+
+		if remoteHash, err = recordHash(user, remote); err != nil {
+			return false, err
+		}
+	}
+
+	return localHash != remoteHash, nil
+}
+
+func metainfoHash(user *storage.User, r *storage.Record) (string, error) {
+	if len(r.Metainfo) == 0 {
+		return "", nil
+	}
+
+	info, err := decryptFileInfo(user, r.Metainfo)
+	if err != nil {
+		return "", fmt.Errorf("decrypt metainfo of %s, err=%w", r.Name, err)
+	}
+
+	return info.Hash, nil
+}
+
+func recordHash(user *storage.User, r *storage.Record) (string, error) {
+	hash, err := metainfoHash(user, r)
+	if err != nil || len(hash) != 0 {
+		return hash, err
+	}
+
+	// records without hash in metainfo were uploaded by older clients
+	data, err := decryptUserData(user, []byte(r.Data))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s, err=%w", r.Name, err)
+	}
+
+	return contentHash(data), nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,195 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushDir(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+
+	user := makeTestUser(t)
+	dir := t.TempDir()
+
+	writeTestFile(t, dir, "new.txt", "new")
+	writeTestFile(t, dir, "sub/changed.txt", "changed")
+	writeTestFile(t, dir, "same.txt", "same")
+
+	remote := []*storage.Record{
+		makeTestRecord(t, user, "sub/changed.txt", "old", 3),
+		makeTestRecord(t, user, "same.txt", "same", 1),
+		makeTestRecord(t, user, "removed.txt", "removed", 1),
+	}
+
+	ctx := context.Background()
+
+	expectRemoteList(mockClient, ctx, user, remote)
+
+	// local copy of the same revision isn't downloaded
+	mockDataStorage.EXPECT().LoadData(ctx, user, "sub/changed.txt").Return(remote[0], nil)
+
+	mockClient.EXPECT().UploadBinaryData(ctx, user, gomock.Any()).DoAndReturn(func(_ context.Context, _ *storage.User, r *storage.Record) error {
+		require.Equal(t, "new.txt", r.Name)
+		require.Equal(t, uint64(1), r.Revision)
+
+		return nil
+	})
+	mockDataStorage.EXPECT().SaveData(ctx, user, gomock.Any()).Return(nil).Times(2)
+
+	mockClient.EXPECT().UpdateBinaryDataDelta(ctx, user, gomock.Any(), remote[0].Data).DoAndReturn(func(_ context.Context, _ *storage.User, r *storage.Record, _ string) error {
+		require.Equal(t, "sub/changed.txt", r.Name)
+		require.Equal(t, uint64(3), r.Revision)
+
+		return nil
+	})
+
+	mockClient.EXPECT().DeleteBinaryData(ctx, user, "removed.txt").Return(nil)
+	mockDataStorage.EXPECT().DeleteData(ctx, user, "removed.txt").Return(nil)
+
+	err := PushDirAction(ctx, user, mockDataStorage, mockClient, dir, true, compress.AlgorithmNone)
+	require.NoError(t, err)
+}
+
+func TestPushDirReportsRemoved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+
+	user := makeTestUser(t)
+	dir := t.TempDir()
+
+	remote := []*storage.Record{
+		makeTestRecord(t, user, "removed.txt", "removed", 1),
+	}
+
+	ctx := context.Background()
+
+	expectRemoteList(mockClient, ctx, user, remote)
+
+	err := PushDirAction(ctx, user, mockDataStorage, mockClient, dir, false, compress.AlgorithmNone)
+	require.NoError(t, err)
+}
+
+func TestPullDir(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+
+	user := makeTestUser(t)
+	dir := t.TempDir()
+
+	writeTestFile(t, dir, "changed.txt", "old")
+	writeTestFile(t, dir, "same.txt", "same")
+	writeTestFile(t, dir, "removed.txt", "removed")
+
+	newRecord := makeTestRecord(t, user, "sub/new.txt", "new", 1)
+	changedRecord := makeTestRecord(t, user, "changed.txt", "changed", 2)
+	remote := []*storage.Record{
+		// keys which escape from the directory are skipped without downloading
+		makeTestRecord(t, user, "../escaped.txt", "escaped", 1),
+		makeTestRecord(t, user, "sub/../dotted.txt", "dotted", 1),
+		newRecord,
+		changedRecord,
+		makeTestRecord(t, user, "same.txt", "same", 1),
+	}
+
+	ctx := context.Background()
+
+	expectRemoteList(mockClient, ctx, user, remote)
+
+	// changed records are downloaded, because local copies are older
+	mockDataStorage.EXPECT().LoadData(ctx, user, "sub/new.txt").Return(nil, sqlstorage.ErrDataNotExist)
+	mockDataStorage.EXPECT().LoadData(ctx, user, "changed.txt").Return(&storage.Record{Name: "changed.txt", Revision: 1}, nil)
+	mockClient.EXPECT().DownloadBinaryData(ctx, user, "sub/new.txt").Return(newRecord, nil)
+	mockClient.EXPECT().DownloadBinaryData(ctx, user, "changed.txt").Return(changedRecord, nil)
+
+	mockDataStorage.EXPECT().SaveData(ctx, user, newRecord).Return(nil)
+	mockDataStorage.EXPECT().SaveData(ctx, user, changedRecord).Return(nil)
+	mockDataStorage.EXPECT().DeleteData(ctx, user, "removed.txt").Return(nil)
+
+	err := PullDirAction(ctx, user, mockDataStorage, mockClient, dir, true)
+	require.NoError(t, err)
+
+	requireFileContent(t, filepath.Join(dir, "sub", "new.txt"), "new")
+	requireFileContent(t, filepath.Join(dir, "changed.txt"), "changed")
+	requireFileContent(t, filepath.Join(dir, "same.txt"), "same")
+
+	_, err = os.Stat(filepath.Join(dir, "removed.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	skipped := []string{
+		filepath.Join(dir, "..", "escaped.txt"),
+		filepath.Join(dir, "escaped.txt"),
+		filepath.Join(dir, "dotted.txt"),
+	}
+	for _, path := range skipped {
+		_, err = os.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
+
+// expectRemoteList returns the remote records without data by pages of the one record
+func expectRemoteList(mockClient *transport.MockBinaryDataClient, ctx context.Context, user *storage.User, remote []*storage.Record) {
+	cursor := ""
+
+	for i, r := range remote {
+		next := ""
+		if i < len(remote)-1 {
+			next = r.Name
+		}
+
+		info := []*handler.DataInfo{{Name: r.Name, Revision: r.Revision, Metainfo: r.Metainfo}}
+		mockClient.EXPECT().ListBinaryData(ctx, user, nil, cursor).Return(info, next, nil)
+
+		cursor = next
+	}
+}
+
+func makeTestUser(t *testing.T) *storage.User {
+	key, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	return &storage.User{Login: "user", Password: "pass", Token: "token", IsActive: true, CryptoKey: key}
+}
+
+func makeTestRecord(t *testing.T, user *storage.User, key string, content string, revision uint64) *storage.Record {
+	data, _, err := encryptUserData(user, []byte(content), compress.AlgorithmNone, nil)
+	require.NoError(t, err)
+
+	metainfo, err := encryptFileInfo(user, &storage.FileInfo{Path: key, Mode: 0600, Hash: contentHash([]byte(content))})
+	require.NoError(t, err)
+
+	return &storage.Record{Name: key, Data: string(data), Revision: revision, Metainfo: metainfo}
+}
+
+func writeTestFile(t *testing.T, dir string, name string, content string) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func requireFileContent(t *testing.T, path string, content string) {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+}
@@ -0,0 +1,64 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+)
+
+// atomicOperation is a change which is applied to the local storage and to the server together
+type atomicOperation struct {
+	// local stages the change in the local storage's transaction
+	local func(ctx context.Context) error
+	// remote applies the change on the server, it isn't called when local returns errSkipRemote
+	remote func(ctx context.Context) error
+	// compensate reverts the server's change when the local transaction can't be committed
+	compensate func(ctx context.Context) error
+	// pending makes the operation which is saved to the outbox when the server is unavailable
+	pending func() *storage.PendingOperation
+}
+
+// errSkipRemote is returned by local step when the server's state doesn't need changes
+var errSkipRemote = errors.New("skip remote")
+
+// runAtomic stages the local change in a transaction and commits it only after the server has confirmed the change.
+// If the server is unavailable the change is committed together with the pending operation in the outbox.
+// If the transaction can't be committed after the server has applied the change, the server's change is compensated.
+func runAtomic(
+	ctx context.Context,
+	user *storage.User,
+	tx storage.Transactor,
+	outbox storage.OutboxStorage,
+	op *atomicOperation,
+) error {
+	remoteApplied := false
+
+	err := tx.InTransaction(ctx, func(txCtx context.Context) error {
+		if err := op.local(txCtx); err != nil {
+			if errors.Is(err, errSkipRemote) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := op.remote(ctx); err != nil {
+			return queueIfUnavailable(txCtx, user, outbox, op.pending(), err)
+		}
+
+		remoteApplied = true
+
+		return nil
+	})
+	if err == nil || !remoteApplied || op.compensate == nil {
+		return err
+	}
+
+	if compensateErr := op.compensate(ctx); compensateErr != nil {
+		return fmt.Errorf("local change failed with err=%w, server's change isn't reverted, err=%w", err, compensateErr)
+	}
+
+	return err
+}
@@ -0,0 +1,132 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/stretchr/testify/require"
+)
+
+// passTransaction runs function of transaction as if commit is always succeeded
+func passTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestCreateDataRollbackOnServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	serverErr := errors.New("request failed code=500")
+
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		err := fn(ctx)
+		require.ErrorIs(t, err, serverErr)
+
+		// rollback
+		return err
+	})
+	expectNewFileKey(ctx, user, mockDataStorage, mockClient)
+	mockDataStorage.EXPECT().CreateData(ctx, user, gomock.Any()).Return(nil)
+	mockClient.EXPECT().UploadBinaryData(ctx, user, gomock.Any()).Return(serverErr)
+
+	err := CreateDataAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName, compress.AlgorithmNone)
+	require.ErrorIs(t, err, serverErr)
+}
+
+func TestCreateDataCompensateOnCommitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDataStorage := storage.NewMockDataStorage(ctrl)
+	mockClient := transport.NewMockBinaryDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	user := makeTestUser(t)
+	ctx := context.Background()
+
+	commitErr := errors.New("commit failed")
+
+	mockDataStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		require.NoError(t, fn(ctx))
+
+		return commitErr
+	})
+
+	expectNewFileKey(ctx, user, mockDataStorage, mockClient)
+
+	gomock.InOrder(
+		mockDataStorage.EXPECT().CreateData(ctx, user, gomock.Any()).Return(nil),
+		mockClient.EXPECT().UploadBinaryData(ctx, user, gomock.Any()).Return(nil),
+		mockClient.EXPECT().DeleteBinaryData(ctx, user, testFileKey).Return(nil),
+	)
+
+	err := CreateDataAction(ctx, user, mockDataStorage, mockOutbox, mockClient, testFileName, compress.AlgorithmNone)
+	require.ErrorIs(t, err, commitErr)
+}
+
+func TestDeleteSecretCompensateOnCommitError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockSecretStorage(ctrl)
+	mockClient := transport.NewMockSecretDataClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	user := makeTestUser(t)
+	secret := &storage.Secret{Name: "secret", Key: "key", Value: "value"}
+	ctx := context.Background()
+
+	commitErr := errors.New("commit failed")
+
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		require.NoError(t, fn(ctx))
+
+		return commitErr
+	})
+
+	gomock.InOrder(
+		mockStorage.EXPECT().GetSecret(ctx, user, secret.Name).Return(secret, nil),
+		mockStorage.EXPECT().DeleteSecret(ctx, user, secret.Name).Return(nil),
+		mockClient.EXPECT().DeleteSecret(ctx, user.Token, secret.Name).Return(nil),
+		mockClient.EXPECT().CreateSecret(ctx, user.Token, secret.Name, gomock.Any()).Return(errors.New("request failed code=500")),
+	)
+
+	err := DeleteSecretAction(ctx, user, mockStorage, mockOutbox, mockClient, secret.Name)
+	require.ErrorIs(t, err, commitErr)
+	require.ErrorContains(t, err, "server's change isn't reverted")
+}
+
+func TestRunAtomicSkipRemote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockWalletStorage(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
+
+	ctx := context.Background()
+	u := &storage.User{Token: "token"}
+
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+
+	err := runAtomic(ctx, u, mockStorage, mockOutbox, &atomicOperation{
+		local: func(context.Context) error { return errSkipRemote },
+		remote: func(context.Context) error {
+			require.Fail(t, "remote mustn't be called")
+
+			return nil
+		},
+	})
+	require.NoError(t, err)
+}
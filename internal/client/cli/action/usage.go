@@ -0,0 +1,60 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// UsageAction prints consumption of server's storage by user's items and user's quota
+func UsageAction(
+	ctx context.Context,
+	user *storage.User,
+	client transport.UsageClient,
+) error {
+	usage, err := client.GetUsage(ctx, user.Token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(formatUsage(usage))
+
+	return nil
+}
+
+func formatUsage(u *handler.UsageResponse) string {
+	maxItems := "unlimited"
+	if u.MaxItems > 0 {
+		maxItems = strconv.Itoa(u.MaxItems)
+	}
+
+	return fmt.Sprintf("bytes: %s of %s\nitems: %d of %s\nmax item size: %s\n",
+		formatBytes(u.Bytes), formatLimit(u.MaxBytes), u.Items, maxItems, formatLimit(u.MaxItemSize))
+}
+
+func formatLimit(limit int64) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+
+	return formatBytes(limit)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
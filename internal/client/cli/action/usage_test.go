@@ -0,0 +1,37 @@
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := transport.NewMockUsageClient(ctrl)
+
+	ctx := context.Background()
+	user := makeTestUser(t)
+
+	mockClient.EXPECT().GetUsage(ctx, user.Token).Return(&handler.UsageResponse{Bytes: 10, Items: 1}, nil)
+
+	require.NoError(t, UsageAction(ctx, user, mockClient))
+}
+
+func TestFormatUsage(t *testing.T) {
+	require.Equal(t,
+		"bytes: 1.5 KiB of 1.0 GiB\nitems: 3 of 100\nmax item size: 16.0 MiB\n",
+		formatUsage(&handler.UsageResponse{Bytes: 1536, Items: 3, MaxBytes: 1 << 30, MaxItems: 100, MaxItemSize: 16 << 20}),
+	)
+
+	require.Equal(t,
+		"bytes: 10 B of unlimited\nitems: 1 of unlimited\nmax item size: unlimited\n",
+		formatUsage(&handler.UsageResponse{Bytes: 10, Items: 1}),
+	)
+}
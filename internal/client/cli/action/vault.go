@@ -0,0 +1,103 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
+)
+
+// prefixes of references to vault's items
+const (
+	secretRefPrefix = "secret:"
+	dataRefPrefix   = "data:"
+)
+
+// vaultResolver returns decrypted values of vault's items by references like secret:name or data:key
+type vaultResolver struct {
+	user    *storage.User
+	storage storage.Storage
+}
+
+func newVaultResolver(user *storage.User, s storage.Storage) *vaultResolver {
+	return &vaultResolver{user: user, storage: s}
+}
+
+func (r *vaultResolver) resolve(ctx context.Context, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretRefPrefix):
+		return r.secret(ctx, strings.TrimPrefix(ref, secretRefPrefix))
+	case strings.HasPrefix(ref, dataRefPrefix):
+		return r.dataContent(ctx, strings.TrimPrefix(ref, dataRefPrefix))
+	default:
+		return "", fmt.Errorf("bad reference=%s, use %sname or %skey", ref, secretRefPrefix, dataRefPrefix)
+	}
+}
+
+func (r *vaultResolver) secret(ctx context.Context, name string) (string, error) {
+	if len(name) == 0 {
+		return "", errors.New("empty secret's name")
+	}
+
+	secret, err := r.storage.GetSecret(ctx, r.user, name)
+	if err != nil {
+		return "", fmt.Errorf("get secret=%s, err=%w", name, err)
+	}
+
+	return secret.Value, nil
+}
+
+func (r *vaultResolver) dataContent(ctx context.Context, key string) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("empty data's key")
+	}
+
+	record, err := r.storage.LoadData(ctx, r.user, normalizeKey(key))
+	if errors.Is(err, sqlstorage.ErrDataNotExist) {
+		// records of older clients are keyed by raw paths
+		record, err = r.storage.LoadData(ctx, r.user, key)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("load data=%s, err=%w", key, err)
+	}
+
+	content, err := decryptUserData(r.user, []byte(record.Data))
+	if err != nil {
+		return "", fmt.Errorf("decrypt data=%s, err=%w", key, err)
+	}
+
+	return string(content), nil
+}
+
+// cardField returns one of card's fields: number, expiration, owner or cvv
+func (r *vaultResolver) cardField(ctx context.Context, number string, field string) (string, error) {
+	cards, err := r.storage.ListCard(ctx, r.user)
+	if err != nil {
+		return "", fmt.Errorf("list cards, err=%w", err)
+	}
+
+	for _, card := range cards {
+		if card.Number != number {
+			continue
+		}
+
+		switch field {
+		case "number":
+			return card.Number, nil
+		case "expiration":
+			return card.ExpiryDate.Format(storage.ExpirationFormat), nil
+		case "owner":
+			return card.Owner, nil
+		case "cvv":
+			return card.CvvCode, nil
+		default:
+			return "", fmt.Errorf("unknown card's field=%s, use number, expiration, owner or cvv", field)
+		}
+	}
+
+	return "", errors.New("card isn't found")
+}
@@ -4,53 +4,133 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
 	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 )
 
 func CreateCardActionHandler(
 	ctx context.Context,
 	user *storage.User,
-	storage storage.WalletStorage,
+	s storage.WalletStorage,
+	outbox storage.OutboxStorage,
 	client transport.WalletClient,
 	card *storage.BankCard,
 ) error {
-	data, err := storage.CreateCard(ctx, user, card)
-	if err != nil {
-		return err
-	}
+	var data string
 
-	if err = client.CreateCardData(ctx, user.Token, card.Number, data); err != nil {
-		return err
-	}
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
 
-	return nil
+			data, err = s.CreateCard(ctx, user, card)
+
+			return err
+		},
+		remote: func(ctx context.Context) error {
+			return client.CreateCardData(ctx, user.Token, card.Number, data)
+		},
+		compensate: func(ctx context.Context) error {
+			return client.DeleteCardData(ctx, user.Token, card.Number)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeCardOperation(storage.OperationCreate, card.Number, data)
+		},
+	})
 }
 
 func DeleteCardActionHandler(
 	ctx context.Context,
 	user *storage.User,
-	storage storage.WalletStorage,
+	s storage.WalletStorage,
+	outbox storage.OutboxStorage,
 	client transport.WalletClient,
 	cardNumber string,
 ) error {
-	if err := storage.DeleteCard(ctx, user, cardNumber); err != nil {
-		return err
+	var previousData string
+
+	return runAtomic(ctx, user, s, outbox, &atomicOperation{
+		local: func(ctx context.Context) error {
+			var err error
+
+			previousData, err = findCardData(ctx, user, s, cardNumber)
+			if err != nil {
+				return err
+			}
+
+			return s.DeleteCard(ctx, user, cardNumber)
+		},
+		remote: func(ctx context.Context) error {
+			return client.DeleteCardData(ctx, user.Token, cardNumber)
+		},
+		compensate: func(ctx context.Context) error {
+			if len(previousData) == 0 {
+				return fmt.Errorf("card=%s isn't stored locally", cardNumber)
+			}
+
+			return client.CreateCardData(ctx, user.Token, cardNumber, previousData)
+		},
+		pending: func() *storage.PendingOperation {
+			return makeCardOperation(storage.OperationDelete, cardNumber, "")
+		},
+	})
+}
+
+// findCardData returns encrypted card's data which is sent to the server or empty string if card isn't stored locally
+func findCardData(
+	ctx context.Context,
+	user *storage.User,
+	s storage.WalletStorage,
+	cardNumber string,
+) (string, error) {
+	cards, err := s.ListCard(ctx, user)
+	if err != nil {
+		return "", err
 	}
 
-	if err := client.DeleteCardData(ctx, user.Token, cardNumber); err != nil {
-		return err
+	for _, card := range cards {
+		if card == nil || card.Number != cardNumber {
+			continue
+		}
+
+		crypto, err := gophcrypto.New(user.CryptoKey)
+		if err != nil {
+			return "", err
+		}
+
+		return sqlstorage.NewSerializer(crypto).SerializeBankCard(card)
 	}
 
-	return nil
+	return "", nil
+}
+
+func makeCardOperation(operation string, cardNumber string, data string) *storage.PendingOperation {
+	return &storage.PendingOperation{Kind: storage.ItemKindCard, Operation: operation, Key: cardNumber, Data: data}
 }
 
+// ListCardActionHandler prints local cards or pages of cards' numbers on the server when opts is set
 func ListCardActionHandler(
 	ctx context.Context,
 	user *storage.User,
 	s storage.WalletStorage,
 	client transport.WalletClient,
+	opts *transport.ListOptions,
 ) error {
+	if opts != nil {
+		_, err := printPages(func(cursor string) ([]*handler.CardInfo, string, error) {
+			return client.ListCardData(ctx, user.Token, opts, cursor)
+		}, func(info *handler.CardInfo) {
+			fmt.Printf("num: %s; %s\n", info.Number, formatUpdateTime(info.UpdatedAt))
+		})
+		if err != nil {
+			return fmt.Errorf("list cards on server, err=%w", err)
+		}
+
+		return nil
+	}
+
 	list, err := s.ListCard(ctx, user)
 	if err != nil {
 		return err
@@ -16,6 +16,7 @@ func TestCreateCard(t *testing.T) {
 
 	mockStorage := storage.NewMockWalletStorage(ctrl)
 	mockClient := transport.NewMockWalletClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	ctx := context.Background()
 	u := &storage.User{Token: "token"}
@@ -23,10 +24,11 @@ func TestCreateCard(t *testing.T) {
 
 	mustBeCryptedData := "crypted_data"
 
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
 	mockStorage.EXPECT().CreateCard(ctx, u, c).Return(mustBeCryptedData, nil)
 	mockClient.EXPECT().CreateCardData(ctx, u.Token, c.Number, mustBeCryptedData).Return(nil)
 
-	err := CreateCardActionHandler(ctx, u, mockStorage, mockClient, c)
+	err := CreateCardActionHandler(ctx, u, mockStorage, mockOutbox, mockClient, c)
 	require.NoError(t, err)
 }
 
@@ -36,15 +38,18 @@ func TestDeleteCard(t *testing.T) {
 
 	mockStorage := storage.NewMockWalletStorage(ctrl)
 	mockClient := transport.NewMockWalletClient(ctrl)
+	mockOutbox := storage.NewMockOutboxStorage(ctrl)
 
 	ctx := context.Background()
 	u := &storage.User{Token: "token"}
 	c := &storage.BankCard{Number: "xxxx"}
 
+	mockStorage.EXPECT().InTransaction(ctx, gomock.Any()).DoAndReturn(passTransaction)
+	mockStorage.EXPECT().ListCard(ctx, u).Return(nil, nil)
 	mockStorage.EXPECT().DeleteCard(ctx, u, c.Number).Return(nil)
 	mockClient.EXPECT().DeleteCardData(ctx, u.Token, c.Number).Return(nil)
 
-	err := DeleteCardActionHandler(ctx, u, mockStorage, mockClient, c.Number)
+	err := DeleteCardActionHandler(ctx, u, mockStorage, mockOutbox, mockClient, c.Number)
 	require.NoError(t, err)
 }
 
@@ -61,6 +66,6 @@ func TestListCard(t *testing.T) {
 
 	mockStorage.EXPECT().ListCard(ctx, u).Return([]*storage.BankCard{c}, nil)
 
-	err := ListCardActionHandler(ctx, u, mockStorage, mockClient)
+	err := ListCardActionHandler(ctx, u, mockStorage, mockClient, nil)
 	require.NoError(t, err)
 }
@@ -6,14 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/kuzhukin/goph-keeper/internal/client/agent"
 	"github.com/kuzhukin/goph-keeper/internal/client/cli/action"
 	"github.com/kuzhukin/goph-keeper/internal/client/cli/args"
 	"github.com/kuzhukin/goph-keeper/internal/client/config"
+	"github.com/kuzhukin/goph-keeper/internal/client/sshagent"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage/sqlstorage"
 	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 	"github.com/urfave/cli/v2"
 )
 
@@ -90,20 +96,371 @@ func (a *Application) initCLI() {
 			a.makeDataCmd(),
 			a.makeWalletCmd(),
 			a.makeSecretCmd(),
+			a.makeStatusCmd(),
+			a.makeAgentCmd(),
+			a.makeSSHKeyCmd(),
+			a.makeSSHAgentCmd(),
+			a.makeRunCmd(),
+			a.makeRenderCmd(),
+			a.makeServiceAccountCmd(),
+			a.makeAuditCmd(),
+			a.makeUsageCmd(),
 		},
 	}
 }
 
+func (a *Application) makeStatusCmd() *cli.Command {
+	return &cli.Command{
+		Name:   "status",
+		Usage:  "Show local changes which aren't sent to server",
+		Before: a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "discard-failed",
+				Usage: "Remove operations which were rejected by server",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			return action.StatusAction(ctx.Context, a.user, a.storage, ctx.Bool("discard-failed"))
+		},
+	}
+}
+
+func (a *Application) makeAgentCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "agent",
+		Usage:       "Run background synchronization with server",
+		Description: "Applies changes from other devices to local storage and serves fast reads for other commands",
+		Before:      a.checkConfigAndReplay,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "socket",
+				Usage: "Path of agent's unix socket",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			socketPath, err := a.agentSocketPath(ctx.String("socket"))
+			if err != nil {
+				return err
+			}
+
+			runCtx, stop := signal.NotifyContext(ctx.Context, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			return agent.New(a.user, a.storage, a.client, socketPath).Run(runCtx)
+		},
+	}
+}
+
+// readStorage reads items through the agent when it's running
+func (a *Application) readStorage() storage.Storage {
+	socketPath, err := a.agentSocketPath("")
+	if err != nil {
+		return a.storage
+	}
+
+	return agent.NewStorage(a.storage, socketPath)
+}
+
+func (a *Application) agentSocketPath(socketPath string) (string, error) {
+	if len(socketPath) != 0 {
+		return socketPath, nil
+	}
+
+	return agent.DefaultSocketPath()
+}
+
+func (a *Application) makeRunCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "run",
+		Usage:       "Run command with environment variables from the vault",
+		UsageText:   "gophkeep run --env DB_PASS=secret:db/password --env TLS_KEY=data:tls/key.pem -- ./myservice",
+		Description: "Values are passed to the command's environment only and aren't written to disk or stdout",
+		Before:      a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "env",
+				Usage: "NAME=secret:name or NAME=data:key",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() == 0 {
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			err := action.RunAction(ctx.Context, a.user, a.readStorage(), ctx.StringSlice("env"), ctx.Args().Slice())
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return cli.Exit("", exitErr.ExitCode())
+			}
+
+			return err
+		},
+	}
+}
+
+func (a *Application) makeRenderCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "render",
+		Usage: "Render text/template with values from the vault",
+		Description: `Template's functions: secret "name", card "number" "field" (number, expiration, owner or cvv) and data "key".
+Output file is written with 0600 permissions`,
+		Before: a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "input", Aliases: []string{"i"}, Required: true},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Required: true},
+		},
+		Action: func(ctx *cli.Context) error {
+			return action.RenderAction(ctx.Context, a.user, a.readStorage(), ctx.String("input"), ctx.String("output"))
+		},
+	}
+}
+
+func (a *Application) makeAuditCmd() *cli.Command {
+	return &cli.Command{
+		Name:   "audit",
+		Usage:  "Show server's audit trail of your items' access and changes",
+		Before: a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "limit", Value: 100, Usage: "Number of latest events"},
+		},
+		Action: func(ctx *cli.Context) error {
+			return action.AuditAction(ctx.Context, a.user, a.client, ctx.Int("limit"))
+		},
+	}
+}
+
+func (a *Application) makeUsageCmd() *cli.Command {
+	return &cli.Command{
+		Name:   "usage",
+		Usage:  "Show consumption of server's storage by your items and your quota",
+		Before: a.checkConfig,
+		Action: func(ctx *cli.Context) error {
+			return action.UsageAction(ctx.Context, a.user, a.client)
+		},
+	}
+}
+
+func (a *Application) makeServiceAccountCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "service-account",
+		Usage:        "Operations with service accounts which have scoped tokens (e.g. for CI jobs)",
+		Before:       a.checkConfig,
+		BashComplete: cli.DefaultAppComplete,
+		Subcommands: []*cli.Command{
+			a.makeCreateServiceAccountCmd(),
+			a.makeListServiceAccountsCmd(),
+			a.makeRevokeServiceAccountCmd(),
+		},
+	}
+}
+
+func (a *Application) makeCreateServiceAccountCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "create",
+		Usage:        "Create service account and show its token",
+		BashComplete: cli.DefaultAppComplete,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name"},
+			&cli.StringSliceFlag{Name: "kind", Usage: "Available item kind: data, card, secret or ssh-key. All kinds by default"},
+			&cli.StringSliceFlag{Name: "prefix", Usage: "Available prefix of items' names. All names by default"},
+			&cli.BoolFlag{Name: "write", Usage: "Allow changes of items, token is read-only by default"},
+		},
+		Action: func(ctx *cli.Context) error {
+			name, err := args.GetServiceAccountName(ctx)
+			if err != nil {
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			scope, err := args.GetServiceAccountScope(ctx)
+			if err != nil {
+				return err
+			}
+
+			return action.CreateServiceAccountAction(ctx.Context, a.user, a.client, name, scope)
+		},
+	}
+}
+
+func (a *Application) makeListServiceAccountsCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "list",
+		Usage:        "Show service accounts",
+		BashComplete: cli.DefaultAppComplete,
+		Action: func(ctx *cli.Context) error {
+			return action.ListServiceAccountsAction(ctx.Context, a.user, a.client)
+		},
+	}
+}
+
+func (a *Application) makeRevokeServiceAccountCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "revoke",
+		Usage:        "Revoke service account's token",
+		BashComplete: cli.DefaultAppComplete,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name"},
+		},
+		Action: func(ctx *cli.Context) error {
+			name, err := args.GetServiceAccountName(ctx)
+			if err != nil {
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			return action.RevokeServiceAccountAction(ctx.Context, a.user, a.client, name)
+		},
+	}
+}
+
+func (a *Application) makeSSHKeyCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "ssh-key",
+		Usage:        "Operations with ssh keys",
+		Before:       a.checkConfigAndReplay,
+		BashComplete: cli.DefaultAppComplete,
+		Subcommands: []*cli.Command{
+			a.makeCreateSSHKeyCmd(),
+			a.makeDeleteSSHKeyCmd(),
+			a.makeListSSHKeysCmd(),
+			a.makePullSSHKeysCmd(),
+		},
+	}
+}
+
+func (a *Application) makeCreateSSHKeyCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "create",
+		Usage:        "Save ssh private key to the vault",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name"},
+			&cli.StringFlag{Name: "file", Usage: "Private key in PEM or OpenSSH format"},
+			&cli.StringFlag{Name: "passphrase", Usage: "Passphrase of the key's file, key is stored without it"},
+			&cli.StringFlag{Name: "comment"},
+			&cli.BoolFlag{Name: "confirm", Usage: "Ask confirmation before every usage of the key in ssh-agent"},
+			&cli.DurationFlag{Name: "lifetime", Usage: "Lifetime of the key in ssh-agent"},
+		},
+		Action: func(ctx *cli.Context) error {
+			key, err := args.GetSSHKey(ctx)
+			if err != nil {
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			filename := args.GetFileArg(ctx)
+
+			return action.CreateSSHKeyAction(
+				ctx.Context, a.user, a.storage, a.storage, a.client, key, filename, ctx.String("passphrase"),
+			)
+		},
+	}
+}
+
+func (a *Application) makeDeleteSSHKeyCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "delete",
+		Usage:        "Delete ssh key",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name"},
+		},
+		Action: func(ctx *cli.Context) error {
+			name, err := args.GetSSHKeyName(ctx)
+			if err != nil {
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			return action.DeleteSSHKeyAction(ctx.Context, a.user, a.storage, a.storage, a.client, name)
+		},
+	}
+}
+
+func (a *Application) makeListSSHKeysCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "list",
+		Usage:        "Show ssh keys' fingerprints",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Action: func(ctx *cli.Context) error {
+			return action.ListSSHKeysAction(ctx.Context, a.user, a.storage)
+		},
+	}
+}
+
+func (a *Application) makePullSSHKeysCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "pull",
+		Usage:        "Download ssh keys which were added by other devices",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Action: func(ctx *cli.Context) error {
+			return action.PullSSHKeysAction(ctx.Context, a.user, a.storage, a.client)
+		},
+	}
+}
+
+func (a *Application) makeSSHAgentCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "ssh-agent",
+		Usage:       "Run ssh-agent with keys from the vault",
+		Description: "Keys are decrypted in memory only. Use printed SSH_AUTH_SOCK with ssh clients",
+		Before:      a.checkConfigAndReplay,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "socket",
+				Usage: "Path of ssh-agent's unix socket",
+			},
+			&cli.BoolFlag{
+				Name:  "confirm",
+				Usage: "Ask confirmation before usage of every key",
+			},
+			&cli.DurationFlag{
+				Name:  "lifetime",
+				Usage: "Lifetime of keys which don't have own lifetime",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			socketPath, err := a.sshAgentSocketPath(ctx.String("socket"))
+			if err != nil {
+				return err
+			}
+
+			listener, err := agent.ListenSocket(socketPath)
+			if err != nil {
+				return err
+			}
+
+			runCtx, stop := signal.NotifyContext(ctx.Context, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			return action.SSHAgentAction(
+				runCtx, a.user, a.storage, listener, sshagent.NewAskpassConfirmer(), ctx.Bool("confirm"), ctx.Duration("lifetime"),
+			)
+		},
+	}
+}
+
+func (a *Application) sshAgentSocketPath(socketPath string) (string, error) {
+	if len(socketPath) != 0 {
+		return socketPath, nil
+	}
+
+	return sshagent.DefaultSocketPath()
+}
+
 func (a *Application) makeSecretCmd() *cli.Command {
 	return &cli.Command{
 		Name:         "secret",
 		Usage:        "Operations with bank's cards",
-		Before:       a.checkConfig,
+		Before:       a.checkConfigAndReplay,
 		BashComplete: cli.DefaultAppComplete,
 		Subcommands: []*cli.Command{
 			a.makeCreateSecretCmd(),
 			a.makeDeleteSecretCmd(),
 			a.makeGetSecretCmd(),
+			a.makeListSecretsCmd(),
 		},
 	}
 }
@@ -125,7 +482,7 @@ func (a *Application) makeCreateSecretCmd() *cli.Command {
 				cli.ShowSubcommandHelpAndExit(ctx, 1)
 			}
 
-			return action.CreateSecretAction(ctx.Context, a.user, a.storage, a.client, secret)
+			return action.CreateSecretAction(ctx.Context, a.user, a.storage, a.storage, a.client, secret)
 		},
 	}
 }
@@ -145,7 +502,7 @@ func (a *Application) makeDeleteSecretCmd() *cli.Command {
 				cli.ShowSubcommandHelpAndExit(ctx, 1)
 			}
 
-			return action.DeleteSecretAction(ctx.Context, a.user, a.storage, a.client, secretName)
+			return action.DeleteSecretAction(ctx.Context, a.user, a.storage, a.storage, a.client, secretName)
 		},
 	}
 }
@@ -165,17 +522,59 @@ func (a *Application) makeGetSecretCmd() *cli.Command {
 				cli.ShowSubcommandHelpAndExit(ctx, 1)
 			}
 
-			return action.GetSecretAction(ctx.Context, a.user, a.storage, a.client, secretName)
+			return action.GetSecretAction(ctx.Context, a.user, a.readStorage(), a.client, secretName)
+		},
+	}
+}
+
+func (a *Application) makeListSecretsCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "list",
+		Usage:        "Print names of secrets on server",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Flags:        listFlags(),
+		Action: func(ctx *cli.Context) error {
+			opts, err := args.GetListOptions(ctx)
+			if err != nil {
+				return err
+			}
+
+			return action.ListSecretsAction(ctx.Context, a.user, a.client, opts)
 		},
 	}
 }
 
+// listFlags are filters of server's list, they are used with "remote" flag by lists of local items
+func listFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "prefix", Usage: "Prefix of items' names"},
+		&cli.StringFlag{Name: "since", Usage: "Items which were changed since the time in RFC 3339 or during the duration, e.g. 24h"},
+		&cli.StringFlag{Name: "sort", Value: handler.SortByName, Usage: "Sort order: name or updated, \"-\" before the order is a descending sort"},
+		&cli.IntFlag{Name: "page-size", Usage: "Number of items in the server's response, server's default is used when it's 0"},
+	}
+}
+
+// remoteListFlags are flags of lists which print local items by default
+func remoteListFlags() []cli.Flag {
+	return append([]cli.Flag{&cli.BoolFlag{Name: "remote", Usage: "List items on server page by page"}}, listFlags()...)
+}
+
+// getRemoteListOptions returns nil options when local items are listed
+func getRemoteListOptions(ctx *cli.Context) (*transport.ListOptions, error) {
+	if !ctx.Bool("remote") {
+		return nil, nil
+	}
+
+	return args.GetListOptions(ctx)
+}
+
 func (a *Application) makeWalletCmd() *cli.Command {
 	return &cli.Command{
 		Name:         "wallet",
 		Usage:        "Operations with bank's cards",
 		BashComplete: cli.DefaultAppComplete,
-		Before:       a.checkConfig,
+		Before:       a.checkConfigAndReplay,
 		Subcommands: []*cli.Command{
 			a.makeCreateCardCmd(),
 			a.makeDeleteCardCmd(),
@@ -201,7 +600,7 @@ func (a *Application) makeCreateCardCmd() *cli.Command {
 				cli.ShowSubcommandHelpAndExit(ctx, 1)
 			}
 
-			return action.CreateCardActionHandler(ctx.Context, a.user, a.storage, a.client, card)
+			return action.CreateCardActionHandler(ctx.Context, a.user, a.storage, a.storage, a.client, card)
 		},
 	}
 }
@@ -221,7 +620,7 @@ func (a *Application) makeDeleteCardCmd() *cli.Command {
 				cli.ShowSubcommandHelpAndExit(ctx, 1)
 			}
 
-			return action.DeleteCardActionHandler(ctx.Context, a.user, a.storage, a.client, card)
+			return action.DeleteCardActionHandler(ctx.Context, a.user, a.storage, a.storage, a.client, card)
 		},
 	}
 }
@@ -232,8 +631,14 @@ func (a *Application) makeListCardCmd() *cli.Command {
 		Usage:        "List with all user cards",
 		BashComplete: cli.DefaultAppComplete,
 		Before:       a.checkConfig,
+		Flags:        remoteListFlags(),
 		Action: func(ctx *cli.Context) error {
-			return action.ListCardActionHandler(ctx.Context, a.user, a.storage, a.client)
+			opts, err := getRemoteListOptions(ctx)
+			if err != nil {
+				return err
+			}
+
+			return action.ListCardActionHandler(ctx.Context, a.user, a.readStorage(), a.client, opts)
 		},
 	}
 }
@@ -243,13 +648,15 @@ func (a *Application) makeDataCmd() *cli.Command {
 		Name:         "data",
 		Usage:        "Operations with text or binary data",
 		BashComplete: cli.DefaultAppComplete,
-		Before:       a.checkConfig,
+		Before:       a.checkConfigAndReplay,
 		Subcommands: []*cli.Command{
 			a.makeCreateDataCmd(),
 			a.makeGetDataCmd(),
 			a.makeListDataCmd(),
 			a.makeUpdateDataCmd(),
 			a.makeDeleteDataCmd(),
+			a.makePushDataCmd(),
+			a.makePullDataCmd(),
 		},
 	}
 }
@@ -269,6 +676,10 @@ func (a *Application) makeConfigCmd() *cli.Command {
 				Name:  "database-name",
 				Usage: "Database's file's name",
 			},
+			&cli.StringFlag{
+				Name:  "compression",
+				Usage: "Compression of data before encryption: none, gzip or zstd",
+			},
 		},
 		Action: action.ConfigAction(configFileName),
 	}
@@ -316,7 +727,7 @@ func (a *Application) makeCreateDataCmd() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			filename := args.GetFileArg(ctx)
 
-			return action.CreateDataAction(ctx.Context, a.user, a.storage, a.client, filename)
+			return action.CreateDataAction(ctx.Context, a.user, a.storage, a.storage, a.client, filename, a.config.Compression)
 		},
 	}
 }
@@ -338,11 +749,22 @@ func (a *Application) makeGetDataCmd() *cli.Command {
 				Aliases: []string{"o"},
 				Usage:   "Output directory",
 			},
+			&cli.BoolFlag{
+				Name:  "stdout",
+				Usage: "Print file's content to stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Overwrite existing file",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
 			filename := args.GetFileArg(ctx)
+			outputDir := args.GetOutputDir(ctx)
 
-			return action.GetDataAction(ctx.Context, a.user, a.storage, a.client, filename)
+			return action.GetDataAction(
+				ctx.Context, a.user, a.readStorage(), a.client, filename, outputDir, ctx.Bool("stdout"), ctx.Bool("force"),
+			)
 		},
 	}
 }
@@ -352,8 +774,14 @@ func (a *Application) makeListDataCmd() *cli.Command {
 		Name:   "list",
 		Usage:  "Print local data names and revisions",
 		Before: a.checkConfig,
+		Flags:  remoteListFlags(),
 		Action: func(ctx *cli.Context) error {
-			return action.ListDataAction(ctx.Context, a.user, a.storage, a.client)
+			opts, err := getRemoteListOptions(ctx)
+			if err != nil {
+				return err
+			}
+
+			return action.ListDataAction(ctx.Context, a.user, a.readStorage(), a.client, opts)
 		},
 	}
 }
@@ -378,7 +806,7 @@ func (a *Application) makeUpdateDataCmd() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			filename := args.GetFileArg(ctx)
 
-			return action.UpdateAction(ctx.Context, a.user, a.storage, a.client, filename)
+			return action.UpdateAction(ctx.Context, a.user, a.storage, a.storage, a.client, filename, a.config.Compression)
 		},
 	}
 }
@@ -400,7 +828,57 @@ func (a *Application) makeDeleteDataCmd() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			filename := args.GetFileArg(ctx)
 
-			return action.DeleteBinaryDataAction(ctx.Context, a.user, a.storage, a.client, filename)
+			return action.DeleteBinaryDataAction(ctx.Context, a.user, a.storage, a.storage, a.client, filename)
+		},
+	}
+}
+
+func (a *Application) makePushDataCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "push",
+		Usage:        "Upload new and changed files of directory to server",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Aliases: []string{"d"},
+				Usage:   "path to directory",
+			},
+			&cli.BoolFlag{
+				Name:  "delete",
+				Usage: "Delete data on server which was removed from directory",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			dir := args.GetDirArg(ctx)
+
+			return action.PushDirAction(ctx.Context, a.user, a.storage, a.client, dir, ctx.Bool("delete"), a.config.Compression)
+		},
+	}
+}
+
+func (a *Application) makePullDataCmd() *cli.Command {
+	return &cli.Command{
+		Name:         "pull",
+		Usage:        "Download new and changed data from server to directory",
+		BashComplete: cli.DefaultAppComplete,
+		Before:       a.checkConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Aliases: []string{"d"},
+				Usage:   "path to directory",
+			},
+			&cli.BoolFlag{
+				Name:  "delete",
+				Usage: "Delete files which were removed on server",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			dir := args.GetDirArg(ctx)
+
+			return action.PullDirAction(ctx.Context, a.user, a.storage, a.client, dir, ctx.Bool("delete"))
 		},
 	}
 }
@@ -425,9 +903,22 @@ func (a *Application) checkConfig(ctx *cli.Context) error {
 	return nil
 }
 
+// checkConfigAndReplay sends operations which were made without connection to server
+func (a *Application) checkConfigAndReplay(ctx *cli.Context) error {
+	if err := a.checkConfig(ctx); err != nil {
+		return err
+	}
+
+	if err := action.ReplayOutboxAction(ctx.Context, a.user, a.storage, a.client); err != nil {
+		fmt.Println("Sending of pending operations failed:", friendlyError(err))
+	}
+
+	return nil
+}
+
 func (a *Application) Run() error {
 	if err := a.cli.Run(os.Args); err != nil {
-		return err
+		return friendlyError(err)
 	}
 
 	return nil
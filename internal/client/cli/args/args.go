@@ -3,8 +3,12 @@ package args
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,6 +21,19 @@ func GetFileArg(ctx *cli.Context) string {
 	return filename
 }
 
+func GetDirArg(ctx *cli.Context) string {
+	dir := ctx.String("dir")
+	if len(dir) == 0 {
+		cli.ShowAppHelpAndExit(ctx, 1)
+	}
+
+	return dir
+}
+
+func GetOutputDir(ctx *cli.Context) string {
+	return ctx.String("output-dir")
+}
+
 func GetSecretName(ctx *cli.Context) (string, error) {
 	name := ctx.String("name")
 	if len(name) == 0 {
@@ -104,3 +121,91 @@ func GetPassword(ctx *cli.Context) string {
 
 	return value
 }
+
+func GetSSHKeyName(ctx *cli.Context) (string, error) {
+	name := ctx.String("name")
+	if len(name) == 0 {
+		return "", errors.New("bad ssh key's name")
+	}
+
+	return name, nil
+}
+
+func GetSSHKey(ctx *cli.Context) (*storage.SSHKey, error) {
+	name := ctx.String("name")
+	if len(name) == 0 {
+		return nil, errors.New("bad ssh key's name")
+	}
+
+	lifetime := ctx.Duration("lifetime")
+	if lifetime < 0 {
+		return nil, errors.New("bad ssh key's lifetime")
+	}
+
+	return &storage.SSHKey{
+		Name:     name,
+		Comment:  ctx.String("comment"),
+		Confirm:  ctx.Bool("confirm"),
+		Lifetime: lifetime,
+	}, nil
+}
+
+func GetServiceAccountName(ctx *cli.Context) (string, error) {
+	name := ctx.String("name")
+	if len(name) == 0 {
+		return "", errors.New("bad service account's name")
+	}
+
+	return name, nil
+}
+
+func GetServiceAccountScope(ctx *cli.Context) (*handler.Scope, error) {
+	scope := &handler.Scope{
+		Kinds:    ctx.StringSlice("kind"),
+		Prefixes: ctx.StringSlice("prefix"),
+		ReadOnly: !ctx.Bool("write"),
+	}
+
+	if !scope.Validate() {
+		return nil, fmt.Errorf("bad item kinds=%v", scope.Kinds)
+	}
+
+	return scope, nil
+}
+
+// GetListOptions reads filters of server's list, "since" is RFC 3339 time or duration before now
+func GetListOptions(ctx *cli.Context) (*transport.ListOptions, error) {
+	opts := &transport.ListOptions{
+		Prefix:   ctx.String("prefix"),
+		Sort:     ctx.String("sort"),
+		PageSize: ctx.Int("page-size"),
+	}
+
+	if order := strings.TrimPrefix(opts.Sort, "-"); len(order) > 0 && order != handler.SortByName && order != handler.SortByUpdated {
+		return nil, fmt.Errorf("bad sort=%s", opts.Sort)
+	}
+
+	if opts.PageSize < 0 || opts.PageSize > handler.MaxPageSize {
+		return nil, fmt.Errorf("bad page size=%d", opts.PageSize)
+	}
+
+	since := ctx.String("since")
+	if len(since) == 0 {
+		return opts, nil
+	}
+
+	if d, err := time.ParseDuration(since); err == nil {
+		opts.UpdatedSince = time.Now().Add(-d)
+
+		return opts, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return nil, fmt.Errorf("bad since=%s", since)
+	}
+
+	opts.UpdatedSince = t
+
+	return opts, nil
+}
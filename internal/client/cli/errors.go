@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// friendlyMessages explain server's errors to user, the first matched error is shown
+var friendlyMessages = []struct {
+	err     error
+	message string
+}{
+	{transport.ErrServerUnavailable, "server is unavailable, check hostport in the config or retry later"},
+	{transport.ErrUnauthorized, "authentication failed, check login and password or register again"},
+	{transport.ErrForbidden, "token's scope doesn't allow the operation, use the owner's account"},
+	{transport.ErrNotFound, "item isn't found on the server, run sync to update local items"},
+	{transport.ErrAlreadyExists, "item already exists on the server, run sync or choose another name"},
+	{transport.ErrRevisionConflict, "item was changed on another device, run sync and retry"},
+	{transport.ErrTooLarge, "item is larger than the server allows"},
+	{transport.ErrQuotaExceeded, "storage quota is exceeded, see usage command"},
+	{transport.ErrBadRequest, "server rejected the request, client may be older than the server"},
+	{transport.ErrInternal, "server failed to handle the request, retry later"},
+}
+
+// userError shows friendly message instead of the wrapped error
+type userError struct {
+	message string
+	err     error
+}
+
+func (e *userError) Error() string {
+	return e.message
+}
+
+func (e *userError) Unwrap() error {
+	return e.err
+}
+
+// friendlyError replaces server's errors with messages for user, the original error is logged,
+// errors without friendly message are returned as is
+func friendlyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// server's message has the time to wait
+	if errors.Is(err, transport.ErrTooManyRequests) {
+		apiErr := &transport.APIError{}
+		if errors.As(err, &apiErr) {
+			return &userError{message: apiErr.Message, err: err}
+		}
+	}
+
+	for _, m := range friendlyMessages {
+		if errors.Is(err, m.err) {
+			zlog.Logger().Debugf("%s: %s", m.message, err)
+
+			return &userError{message: m.message, err: err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFriendlyError(t *testing.T) {
+	conflict := fmt.Errorf("update data key, err=%w", &transport.APIError{StatusCode: 412, Code: "revision_conflict", Message: "data has another revision"})
+
+	err := friendlyError(conflict)
+	require.Equal(t, "item was changed on another device, run sync and retry", err.Error())
+	require.ErrorIs(t, err, transport.ErrRevisionConflict)
+
+	limited := fmt.Errorf("sync, err=%w", &transport.APIError{StatusCode: 429, Code: "too_many_requests", Message: "too many requests, retry after 60s"})
+	require.Equal(t, "too many requests, retry after 60s", friendlyError(limited).Error())
+
+	other := errors.New("local storage is broken")
+	require.Equal(t, other, friendlyError(other))
+	require.NoError(t, friendlyError(nil))
+}
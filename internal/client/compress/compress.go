@@ -0,0 +1,142 @@
+// Package compress packs user's data into a versioned envelope before encryption
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	AlgorithmNone = "none"
+	AlgorithmGzip = "gzip"
+	AlgorithmZstd = "zstd"
+)
+
+// magic starts the envelope, the high bit makes it unlikely at the beginning of text files which were stored without envelope
+var magic = []byte{0x89, 'G', 'K', 'Z'}
+
+// envelope's header is magic, version and algorithm's id
+const (
+	version1   byte = 1
+	headerSize      = 6
+)
+
+const (
+	idGzip byte = 1
+	idZstd byte = 2
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown compression algorithm")
+
+// ValidAlgorithm checks algorithm's name of client's config, empty name doesn't compress data
+func ValidAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case "", AlgorithmNone, AlgorithmGzip, AlgorithmZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pack compresses data into the envelope, data is returned as is when it isn't compressed or compression doesn't reduce it,
+// so clients which don't know envelopes read it
+func Pack(data []byte, algorithm string) ([]byte, error) {
+	var id byte
+
+	switch algorithm {
+	case "", AlgorithmNone:
+		return data, nil
+	case AlgorithmGzip:
+		id = idGzip
+	case AlgorithmZstd:
+		id = idZstd
+	default:
+		return nil, fmt.Errorf("algorithm=%s, err=%w", algorithm, ErrUnknownAlgorithm)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, headerSize+len(data)/2))
+	buf.Write(magic)
+	buf.WriteByte(version1)
+	buf.WriteByte(id)
+
+	if err := compress(buf, data, id); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() >= len(data) {
+		return data, nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unpack detects the envelope and decompresses its data, data without envelope is returned as is
+func Unpack(data []byte) ([]byte, error) {
+	if len(data) < headerSize || !bytes.HasPrefix(data, magic) {
+		return data, nil
+	}
+
+	if version := data[len(magic)]; version != version1 {
+		return nil, fmt.Errorf("envelope's version=%d isn't supported, update client", version)
+	}
+
+	id := data[len(magic)+1]
+	payload := bytes.NewReader(data[headerSize:])
+
+	var r io.Reader
+
+	switch id {
+	case idGzip:
+		gr, err := gzip.NewReader(payload)
+		if err != nil {
+			return nil, fmt.Errorf("gzip reader, err=%w", err)
+		}
+		defer gr.Close()
+
+		r = gr
+	case idZstd:
+		zr, err := zstd.NewReader(payload)
+		if err != nil {
+			return nil, fmt.Errorf("zstd reader, err=%w", err)
+		}
+		defer zr.Close()
+
+		r = zr
+	default:
+		return nil, fmt.Errorf("algorithm's id=%d, err=%w", id, ErrUnknownAlgorithm)
+	}
+
+	unpacked, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompress, err=%w", err)
+	}
+
+	return unpacked, nil
+}
+
+func compress(w io.Writer, data []byte, id byte) error {
+	var cw io.WriteCloser
+
+	switch id {
+	case idGzip:
+		cw = gzip.NewWriter(w)
+	case idZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return fmt.Errorf("zstd writer, err=%w", err)
+		}
+
+		cw = zw
+	}
+
+	if _, err := cw.Write(data); err != nil {
+		return errors.Join(fmt.Errorf("compress, err=%w", err), cw.Close())
+	}
+
+	return cw.Close()
+}
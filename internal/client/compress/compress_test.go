@@ -0,0 +1,55 @@
+package compress
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackUnpack(t *testing.T) {
+	data := bytes.Repeat([]byte("log line which repeats\n"), 100)
+
+	for _, algorithm := range []string{AlgorithmGzip, AlgorithmZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			packed, err := Pack(data, algorithm)
+			require.NoError(t, err)
+			require.True(t, bytes.HasPrefix(packed, magic))
+			require.Less(t, len(packed), len(data))
+
+			unpacked, err := Unpack(packed)
+			require.NoError(t, err)
+			require.Equal(t, data, unpacked)
+		})
+	}
+}
+
+func TestPackWithoutCompression(t *testing.T) {
+	// short data isn't reduced by compression
+	data := []byte("short")
+
+	for _, algorithm := range []string{"", AlgorithmNone, AlgorithmGzip, AlgorithmZstd} {
+		packed, err := Pack(data, algorithm)
+		require.NoError(t, err)
+		require.Equal(t, data, packed)
+	}
+
+	_, err := Pack(data, "lz4")
+	require.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
+
+func TestUnpackWithoutEnvelope(t *testing.T) {
+	for _, data := range [][]byte{{}, []byte("plain data"), magic} {
+		unpacked, err := Unpack(data)
+		require.NoError(t, err)
+		require.Equal(t, data, unpacked)
+	}
+}
+
+func TestUnpackUnsupportedEnvelope(t *testing.T) {
+	_, err := Unpack(append(append([]byte{}, magic...), 2, idGzip))
+	require.Error(t, err)
+
+	_, err = Unpack(append(append([]byte{}, magic...), version1, 9))
+	require.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/kuzhukin/goph-keeper/internal/client/compress"
 	"github.com/kuzhukin/goph-keeper/internal/yaml"
 )
 
@@ -17,6 +19,8 @@ const (
 type Config struct {
 	Hostport string `yaml:"hostport"`
 	Database string `yaml:"database"`
+	// Compression is algorithm which compresses data before encryption, data isn't compressed when it's empty
+	Compression string `yaml:"compression,omitempty"`
 }
 
 func ReadConfig(filename string) (*Config, error) {
@@ -75,6 +79,14 @@ func UpdateConfig(filename string, params map[string]string) error {
 		config.Database = database
 	}
 
+	if compression, ok := params["compression"]; ok {
+		if !compress.ValidAlgorithm(compression) {
+			return fmt.Errorf("compression=%s, err=%w", compression, compress.ErrUnknownAlgorithm)
+		}
+
+		config.Compression = compression
+	}
+
 	return yaml.WriteYaml(fullPath, config)
 }
 
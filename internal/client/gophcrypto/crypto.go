@@ -3,12 +3,23 @@ package gophcrypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 )
 
+// labels derive keys of hashes from crypto key, so hashes don't use the encryption key
+const (
+	contentHashLabel = "goph-keeper content hash"
+	chunkHashLabel   = "goph-keeper chunk hash"
+)
+
+// chunkHashSize is size of chunk's hash in bytes, it only distinguishes chunks of the one file
+const chunkHashSize = 16
+
 type Cryptographer struct {
 	cipher cipher.AEAD
 	vector []byte
@@ -53,6 +64,63 @@ func (c *Cryptographer) Decrypt(base64data []byte) ([]byte, error) {
 	return dst, nil
 }
 
+// EncryptWithRandomNonce encrypts data with a new random nonce which is prepended to the ciphertext,
+// so many parts of the one file can be encrypted by the same key
+func (c *Cryptographer) EncryptWithRandomNonce(data []byte) (string, error) {
+	nonce, err := generateRandom(c.cipher.NonceSize())
+	if err != nil {
+		return "", fmt.Errorf("generate nonce err=%w", err)
+	}
+
+	dst := c.cipher.Seal(nonce, nonce, data, nil)
+
+	return base64.RawStdEncoding.EncodeToString(dst), nil
+}
+
+func (c *Cryptographer) DecryptWithRandomNonce(base64data []byte) ([]byte, error) {
+	data := make([]byte, base64.RawStdEncoding.DecodedLen(len(base64data)))
+
+	_, err := base64.RawStdEncoding.Decode(data, base64data)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode err=%w", err)
+	}
+
+	if len(data) < c.cipher.NonceSize() {
+		return nil, fmt.Errorf("data is shorter than nonce")
+	}
+
+	nonce, ciphertext := data[:c.cipher.NonceSize()], data[c.cipher.NonceSize():]
+
+	dst, err := c.cipher.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decode err=%w", err)
+	}
+
+	return dst, nil
+}
+
+// ContentHash returns keyed hash of user's plain content, so equal files have equal hashes regardless
+// of their encryption and compression and server deduplicates them without knowing anything about the content
+func ContentHash(cryptoKey []byte, data []byte) string {
+	return hex.EncodeToString(keyedHash(cryptoKey, contentHashLabel, data))
+}
+
+// ChunkHash returns keyed hash of the file's plain chunk, hashes are kept in encrypted metainfo,
+// so the client finds unchanged chunks of the next revision without decrypting all of them
+func ChunkHash(cryptoKey []byte, data []byte) string {
+	return hex.EncodeToString(keyedHash(cryptoKey, chunkHashLabel, data)[:chunkHashSize])
+}
+
+func keyedHash(cryptoKey []byte, label string, data []byte) []byte {
+	keyMac := hmac.New(sha256.New, cryptoKey)
+	keyMac.Write([]byte(label))
+
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
 func GenerateCryptoKey() ([]byte, error) {
 	const keyLenDefault = aes.BlockSize
 
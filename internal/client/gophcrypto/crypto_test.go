@@ -38,3 +38,58 @@ func TestGenerateCryptoKey(t *testing.T) {
 	require.NotNil(t, key)
 	require.Len(t, key, aes.BlockSize)
 }
+
+func TestContentHash(t *testing.T) {
+	key, err := GenerateCryptoKey()
+	require.NoError(t, err)
+
+	otherKey, err := GenerateCryptoKey()
+	require.NoError(t, err)
+
+	hash := ContentHash(key, []byte("data"))
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, ContentHash(key, []byte("data")))
+	require.NotEqual(t, hash, ContentHash(key, []byte("other data")))
+	// another user's hash of the same data doesn't match
+	require.NotEqual(t, hash, ContentHash(otherKey, []byte("data")))
+}
+
+func TestEncryptWithRandomNonce(t *testing.T) {
+	key, err := GenerateCryptoKey()
+	require.NoError(t, err)
+
+	c, err := New(key)
+	require.NoError(t, err)
+
+	data := []byte("chunk")
+
+	first, err := c.EncryptWithRandomNonce(data)
+	require.NoError(t, err)
+
+	second, err := c.EncryptWithRandomNonce(data)
+	require.NoError(t, err)
+
+	// equal data isn't encrypted to equal ciphertexts
+	require.NotEqual(t, first, second)
+
+	for _, encrypted := range []string{first, second} {
+		decrypted, err := c.DecryptWithRandomNonce([]byte(encrypted))
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+	}
+
+	_, err = c.DecryptWithRandomNonce([]byte("AAA"))
+	require.Error(t, err)
+}
+
+func TestChunkHash(t *testing.T) {
+	key, err := GenerateCryptoKey()
+	require.NoError(t, err)
+
+	hash := ChunkHash(key, []byte("chunk"))
+	require.Len(t, hash, 32)
+	require.Equal(t, hash, ChunkHash(key, []byte("chunk")))
+	require.NotEqual(t, hash, ChunkHash(key, []byte("other chunk")))
+	// chunk's hash isn't the content hash of the same data
+	require.NotContains(t, ContentHash(key, []byte("chunk")), hash)
+}
@@ -0,0 +1,30 @@
+package sshagent
+
+import (
+	"os"
+	"os/exec"
+)
+
+const defaultAskpassProgram = "ssh-askpass"
+
+// AskpassConfirmer asks confirmation with SSH_ASKPASS program like OpenSSH's ssh-agent
+type AskpassConfirmer struct {
+	program string
+}
+
+func NewAskpassConfirmer() *AskpassConfirmer {
+	program := os.Getenv("SSH_ASKPASS")
+	if len(program) == 0 {
+		program = defaultAskpassProgram
+	}
+
+	return &AskpassConfirmer{program: program}
+}
+
+// Confirm returns true when user allows usage, askpass program exits with non zero code otherwise
+func (c *AskpassConfirmer) Confirm(prompt string) bool {
+	cmd := exec.Command(c.program, prompt)
+	cmd.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=confirm")
+
+	return cmd.Run() == nil
+}
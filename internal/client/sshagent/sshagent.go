@@ -0,0 +1,206 @@
+package sshagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/config"
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const socketFileName = "ssh-agent.sock"
+
+// interval of removing expired keys from memory
+const expireInterval = time.Second * 10
+
+var ErrNotConfirmed = errors.New("signing isn't confirmed by user")
+
+// Confirmer asks user to allow usage of the key
+type Confirmer interface {
+	Confirm(prompt string) bool
+}
+
+var _ agent.ExtendedAgent = &Agent{}
+
+// Agent implements ssh-agent protocol with keys which are held in memory only.
+// Keys added with confirmation constraint are used only after user's confirmation.
+type Agent struct {
+	agent.ExtendedAgent
+
+	mu        sync.Mutex
+	confirm   map[string]string
+	confirmer Confirmer
+}
+
+func New(confirmer Confirmer) *Agent {
+	return &Agent{
+		ExtendedAgent: agent.NewKeyring().(agent.ExtendedAgent),
+		confirm:       make(map[string]string),
+		confirmer:     confirmer,
+	}
+}
+
+// DefaultSocketPath returns path of ssh-agent's socket in the application's directory
+func DefaultSocketPath() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homedir, config.DefaultAppDirName, socketFileName), nil
+}
+
+// AddVaultKey decrypts vault's key into the agent's memory with the key's constraints
+func (a *Agent) AddVaultKey(key *storage.SSHKey) error {
+	privateKey, err := ssh.ParseRawPrivateKey(key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("parse ssh key=%s, err=%w", key.Name, err)
+	}
+
+	comment := key.Comment
+	if len(comment) == 0 {
+		comment = key.Name
+	}
+
+	return a.Add(agent.AddedKey{
+		PrivateKey:       privateKey,
+		Comment:          comment,
+		LifetimeSecs:     uint32(key.Lifetime.Seconds()),
+		ConfirmBeforeUse: key.Confirm,
+	})
+}
+
+func (a *Agent) Add(key agent.AddedKey) error {
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	if err := a.ExtendedAgent.Add(key); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	blob := string(signer.PublicKey().Marshal())
+	if key.ConfirmBeforeUse {
+		a.confirm[blob] = key.Comment
+	} else {
+		delete(a.confirm, blob)
+	}
+
+	return nil
+}
+
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if err := a.confirmUsage(key); err != nil {
+		return nil, err
+	}
+
+	return a.ExtendedAgent.SignWithFlags(key, data, flags)
+}
+
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	if err := a.ExtendedAgent.Remove(key); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.confirm, string(key.Marshal()))
+
+	return nil
+}
+
+func (a *Agent) RemoveAll() error {
+	if err := a.ExtendedAgent.RemoveAll(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.confirm = make(map[string]string)
+
+	return nil
+}
+
+func (a *Agent) confirmUsage(key ssh.PublicKey) error {
+	// lock isn't held while user answers, so other clients aren't blocked
+	a.mu.Lock()
+	comment, ok := a.confirm[string(key.Marshal())]
+	a.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	prompt := fmt.Sprintf("Allow use of key %s?\nKey fingerprint %s.", comment, ssh.FingerprintSHA256(key))
+	if !a.confirmer.Confirm(prompt) {
+		zlog.Logger().Infof("usage of ssh key=%s isn't confirmed", comment)
+
+		return ErrNotConfirmed
+	}
+
+	return nil
+}
+
+// Serve handles ssh-agent's clients until ctx is done
+func (a *Agent) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	go a.expireKeys(ctx)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("accept ssh-agent's client, err=%w", err)
+		}
+
+		go func() {
+			defer conn.Close()
+
+			if err := agent.ServeAgent(a, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+				zlog.Logger().Debugf("ssh-agent's client is disconnected, err=%s", err)
+			}
+		}()
+	}
+}
+
+// expireKeys removes keys with expired lifetime from memory even when agent isn't used
+func (a *Agent) expireKeys(ctx context.Context) {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// keyring removes expired keys on listing
+			_, _ = a.List()
+		}
+	}
+}
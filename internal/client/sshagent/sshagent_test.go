@@ -0,0 +1,115 @@
+package sshagent
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/storage"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+type testConfirmer struct {
+	allow   bool
+	prompts []string
+}
+
+func (c *testConfirmer) Confirm(prompt string) bool {
+	c.prompts = append(c.prompts, prompt)
+
+	return c.allow
+}
+
+func TestAgentSignsWithVaultKey(t *testing.T) {
+	key, publicKey := makeTestKey(t, "deploy")
+
+	a := New(&testConfirmer{})
+	require.NoError(t, a.AddVaultKey(key))
+
+	client := connectTestClient(t, a)
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, "deploy", keys[0].Comment)
+	require.Equal(t, publicKey.Marshal(), keys[0].Blob)
+
+	data := []byte("data")
+
+	signature, err := client.Sign(publicKey, data)
+	require.NoError(t, err)
+	require.NoError(t, publicKey.Verify(data, signature))
+}
+
+func TestAgentAsksConfirmation(t *testing.T) {
+	key, publicKey := makeTestKey(t, "deploy")
+	key.Confirm = true
+
+	confirmer := &testConfirmer{}
+
+	a := New(confirmer)
+	require.NoError(t, a.AddVaultKey(key))
+
+	client := connectTestClient(t, a)
+
+	_, err := client.Sign(publicKey, []byte("data"))
+	require.Error(t, err)
+	require.Len(t, confirmer.prompts, 1)
+	require.Contains(t, confirmer.prompts[0], ssh.FingerprintSHA256(publicKey))
+
+	confirmer.allow = true
+
+	_, err = client.Sign(publicKey, []byte("data"))
+	require.NoError(t, err)
+	require.Len(t, confirmer.prompts, 2)
+}
+
+func TestAgentRemovesExpiredKey(t *testing.T) {
+	key, _ := makeTestKey(t, "deploy")
+	key.Lifetime = time.Second
+
+	a := New(&testConfirmer{})
+	require.NoError(t, a.AddVaultKey(key))
+
+	keys, err := a.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	require.Eventually(t, func() bool {
+		keys, err := a.List()
+
+		return err == nil && len(keys) == 0
+	}, time.Second*3, time.Millisecond*100)
+}
+
+func makeTestKey(t *testing.T, name string) (*storage.SSHKey, ssh.PublicKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+
+	return &storage.SSHKey{Name: name, PrivateKey: pem.EncodeToMemory(block)}, sshPublicKey
+}
+
+func connectTestClient(t *testing.T, a *Agent) agent.ExtendedAgent {
+	clientConn, agentConn := net.Pipe()
+
+	go func() {
+		_ = agent.ServeAgent(a, agentConn)
+	}()
+
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+	})
+
+	return agent.NewClient(clientConn)
+}
@@ -1,11 +1,30 @@
 package storage
 
-import "time"
+import (
+	"os"
+	"time"
+)
 
 type Record struct {
 	Name     string
 	Data     string
 	Revision uint64
+	// Metainfo is an encrypted FileInfo of the original file
+	Metainfo string
+	// ContentHash is a keyed hash of the plain content which is read from the file,
+	// the server deduplicates uploads by it, so it isn't kept in local storage
+	ContentHash string
+}
+
+// FileInfo describes the file a record was read from
+type FileInfo struct {
+	Path    string      `json:"path"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	// Hash is a hex encoded sha256 of the file's content
+	Hash string `json:"hash,omitempty"`
+	// Chunks are keyed hashes of the file's plain chunks in order of the record's encrypted chunks
+	Chunks []string `json:"chunks,omitempty"`
 }
 
 type User struct {
@@ -30,3 +49,54 @@ type BankCard struct {
 	Owner      string
 	CvvCode    string
 }
+
+// SSHKey is a private key which is served by ssh-agent.
+// PrivateKey is PEM without passphrase, it's stored encrypted and decrypted in memory only.
+type SSHKey struct {
+	Name       string
+	PrivateKey []byte
+	Comment    string
+	// Confirm requires user's confirmation before every signing
+	Confirm bool
+	// Lifetime of key in ssh-agent, zero is unlimited
+	Lifetime time.Duration
+}
+
+// item kinds
+const (
+	ItemKindData   = "data"
+	ItemKindCard   = "card"
+	ItemKindSecret = "secret"
+	ItemKindSSHKey = "ssh-key"
+)
+
+// operations with items
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// PendingOperation is a local change which wasn't sent to the server
+type PendingOperation struct {
+	ID        int64
+	Kind      string
+	Operation string
+	Key       string
+	Data      string
+	Revision  uint64
+	Metainfo  string
+	CreatedAt time.Time
+	// Error is server's reason of rejection, rejected operation isn't replayed
+	Error string
+}
+
+// Change is an item's change which was made on the server by one of user's devices
+type Change struct {
+	Kind      string
+	Operation string
+	Key       string
+	Data      string
+	Revision  uint64
+	Metainfo  string
+}
@@ -34,6 +34,20 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddPendingOperation mocks base method.
+func (m *MockStorage) AddPendingOperation(ctx context.Context, u *User, op *PendingOperation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPendingOperation", ctx, u, op)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPendingOperation indicates an expected call of AddPendingOperation.
+func (mr *MockStorageMockRecorder) AddPendingOperation(ctx, u, op interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPendingOperation", reflect.TypeOf((*MockStorage)(nil).AddPendingOperation), ctx, u, op)
+}
+
 // CreateCard mocks base method.
 func (m *MockStorage) CreateCard(ctx context.Context, u *User, c *BankCard) (string, error) {
 	m.ctrl.T.Helper()
@@ -63,6 +77,21 @@ func (mr *MockStorageMockRecorder) CreateData(ctx, u, r interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateData", reflect.TypeOf((*MockStorage)(nil).CreateData), ctx, u, r)
 }
 
+// CreateSSHKey mocks base method.
+func (m *MockStorage) CreateSSHKey(ctx context.Context, u *User, key *SSHKey) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSHKey", ctx, u, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSSHKey indicates an expected call of CreateSSHKey.
+func (mr *MockStorageMockRecorder) CreateSSHKey(ctx, u, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSHKey", reflect.TypeOf((*MockStorage)(nil).CreateSSHKey), ctx, u, key)
+}
+
 // CreateSecret mocks base method.
 func (m *MockStorage) CreateSecret(ctx context.Context, u *User, s *Secret) (string, error) {
 	m.ctrl.T.Helper()
@@ -106,6 +135,34 @@ func (mr *MockStorageMockRecorder) DeleteData(ctx, u, name interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockStorage)(nil).DeleteData), ctx, u, name)
 }
 
+// DeletePendingOperation mocks base method.
+func (m *MockStorage) DeletePendingOperation(ctx context.Context, u *User, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingOperation", ctx, u, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingOperation indicates an expected call of DeletePendingOperation.
+func (mr *MockStorageMockRecorder) DeletePendingOperation(ctx, u, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingOperation", reflect.TypeOf((*MockStorage)(nil).DeletePendingOperation), ctx, u, id)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockStorage) DeleteSSHKey(ctx context.Context, u *User, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, u, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockStorageMockRecorder) DeleteSSHKey(ctx, u, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockStorage)(nil).DeleteSSHKey), ctx, u, name)
+}
+
 // DeleteSecret mocks base method.
 func (m *MockStorage) DeleteSecret(ctx context.Context, u *User, secretKey string) error {
 	m.ctrl.T.Helper()
@@ -120,6 +177,20 @@ func (mr *MockStorageMockRecorder) DeleteSecret(ctx, u, secretKey interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockStorage)(nil).DeleteSecret), ctx, u, secretKey)
 }
 
+// FailPendingOperation mocks base method.
+func (m *MockStorage) FailPendingOperation(ctx context.Context, u *User, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailPendingOperation", ctx, u, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailPendingOperation indicates an expected call of FailPendingOperation.
+func (mr *MockStorageMockRecorder) FailPendingOperation(ctx, u, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailPendingOperation", reflect.TypeOf((*MockStorage)(nil).FailPendingOperation), ctx, u, id, reason)
+}
+
 // GetActive mocks base method.
 func (m *MockStorage) GetActive(ctx context.Context) (*User, error) {
 	m.ctrl.T.Helper()
@@ -150,6 +221,20 @@ func (mr *MockStorageMockRecorder) GetSecret(ctx, u, secretKey interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockStorage)(nil).GetSecret), ctx, u, secretKey)
 }
 
+// InTransaction mocks base method.
+func (m *MockStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockStorageMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockStorage)(nil).InTransaction), ctx, fn)
+}
+
 // ListCard mocks base method.
 func (m *MockStorage) ListCard(ctx context.Context, u *User) ([]*BankCard, error) {
 	m.ctrl.T.Helper()
@@ -180,6 +265,51 @@ func (mr *MockStorageMockRecorder) ListData(ctx, u interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockStorage)(nil).ListData), ctx, u)
 }
 
+// ListPendingOperations mocks base method.
+func (m *MockStorage) ListPendingOperations(ctx context.Context, u *User) ([]*PendingOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingOperations", ctx, u)
+	ret0, _ := ret[0].([]*PendingOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingOperations indicates an expected call of ListPendingOperations.
+func (mr *MockStorageMockRecorder) ListPendingOperations(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOperations", reflect.TypeOf((*MockStorage)(nil).ListPendingOperations), ctx, u)
+}
+
+// ListSSHKeys mocks base method.
+func (m *MockStorage) ListSSHKeys(ctx context.Context, u *User) ([]*SSHKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSSHKeys", ctx, u)
+	ret0, _ := ret[0].([]*SSHKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSSHKeys indicates an expected call of ListSSHKeys.
+func (mr *MockStorageMockRecorder) ListSSHKeys(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSSHKeys", reflect.TypeOf((*MockStorage)(nil).ListSSHKeys), ctx, u)
+}
+
+// ListSecrets mocks base method.
+func (m *MockStorage) ListSecrets(ctx context.Context, u *User) ([]*Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, u)
+	ret0, _ := ret[0].([]*Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *MockStorageMockRecorder) ListSecrets(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockStorage)(nil).ListSecrets), ctx, u)
+}
+
 // LoadData mocks base method.
 func (m *MockStorage) LoadData(ctx context.Context, u *User, name string) (*Record, error) {
 	m.ctrl.T.Helper()
@@ -209,6 +339,20 @@ func (mr *MockStorageMockRecorder) Register(ctx, login, password, token, cryptok
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockStorage)(nil).Register), ctx, login, password, token, cryptokey)
 }
 
+// SaveData mocks base method.
+func (m *MockStorage) SaveData(ctx context.Context, u *User, r *Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveData", ctx, u, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveData indicates an expected call of SaveData.
+func (mr *MockStorageMockRecorder) SaveData(ctx, u, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveData", reflect.TypeOf((*MockStorage)(nil).SaveData), ctx, u, r)
+}
+
 // Stop mocks base method.
 func (m *MockStorage) Stop() error {
 	m.ctrl.T.Helper()
@@ -239,6 +383,43 @@ func (mr *MockStorageMockRecorder) UpdateData(ctx, u, r interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockStorage)(nil).UpdateData), ctx, u, r)
 }
 
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// InTransaction mocks base method.
+func (m *MockTransactor) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockTransactorMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockTransactor)(nil).InTransaction), ctx, fn)
+}
+
 // MockDataStorage is a mock of DataStorage interface.
 type MockDataStorage struct {
 	ctrl     *gomock.Controller
@@ -290,6 +471,20 @@ func (mr *MockDataStorageMockRecorder) DeleteData(ctx, u, name interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockDataStorage)(nil).DeleteData), ctx, u, name)
 }
 
+// InTransaction mocks base method.
+func (m *MockDataStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockDataStorageMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockDataStorage)(nil).InTransaction), ctx, fn)
+}
+
 // ListData mocks base method.
 func (m *MockDataStorage) ListData(ctx context.Context, u *User) ([]*Record, error) {
 	m.ctrl.T.Helper()
@@ -320,6 +515,20 @@ func (mr *MockDataStorageMockRecorder) LoadData(ctx, u, name interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadData", reflect.TypeOf((*MockDataStorage)(nil).LoadData), ctx, u, name)
 }
 
+// SaveData mocks base method.
+func (m *MockDataStorage) SaveData(ctx context.Context, u *User, r *Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveData", ctx, u, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveData indicates an expected call of SaveData.
+func (mr *MockDataStorageMockRecorder) SaveData(ctx, u, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveData", reflect.TypeOf((*MockDataStorage)(nil).SaveData), ctx, u, r)
+}
+
 // UpdateData mocks base method.
 func (m *MockDataStorage) UpdateData(ctx context.Context, u *User, r *Record) (uint64, bool, error) {
 	m.ctrl.T.Helper()
@@ -440,6 +649,20 @@ func (mr *MockWalletStorageMockRecorder) DeleteCard(ctx, u, cardNumber interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCard", reflect.TypeOf((*MockWalletStorage)(nil).DeleteCard), ctx, u, cardNumber)
 }
 
+// InTransaction mocks base method.
+func (m *MockWalletStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockWalletStorageMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockWalletStorage)(nil).InTransaction), ctx, fn)
+}
+
 // ListCard mocks base method.
 func (m *MockWalletStorage) ListCard(ctx context.Context, u *User) ([]*BankCard, error) {
 	m.ctrl.T.Helper()
@@ -521,3 +744,193 @@ func (mr *MockSecretStorageMockRecorder) GetSecret(ctx, u, secretKey interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockSecretStorage)(nil).GetSecret), ctx, u, secretKey)
 }
+
+// InTransaction mocks base method.
+func (m *MockSecretStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockSecretStorageMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockSecretStorage)(nil).InTransaction), ctx, fn)
+}
+
+// ListSecrets mocks base method.
+func (m *MockSecretStorage) ListSecrets(ctx context.Context, u *User) ([]*Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, u)
+	ret0, _ := ret[0].([]*Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *MockSecretStorageMockRecorder) ListSecrets(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockSecretStorage)(nil).ListSecrets), ctx, u)
+}
+
+// MockSSHKeyStorage is a mock of SSHKeyStorage interface.
+type MockSSHKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSHKeyStorageMockRecorder
+}
+
+// MockSSHKeyStorageMockRecorder is the mock recorder for MockSSHKeyStorage.
+type MockSSHKeyStorageMockRecorder struct {
+	mock *MockSSHKeyStorage
+}
+
+// NewMockSSHKeyStorage creates a new mock instance.
+func NewMockSSHKeyStorage(ctrl *gomock.Controller) *MockSSHKeyStorage {
+	mock := &MockSSHKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockSSHKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSSHKeyStorage) EXPECT() *MockSSHKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateSSHKey mocks base method.
+func (m *MockSSHKeyStorage) CreateSSHKey(ctx context.Context, u *User, key *SSHKey) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSHKey", ctx, u, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSSHKey indicates an expected call of CreateSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) CreateSSHKey(ctx, u, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).CreateSSHKey), ctx, u, key)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockSSHKeyStorage) DeleteSSHKey(ctx context.Context, u *User, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, u, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) DeleteSSHKey(ctx, u, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).DeleteSSHKey), ctx, u, name)
+}
+
+// InTransaction mocks base method.
+func (m *MockSSHKeyStorage) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockSSHKeyStorageMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockSSHKeyStorage)(nil).InTransaction), ctx, fn)
+}
+
+// ListSSHKeys mocks base method.
+func (m *MockSSHKeyStorage) ListSSHKeys(ctx context.Context, u *User) ([]*SSHKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSSHKeys", ctx, u)
+	ret0, _ := ret[0].([]*SSHKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSSHKeys indicates an expected call of ListSSHKeys.
+func (mr *MockSSHKeyStorageMockRecorder) ListSSHKeys(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSSHKeys", reflect.TypeOf((*MockSSHKeyStorage)(nil).ListSSHKeys), ctx, u)
+}
+
+// MockOutboxStorage is a mock of OutboxStorage interface.
+type MockOutboxStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxStorageMockRecorder
+}
+
+// MockOutboxStorageMockRecorder is the mock recorder for MockOutboxStorage.
+type MockOutboxStorageMockRecorder struct {
+	mock *MockOutboxStorage
+}
+
+// NewMockOutboxStorage creates a new mock instance.
+func NewMockOutboxStorage(ctrl *gomock.Controller) *MockOutboxStorage {
+	mock := &MockOutboxStorage{ctrl: ctrl}
+	mock.recorder = &MockOutboxStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxStorage) EXPECT() *MockOutboxStorageMockRecorder {
+	return m.recorder
+}
+
+// AddPendingOperation mocks base method.
+func (m *MockOutboxStorage) AddPendingOperation(ctx context.Context, u *User, op *PendingOperation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPendingOperation", ctx, u, op)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPendingOperation indicates an expected call of AddPendingOperation.
+func (mr *MockOutboxStorageMockRecorder) AddPendingOperation(ctx, u, op interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPendingOperation", reflect.TypeOf((*MockOutboxStorage)(nil).AddPendingOperation), ctx, u, op)
+}
+
+// DeletePendingOperation mocks base method.
+func (m *MockOutboxStorage) DeletePendingOperation(ctx context.Context, u *User, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingOperation", ctx, u, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingOperation indicates an expected call of DeletePendingOperation.
+func (mr *MockOutboxStorageMockRecorder) DeletePendingOperation(ctx, u, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingOperation", reflect.TypeOf((*MockOutboxStorage)(nil).DeletePendingOperation), ctx, u, id)
+}
+
+// FailPendingOperation mocks base method.
+func (m *MockOutboxStorage) FailPendingOperation(ctx context.Context, u *User, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailPendingOperation", ctx, u, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailPendingOperation indicates an expected call of FailPendingOperation.
+func (mr *MockOutboxStorageMockRecorder) FailPendingOperation(ctx, u, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailPendingOperation", reflect.TypeOf((*MockOutboxStorage)(nil).FailPendingOperation), ctx, u, id, reason)
+}
+
+// ListPendingOperations mocks base method.
+func (m *MockOutboxStorage) ListPendingOperations(ctx context.Context, u *User) ([]*PendingOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingOperations", ctx, u)
+	ret0, _ := ret[0].([]*PendingOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingOperations indicates an expected call of ListPendingOperations.
+func (mr *MockOutboxStorageMockRecorder) ListPendingOperations(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOperations", reflect.TypeOf((*MockOutboxStorage)(nil).ListPendingOperations), ctx, u)
+}
@@ -2,7 +2,7 @@ package sqlstorage
 
 const (
 	createCardTableQuery = `CREATE TABLE IF NOT EXISTS cards (
-		"user"			text		NOT_NULL,
+		"user"			text		NOT NULL,
 		"number"		text		NOT NULL,
 		"data"			text		NOT NULL,
 		PRIMARY KEY ( "user", "number" )
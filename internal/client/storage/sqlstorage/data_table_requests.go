@@ -2,27 +2,33 @@ package sqlstorage
 
 const (
 	createDataTableQuery = `CREATE TABLE IF NOT EXISTS data (
-		"user"			text		NOT_NULL,
+		"user"			text		NOT NULL,
 		"key"			text		NOT NULL,
 		"value"			text		NOT NULL,
 		"revision"		integer 	NOT NULL,
+		"metainfo"		text		NOT NULL DEFAULT '',
 		PRIMARY KEY ( "user", "key" )
 	);`
 
-	addNewDataQuery  = `INSERT INTO data ("user", "key", "value", "revision") VALUES ($1, $2, $3, 1);`
-	updateDataQuery  = `UPDATE data SET "value" = $1, "revision" = "revision" + 1 WHERE "user" = $2 AND "key" = $3;`
+	addNewDataQuery  = `INSERT INTO data ("user", "key", "value", "revision", "metainfo") VALUES ($1, $2, $3, 1, $4);`
+	updateDataQuery  = `UPDATE data SET "value" = $1, "metainfo" = $2, "revision" = "revision" + 1 WHERE "user" = $3 AND "key" = $4;`
+	saveDataQuery    = `INSERT INTO data ("user", "key", "value", "revision", "metainfo") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("user", "key") DO UPDATE SET "value" = excluded."value", "revision" = excluded."revision", "metainfo" = excluded."metainfo";`
 	getRevisionQuery = `SELECT "revision" FROM data WHERE "user" = $1 AND "key" = $2;`
-	getData          = `SELECT "value", "revision" FROM data WHERE "user" = $1 AND "key" = $2;`
-	listData         = `SELECT "key", "value", "revision" FROM data WHERE "user" = $1;`
+	getData          = `SELECT "value", "revision", "metainfo" FROM data WHERE "user" = $1 AND "key" = $2;`
+	listData         = `SELECT "key", "value", "revision", "metainfo" FROM data WHERE "user" = $1;`
 	deleteBinaryData = `DELETE FROM data WHERE "user" = $1 AND "key" = $2;`
 )
 
-func prepareAddDataQuery(user string, key string, value string) *query {
-	return &query{request: addNewDataQuery, args: []any{user, key, value}}
+func prepareAddDataQuery(user string, key string, value string, metainfo string) *query {
+	return &query{request: addNewDataQuery, args: []any{user, key, value, metainfo}}
 }
 
-func prepareUpdateDataQuery(user, key, value string) *query {
-	return &query{request: updateDataQuery, args: []any{value, user, key}}
+func prepareUpdateDataQuery(user, key, value, metainfo string) *query {
+	return &query{request: updateDataQuery, args: []any{value, metainfo, user, key}}
+}
+
+func prepareSaveDataQuery(user, key, value string, revision uint64, metainfo string) *query {
+	return &query{request: saveDataQuery, args: []any{user, key, value, revision, metainfo}}
 }
 
 func prepareGetDataQuery(user, key string) *query {
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/client/config"
 	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
@@ -24,50 +25,80 @@ var (
 var _ storage.DataStorage = &DbStorage{}
 var _ storage.UserStorage = &DbStorage{}
 var _ storage.WalletStorage = &DbStorage{}
+var _ storage.OutboxStorage = &DbStorage{}
 
 type DbStorage struct {
 	db *sql.DB
 }
 
-func StartNewDbStorage(dbName string) (*DbStorage, error) {
-	homedir, err := os.UserHomeDir()
+// executor is a common part of sql.DB and sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type txKey struct{}
+
+// InTransaction calls fn in a transaction, storage's methods called with fn's context are made in this transaction.
+// Transaction is committed when fn returns nil and is rolled back otherwise.
+func (s *DbStorage) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		// nested call is a part of the outer transaction
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("begin transaction, err=%w", err)
 	}
 
-	dbPath := filepath.Join(homedir, config.DefaultAppDirName, dbName)
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback, err=%w", rollbackErr))
+		}
 
-	db, err := sql.Open("sqlite3", dbPath)
-	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction, err=%w", err)
 	}
 
-	storage := &DbStorage{db: db}
+	return nil
+}
 
-	if err = storage.initTables(); err != nil {
+func (s *DbStorage) conn(ctx context.Context) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return s.db
+}
+
+func StartNewDbStorage(dbName string) (*DbStorage, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
 		return nil, err
 	}
 
-	return storage, nil
+	return openDbStorage(filepath.Join(homedir, config.DefaultAppDirName, dbName))
 }
 
-func (s *DbStorage) initTables() error {
-	var err error
+// openDbStorage opens db and upgrades its schema, backup of the old schema is kept near the db
+func openDbStorage(dbPath string) (*DbStorage, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, t := range []string{
-		createDataTableQuery,
-		createUserTableQuery,
-		createCardTableQuery,
-		createSecretTableQuery,
-	} {
-		if _, err = s.db.Exec(t); err != nil {
-			fmt.Println("init table", t)
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
 
-			return err
-		}
+	if err := migrate(ctx, db, dbPath+".bak"); err != nil {
+		return nil, errors.Join(fmt.Errorf("migrate db=%s, err=%w", dbPath, err), db.Close())
 	}
 
-	return nil
+	return &DbStorage{db: db}, nil
 }
 
 func (s *DbStorage) Register(
@@ -97,7 +128,7 @@ func (s *DbStorage) addNewUser(
 ) error {
 	query := prepareInsertUserQuery(login, password, token, cryptoKey)
 
-	_, err := s.db.ExecContext(ctx, query.request, query.args...)
+	_, err := s.conn(ctx).ExecContext(ctx, query.request, query.args...)
 	if err != nil {
 		if isUniqueConstraint(err) {
 			fmt.Println("User alreay registred in client")
@@ -115,7 +146,7 @@ func (s *DbStorage) changeCurrentUserStatus(ctx context.Context) error {
 	var err error
 
 	q := prepareGetUserQuery()
-	rows, err := s.db.QueryContext(ctx, q.request, q.args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, q.request, q.args...)
 	if err != nil {
 		return err
 	}
@@ -135,8 +166,13 @@ func (s *DbStorage) changeCurrentUserStatus(ctx context.Context) error {
 		return err
 	}
 
+	// sqlite doesn't allow to write while rows are read by another connection
+	if err = rows.Close(); err != nil {
+		return err
+	}
+
 	q = prepareChangeActiveQuery(u.Login)
-	_, err = s.db.ExecContext(ctx, q.request, q.args...)
+	_, err = s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 	if err != nil {
 		return err
 	}
@@ -160,7 +196,7 @@ func (s *DbStorage) GetActive(
 ) (*storage.User, error) {
 	query := prepareGetUserQuery()
 
-	rows, err := s.db.QueryContext(ctx, query.request)
+	rows, err := s.conn(ctx).QueryContext(ctx, query.request)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +236,7 @@ func (s *DbStorage) DeleteData(
 ) error {
 	q := prepareDeleteDataQuery(u.Login, name)
 
-	_, err := s.db.ExecContext(ctx, q.request, q.args...)
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 
 	return err
 }
@@ -217,6 +253,8 @@ func (s *DbStorage) CreateData(
 		if isUniqueConstraint(err) {
 			return ErrAlreadyExist
 		}
+
+		return err
 	}
 
 	return nil
@@ -243,13 +281,26 @@ func (s *DbStorage) UpdateData(
 	return storedData.Revision, true, nil
 }
 
+// SaveData stores record with it's revision, existing record will be replaced
+func (s *DbStorage) SaveData(
+	ctx context.Context,
+	u *storage.User,
+	r *storage.Record,
+) error {
+	q := prepareSaveDataQuery(u.Login, r.Name, r.Data, r.Revision, r.Metainfo)
+
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
+
+	return err
+}
+
 func (s *DbStorage) saveNewData(
 	ctx context.Context,
 	u *storage.User,
 	r *storage.Record) error {
-	q := prepareAddDataQuery(u.Login, r.Name, r.Data)
+	q := prepareAddDataQuery(u.Login, r.Name, r.Data, r.Metainfo)
 
-	res, err := s.db.ExecContext(ctx, q.request, q.args...)
+	res, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 	if err != nil {
 		return err
 	}
@@ -267,9 +318,9 @@ func (s *DbStorage) updateData(
 	u *storage.User,
 	r *storage.Record,
 ) error {
-	q := prepareUpdateDataQuery(u.Login, r.Name, r.Data)
+	q := prepareUpdateDataQuery(u.Login, r.Name, r.Data, r.Metainfo)
 
-	res, err := s.db.ExecContext(ctx, q.request, q.args...)
+	res, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 	if err != nil {
 		return err
 	}
@@ -291,7 +342,7 @@ func (s *DbStorage) LoadData(
 ) (*storage.Record, error) {
 	query := prepareGetDataQuery(u.Login, name)
 
-	rows, err := s.db.QueryContext(ctx, query.request, query.args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, query.request, query.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +353,7 @@ func (s *DbStorage) LoadData(
 	}
 
 	record := &storage.Record{Name: name}
-	err = rows.Scan(&record.Data, &record.Revision)
+	err = rows.Scan(&record.Data, &record.Revision, &record.Metainfo)
 	if err != nil {
 		return nil, err
 	}
@@ -316,7 +367,7 @@ func (s *DbStorage) ListData(
 ) ([]*storage.Record, error) {
 	query := prepareListDataQuery(u.Login)
 
-	rows, err := s.db.QueryContext(ctx, query.request, query.args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, query.request, query.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -327,7 +378,7 @@ func (s *DbStorage) ListData(
 
 	for rows.Next() {
 		r := storage.Record{}
-		err = rows.Scan(&r.Name, &r.Data, &r.Revision)
+		err = rows.Scan(&r.Name, &r.Data, &r.Revision, &r.Metainfo)
 		if err != nil {
 			return nil, err
 		}
@@ -354,7 +405,7 @@ func (s *DbStorage) CreateCard(
 
 	query := prepareAddCard(u.Login, c.Number, data)
 
-	_, err = s.db.ExecContext(ctx, query.request, query.args...)
+	_, err = s.conn(ctx).ExecContext(ctx, query.request, query.args...)
 	if err != nil {
 		if isUniqueConstraint(err) {
 			return "", ErrAlreadyExist
@@ -373,7 +424,7 @@ func (s *DbStorage) DeleteCard(
 ) error {
 	query := prepareDeleteCard(u.Login, number)
 
-	_, err := s.db.ExecContext(ctx, query.request, query.args...)
+	_, err := s.conn(ctx).ExecContext(ctx, query.request, query.args...)
 
 	return err
 }
@@ -384,7 +435,7 @@ func (s *DbStorage) ListCard(
 ) ([]*storage.BankCard, error) {
 	query := prepareListCard(u.Login)
 
-	rows, err := s.db.QueryContext(ctx, query.request, query.args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, query.request, query.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -457,7 +508,7 @@ func (s *DbStorage) CreateSecret(
 	}
 
 	q := prepareAddSecretQuery(u.Login, secret.Name, cryptedSecret)
-	_, err = s.db.Exec(q.request, q.args...)
+	_, err = s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 	if err != nil {
 		if isUniqueConstraint(err) {
 			return cryptedSecret, ErrAlreadyExist
@@ -475,14 +526,14 @@ func (s *DbStorage) GetSecret(
 	secretName string,
 ) (*storage.Secret, error) {
 	q := preareGetSecretQuery(u.Login, secretName)
-	rows, err := s.db.QueryContext(ctx, q.request, q.args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, q.request, q.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("secret %s isn't exist", secretName)
+		return nil, fmt.Errorf("secret %s isn't exist, err=%w", secretName, ErrDataNotExist)
 	}
 
 	cryptedData := ""
@@ -493,6 +544,42 @@ func (s *DbStorage) GetSecret(
 	return deserializeSecret(u, cryptedData)
 }
 
+func (s *DbStorage) ListSecrets(
+	ctx context.Context,
+	u *storage.User,
+) ([]*storage.Secret, error) {
+	q := prepareListSecretsQuery(u.Login)
+
+	rows, err := s.conn(ctx).QueryContext(ctx, q.request, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]*storage.Secret, 0, 10)
+
+	for rows.Next() {
+		name, cryptedData := "", ""
+		if err := rows.Scan(&name, &cryptedData); err != nil {
+			return nil, err
+		}
+
+		secret, err := deserializeSecret(u, cryptedData)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize user's secret err=%w", err)
+		}
+
+		secret.Name = name
+		secrets = append(secrets, secret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
 func deserializeSecret(u *storage.User, cryptedData string) (*storage.Secret, error) {
 	crypt, err := gophcrypto.New(u.CryptoKey)
 	if err != nil {
@@ -532,7 +619,167 @@ func (s *DbStorage) DeleteSecret(
 ) error {
 	q := prepareDeleteSecretQuery(u.Login, secretKey)
 
-	_, err := s.db.ExecContext(ctx, q.request, q.args...)
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
+
+	return err
+}
+
+func (s *DbStorage) CreateSSHKey(
+	ctx context.Context,
+	u *storage.User,
+	key *storage.SSHKey,
+) (string, error) {
+	data, err := serializeSSHKey(u, key)
+	if err != nil {
+		return "", fmt.Errorf("serialize ssh key err=%w", err)
+	}
+
+	query := prepareAddSSHKey(u.Login, key.Name, data)
+
+	_, err = s.conn(ctx).ExecContext(ctx, query.request, query.args...)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return "", ErrAlreadyExist
+		}
+
+		return "", err
+	}
+
+	return data, nil
+}
+
+func (s *DbStorage) ListSSHKeys(
+	ctx context.Context,
+	u *storage.User,
+) ([]*storage.SSHKey, error) {
+	query := prepareListSSHKeys(u.Login)
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query.request, query.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*storage.SSHKey, 0, 10)
+
+	for rows.Next() {
+		data := ""
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		key, err := deserializeSSHKey(u, data)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize user's ssh key err=%w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *DbStorage) DeleteSSHKey(
+	ctx context.Context,
+	u *storage.User,
+	name string,
+) error {
+	query := prepareDeleteSSHKey(u.Login, name)
+
+	_, err := s.conn(ctx).ExecContext(ctx, query.request, query.args...)
+
+	return err
+}
+
+func serializeSSHKey(u *storage.User, key *storage.SSHKey) (string, error) {
+	crypt, err := gophcrypto.New(u.CryptoKey)
+	if err != nil {
+		return "", err
+	}
+
+	return NewSerializer(crypt).SerializeSSHKey(key)
+}
+
+func deserializeSSHKey(u *storage.User, data string) (*storage.SSHKey, error) {
+	crypt, err := gophcrypto.New(u.CryptoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSerializer(crypt).DeserializeSSHKey(data)
+}
+
+func (s *DbStorage) AddPendingOperation(
+	ctx context.Context,
+	u *storage.User,
+	op *storage.PendingOperation,
+) error {
+	q := prepareAddPendingOperation(u.Login, op.Kind, op.Operation, op.Key, op.Data, op.Revision, op.Metainfo, op.CreatedAt.Unix())
+
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
+
+	return err
+}
+
+func (s *DbStorage) ListPendingOperations(
+	ctx context.Context,
+	u *storage.User,
+) ([]*storage.PendingOperation, error) {
+	q := prepareListPendingOperations(u.Login)
+
+	rows, err := s.conn(ctx).QueryContext(ctx, q.request, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ops := make([]*storage.PendingOperation, 0, 10)
+
+	for rows.Next() {
+		op := &storage.PendingOperation{}
+		createdAt := int64(0)
+
+		err = rows.Scan(&op.ID, &op.Kind, &op.Operation, &op.Key, &op.Data, &op.Revision, &op.Metainfo, &createdAt, &op.Error)
+		if err != nil {
+			return nil, err
+		}
+
+		op.CreatedAt = time.Unix(createdAt, 0)
+		ops = append(ops, op)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+func (s *DbStorage) DeletePendingOperation(
+	ctx context.Context,
+	u *storage.User,
+	id int64,
+) error {
+	q := prepareDeletePendingOperation(u.Login, id)
+
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
+
+	return err
+}
+
+func (s *DbStorage) FailPendingOperation(
+	ctx context.Context,
+	u *storage.User,
+	id int64,
+	reason string,
+) error {
+	q := prepareFailPendingOperation(u.Login, id, reason)
+
+	_, err := s.conn(ctx).ExecContext(ctx, q.request, q.args...)
 
 	return err
 }
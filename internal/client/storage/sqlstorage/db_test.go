@@ -1,6 +1,10 @@
 package sqlstorage
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -40,3 +44,81 @@ func TestSerializeSecret(t *testing.T) {
 
 	require.Equal(t, c, c2)
 }
+
+func TestSerializeSSHKey(t *testing.T) {
+	cryptoKey, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	k := &storage.SSHKey{Name: "n", PrivateKey: []byte("key"), Comment: "c", Confirm: true, Lifetime: time.Hour}
+	u := &storage.User{Login: "l", Password: "p", Token: "t", CryptoKey: cryptoKey}
+
+	data, err := serializeSSHKey(u, k)
+	require.NoError(t, err)
+
+	k2, err := deserializeSSHKey(u, data)
+	require.NoError(t, err)
+
+	require.Equal(t, k, k2)
+}
+
+func TestInTransactionRollback(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+
+	s := &DbStorage{db: db}
+	defer s.Stop()
+
+	_, err = db.Exec(createDataTableQuery)
+	require.NoError(t, err)
+
+	cryptoKey, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	u := &storage.User{Login: "l", Password: "p", Token: "t", CryptoKey: cryptoKey}
+	fnErr := errors.New("fn error")
+
+	err = s.InTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, s.CreateData(ctx, u, &storage.Record{Name: "rollback", Data: "data", Revision: 1}))
+
+		return fnErr
+	})
+	require.ErrorIs(t, err, fnErr)
+
+	_, err = s.LoadData(ctx, u, "rollback")
+	require.ErrorIs(t, err, ErrDataNotExist)
+
+	err = s.InTransaction(ctx, func(ctx context.Context) error {
+		return s.CreateData(ctx, u, &storage.Record{Name: "commit", Data: "data", Revision: 1})
+	})
+	require.NoError(t, err)
+
+	r, err := s.LoadData(ctx, u, "commit")
+	require.NoError(t, err)
+	require.Equal(t, "data", r.Data)
+}
+
+func TestFailPendingOperation(t *testing.T) {
+	s, err := openDbStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer s.Stop()
+
+	ctx := context.Background()
+	u := &storage.User{Login: "l"}
+
+	for _, key := range []string{"first", "second"} {
+		op := &storage.PendingOperation{Kind: storage.ItemKindSecret, Operation: storage.OperationCreate, Key: key, CreatedAt: time.Now()}
+		require.NoError(t, s.AddPendingOperation(ctx, u, op))
+	}
+
+	ops, err := s.ListPendingOperations(ctx, u)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+
+	require.NoError(t, s.FailPendingOperation(ctx, u, ops[0].ID, "rejected"))
+
+	ops, err = s.ListPendingOperations(ctx, u)
+	require.NoError(t, err)
+	require.Equal(t, "rejected", ops[0].Error)
+	require.Empty(t, ops[1].Error)
+}
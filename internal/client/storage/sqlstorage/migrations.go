@@ -0,0 +1,183 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var ErrUnknownSchemaVersion = errors.New("db schema is newer than client knows")
+
+const migrationTimeout = time.Minute
+
+// migration upgrades schema from the previous version, version is stored in sqlite's user_version
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations are applied one by one, applied migration must never be changed
+var migrations = []*migration{
+	{
+		version: 1,
+		name:    "init",
+		up: execMigrationQueries(
+			createDataTableQuery,
+			createUserTableQuery,
+			createCardTableQuery,
+			createSecretTableQuery,
+			createOutboxTableQuery,
+			createSSHKeyTableQuery,
+		),
+	},
+	{
+		// data table of the first client's versions doesn't have metainfo
+		version: 2,
+		name:    "data_metainfo",
+		up:      addColumnIfNotExists("data", "metainfo", `text NOT NULL DEFAULT ''`),
+	},
+	{
+		version: 3,
+		name:    "outbox_error",
+		up:      execMigrationQueries(addOutboxErrorColumnQuery),
+	},
+}
+
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate applies pending migrations, db is copied to backupPath before upgrade of not empty db
+func migrate(ctx context.Context, db *sql.DB, backupPath string) error {
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if version > latestSchemaVersion() {
+		return fmt.Errorf("%w, version=%d latest=%d", ErrUnknownSchemaVersion, version, latestSchemaVersion())
+	}
+
+	if version == latestSchemaVersion() {
+		return nil
+	}
+
+	empty, err := isEmptyDb(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if !empty {
+		if err := backupDb(ctx, db, backupPath); err != nil {
+			return fmt.Errorf("backup db before migration, err=%w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration=%d_%s, err=%w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m *migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := m.up(ctx, tx); err != nil {
+		return err
+	}
+
+	// pragma doesn't support parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d;", m.version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version;").Scan(&version); err != nil {
+		return 0, fmt.Errorf("get schema version, err=%w", err)
+	}
+
+	return version, nil
+}
+
+func isEmptyDb(ctx context.Context, db *sql.DB) (bool, error) {
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE "type" = 'table';`).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func execMigrationQueries(queries ...string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, q := range queries {
+			if _, err := tx.ExecContext(ctx, q); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// addColumnIfNotExists adds column to the table which could be created by the client without migrations
+func addColumnIfNotExists(table string, column string, definition string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT name FROM pragma_table_info('%s');", table))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+
+			if name == column {
+				return nil
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s;`, table, column, definition))
+
+		return err
+	}
+}
+
+// backupDb writes consistent copy of db, previous backup is replaced
+func backupDb(ctx context.Context, db *sql.DB, backupPath string) error {
+	if err := os.Remove(backupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?;", backupPath)
+
+	return err
+}
@@ -0,0 +1,94 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateNewDb(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := openDbStorage(dbPath)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	ctx := context.Background()
+
+	version, err := schemaVersion(ctx, s.db)
+	require.NoError(t, err)
+	require.Equal(t, latestSchemaVersion(), version)
+
+	// nothing to back up
+	require.NoFileExists(t, dbPath+".bak")
+
+	cryptoKey, err := gophcrypto.GenerateCryptoKey()
+	require.NoError(t, err)
+
+	require.NoError(t, s.Register(ctx, "first", "p", "t1", string(cryptoKey)))
+	require.NoError(t, s.Register(ctx, "second", "p", "t2", string(cryptoKey)))
+
+	user, err := s.GetActive(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "second", user.Login)
+}
+
+func TestMigrateLegacyDb(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// data table of the first client's versions
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`CREATE TABLE data ("user" text NOT_NULL, "key" text NOT NULL, "value" text NOT NULL, "revision" integer NOT NULL, PRIMARY KEY ( "user", "key" ));`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO data ("user", "key", "value", "revision") VALUES ('u', 'k', 'v', 3);`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := openDbStorage(dbPath)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	ctx := context.Background()
+
+	version, err := schemaVersion(ctx, s.db)
+	require.NoError(t, err)
+	require.Equal(t, latestSchemaVersion(), version)
+
+	var value, metainfo string
+	require.NoError(t, s.db.QueryRow(`SELECT "value", "metainfo" FROM data WHERE "key" = 'k';`).Scan(&value, &metainfo))
+	require.Equal(t, "v", value)
+	require.Empty(t, metainfo)
+
+	// backup has the old schema
+	backup, err := sql.Open("sqlite3", dbPath+".bak")
+	require.NoError(t, err)
+	defer backup.Close()
+
+	backupVersion, err := schemaVersion(ctx, backup)
+	require.NoError(t, err)
+	require.Equal(t, 0, backupVersion)
+
+	require.NoError(t, backup.QueryRow(`SELECT "value" FROM data WHERE "key" = 'k';`).Scan(&value))
+	require.Equal(t, "v", value)
+}
+
+func TestMigrateNewerDb(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+
+	_, err = db.Exec("PRAGMA user_version = 1000;")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = openDbStorage(dbPath)
+	require.ErrorIs(t, err, ErrUnknownSchemaVersion)
+}
@@ -0,0 +1,39 @@
+package sqlstorage
+
+const (
+	createOutboxTableQuery = `CREATE TABLE IF NOT EXISTS outbox (
+		"id"			integer		PRIMARY KEY AUTOINCREMENT,
+		"user"			text		NOT NULL,
+		"kind"			text		NOT NULL,
+		"operation"		text		NOT NULL,
+		"key"			text		NOT NULL,
+		"data"			text		NOT NULL DEFAULT '',
+		"revision"		integer		NOT NULL DEFAULT 0,
+		"metainfo"		text		NOT NULL DEFAULT '',
+		"created_at"	integer		NOT NULL
+	);`
+
+	// operations rejected by server are kept with the reason
+	addOutboxErrorColumnQuery = `ALTER TABLE outbox ADD COLUMN "error" text NOT NULL DEFAULT '';`
+
+	addPendingOperation    = `INSERT INTO outbox ("user", "kind", "operation", "key", "data", "revision", "metainfo", "created_at") VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+	listPendingOperations  = `SELECT "id", "kind", "operation", "key", "data", "revision", "metainfo", "created_at", "error" FROM outbox WHERE "user" = $1 ORDER BY "id";`
+	deletePendingOperation = `DELETE FROM outbox WHERE "user" = $1 AND "id" = $2;`
+	failPendingOperation   = `UPDATE outbox SET "error" = $1 WHERE "user" = $2 AND "id" = $3;`
+)
+
+func prepareAddPendingOperation(user, kind, operation, key, data string, revision uint64, metainfo string, createdAt int64) *query {
+	return &query{request: addPendingOperation, args: []any{user, kind, operation, key, data, revision, metainfo, createdAt}}
+}
+
+func prepareListPendingOperations(user string) *query {
+	return &query{request: listPendingOperations, args: []any{user}}
+}
+
+func prepareDeletePendingOperation(user string, id int64) *query {
+	return &query{request: deletePendingOperation, args: []any{user, id}}
+}
+
+func prepareFailPendingOperation(user string, id int64, reason string) *query {
+	return &query{request: failPendingOperation, args: []any{reason, user, id}}
+}
@@ -2,14 +2,15 @@ package sqlstorage
 
 const (
 	createSecretTableQuery = `CREATE TABLE IF NOT EXISTS secrets (
-		"user"			text		NOT_NULL,
-		"name"			text		NOT_NULL,
+		"user"			text		NOT NULL,
+		"name"			text		NOT NULL,
 		"secret"		text		NOT NULL,
 		PRIMARY KEY ( "user", "name" )
 	);`
 
 	addSecretQuery    = `INSERT INTO secrets ("user", "name", "secret") VALUES ($1, $2, $3);`
 	getSecretQuery    = `SELECT "secret" FROM secrets WHERE "user" = $1 AND "name" = $2;`
+	listSecretsQuery  = `SELECT "name", "secret" FROM secrets WHERE "user" = $1;`
 	deleteSecretQuery = `DELETE FROM secrets WHERE "user" = $1 AND "name" = $2;`
 )
 
@@ -21,6 +22,10 @@ func preareGetSecretQuery(user, name string) *query {
 	return &query{request: getSecretQuery, args: []any{user, name}}
 }
 
+func prepareListSecretsQuery(user string) *query {
+	return &query{request: listSecretsQuery, args: []any{user}}
+}
+
 func prepareDeleteSecretQuery(user, name string) *query {
 	return &query{request: deleteSecretQuery, args: []any{user, name}}
 }
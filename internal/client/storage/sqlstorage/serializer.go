@@ -31,6 +31,22 @@ func (s *DbSerializer) DeserializeSecret(base64data string) (*storage.Secret, er
 	return doDeserializarion[storage.Secret](s, base64data)
 }
 
+func (s *DbSerializer) SerializeSSHKey(key *storage.SSHKey) (string, error) {
+	return doSerializarion(s, key)
+}
+
+func (s *DbSerializer) DeserializeSSHKey(base64data string) (*storage.SSHKey, error) {
+	return doDeserializarion[storage.SSHKey](s, base64data)
+}
+
+func (s *DbSerializer) SerializeFileInfo(info *storage.FileInfo) (string, error) {
+	return doSerializarion(s, info)
+}
+
+func (s *DbSerializer) DeserializeFileInfo(base64data string) (*storage.FileInfo, error) {
+	return doDeserializarion[storage.FileInfo](s, base64data)
+}
+
 func doSerializarion[T any](serializer *DbSerializer, obj *T) (string, error) {
 	marshaled, err := json.Marshal(obj)
 	if err != nil {
@@ -0,0 +1,26 @@
+package sqlstorage
+
+const (
+	createSSHKeyTableQuery = `CREATE TABLE IF NOT EXISTS ssh_keys (
+		"user"			text		NOT NULL,
+		"name"			text		NOT NULL,
+		"data"			text		NOT NULL,
+		PRIMARY KEY ( "user", "name" )
+	);`
+
+	addSSHKey    = `INSERT INTO ssh_keys ("user", "name", "data") VALUES ($1, $2, $3);`
+	listSSHKeys  = `SELECT "data" FROM ssh_keys WHERE "user" = $1 ORDER BY "name";`
+	deleteSSHKey = `DELETE FROM ssh_keys WHERE "user" = $1 AND "name" = $2;`
+)
+
+func prepareAddSSHKey(user, name, data string) *query {
+	return &query{request: addSSHKey, args: []any{user, name, data}}
+}
+
+func prepareListSSHKeys(user string) *query {
+	return &query{request: listSSHKeys, args: []any{user}}
+}
+
+func prepareDeleteSSHKey(user, name string) *query {
+	return &query{request: deleteSSHKey, args: []any{user, name}}
+}
@@ -7,12 +7,12 @@ const (
 		"token"         text NOT NULL,
 		"crypto_key" 	text NOT NULL,
 		"active"		integer NOT NULL,
-		PRIMARY KEY "login"
+		PRIMARY KEY ( "login" )
 	);`
 
 	insertUser   = `INSERT INTO users ("login", "password", "token",  "crypto_key", "active") VALUES ($1, $2, $3, $4, 1);`
 	getUser      = `SELECT "login", "password", "token", "crypto_key" FROM users WHERE "active" == 1;`
-	changeActive = `UPDATE users SET "active" = 0 WHERE "user" = $1`
+	changeActive = `UPDATE users SET "active" = 0 WHERE "login" = $1;`
 )
 
 func prepareInsertUserQuery(login, password, token, crypto_key string) *query {
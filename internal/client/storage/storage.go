@@ -8,12 +8,21 @@ type Storage interface {
 	DataStorage
 	WalletStorage
 	SecretStorage
+	SSHKeyStorage
+	OutboxStorage
 	Stop() error
 }
 
+// Transactor runs fn in a transaction, storage's calls with fn's context are the part of it
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type DataStorage interface {
+	Transactor
 	CreateData(ctx context.Context, u *User, r *Record) error
 	UpdateData(ctx context.Context, u *User, r *Record) (uint64, bool, error)
+	SaveData(ctx context.Context, u *User, r *Record) error
 	LoadData(ctx context.Context, u *User, name string) (*Record, error)
 	ListData(ctx context.Context, u *User) ([]*Record, error)
 	DeleteData(ctx context.Context, u *User, name string) error
@@ -25,13 +34,30 @@ type UserStorage interface {
 }
 
 type WalletStorage interface {
+	Transactor
 	CreateCard(ctx context.Context, u *User, c *BankCard) (string, error)
 	ListCard(ctx context.Context, u *User) ([]*BankCard, error)
 	DeleteCard(ctx context.Context, u *User, cardNumber string) error
 }
 
 type SecretStorage interface {
+	Transactor
 	CreateSecret(ctx context.Context, u *User, s *Secret) (string, error)
 	GetSecret(ctx context.Context, u *User, secretKey string) (*Secret, error)
+	ListSecrets(ctx context.Context, u *User) ([]*Secret, error)
 	DeleteSecret(ctx context.Context, u *User, secretKey string) error
 }
+
+type SSHKeyStorage interface {
+	Transactor
+	CreateSSHKey(ctx context.Context, u *User, key *SSHKey) (string, error)
+	ListSSHKeys(ctx context.Context, u *User) ([]*SSHKey, error)
+	DeleteSSHKey(ctx context.Context, u *User, name string) error
+}
+
+type OutboxStorage interface {
+	AddPendingOperation(ctx context.Context, u *User, op *PendingOperation) error
+	ListPendingOperations(ctx context.Context, u *User) ([]*PendingOperation, error)
+	DeletePendingOperation(ctx context.Context, u *User, id int64) error
+	FailPendingOperation(ctx context.Context, u *User, id int64, reason string) error
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,6 +9,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/client/config"
@@ -20,8 +25,11 @@ import (
 type BinaryDataClient interface {
 	UploadBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error
 	UpdateBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error
+	UpdateBinaryDataDelta(ctx context.Context, u *storage.User, r *storage.Record, previousData string) error
 	DownloadBinaryData(ctx context.Context, u *storage.User, dataKey string) (*storage.Record, error)
 	DeleteBinaryData(ctx context.Context, u *storage.User, dataKey string) error
+	// ListBinaryData returns the page of records' metadata and the cursor of the next page, the cursor is empty on the last page
+	ListBinaryData(ctx context.Context, u *storage.User, opts *ListOptions, cursor string) ([]*handler.DataInfo, string, error)
 }
 
 type RegisterClient interface {
@@ -32,14 +40,66 @@ type SecretDataClient interface {
 	CreateSecret(ctx context.Context, userToken string, secretName string, secretData string) error
 	DeleteSecret(ctx context.Context, userToken string, secretKey string) error
 	GetSecret(ctx context.Context, userToken string, secretName string) (*storage.Secret, error)
+	ListSecrets(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SecretInfo, string, error)
 }
 
 type WalletClient interface {
 	CreateCardData(ctx context.Context, userToken string, cardNumber string, cardData string) error
 	DeleteCardData(ctx context.Context, userToken string, cardNumber string) error
-	ListCardData(ctx context.Context, userToken string) ([]*handler.CardData, error)
+	// GetCardData returns card's encrypted data
+	GetCardData(ctx context.Context, userToken string, cardNumber string) (string, error)
+	ListCardData(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.CardInfo, string, error)
 }
 
+type SSHKeyClient interface {
+	CreateSSHKey(ctx context.Context, userToken string, name string, data string) error
+	DeleteSSHKey(ctx context.Context, userToken string, name string) error
+	// GetSSHKey returns key's encrypted data
+	GetSSHKey(ctx context.Context, userToken string, name string) (string, error)
+	ListSSHKeys(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SSHKeyInfo, string, error)
+}
+
+// ServiceAccountClient manages service accounts with scoped tokens, it's available with user's own token only
+type ServiceAccountClient interface {
+	CreateServiceAccount(ctx context.Context, userToken string, name string, scope *handler.Scope) (string, error)
+	ListServiceAccounts(ctx context.Context, userToken string) ([]*handler.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, userToken string, name string) error
+}
+
+type AuditClient interface {
+	ListAuditEvents(ctx context.Context, userToken string, limit int) ([]*handler.AuditEvent, error)
+}
+
+type UsageClient interface {
+	GetUsage(ctx context.Context, userToken string) (*handler.UsageResponse, error)
+}
+
+// ChangesClient receives changes of user's items which are made by other user's devices
+type ChangesClient interface {
+	WatchChanges(ctx context.Context, u *storage.User, onChange func(*storage.Change) error) error
+}
+
+// ItemsClient sends user's items to the server
+type ItemsClient interface {
+	BinaryDataClient
+	SecretDataClient
+	WalletClient
+	SSHKeyClient
+}
+
+// deviceName is sent to the server for audit log
+var deviceName = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+
+	return hostname
+}()
+
+// ErrServerUnavailable is returned when the server can't be reached after all retries
+var ErrServerUnavailable = errors.New("server is unavailable")
+
 type Client struct {
 	hostport string
 	done     chan error
@@ -52,14 +112,19 @@ func NewClient(config *config.Config) *Client {
 	}
 }
 
+// dedupMinSize is size of data which is offered to the server by content hash before uploading,
+// smaller data is uploaded at once, because the additional request costs more than the upload
+var dedupMinSize = 64 << 10
+
 func (c *Client) UploadBinaryData(
 	ctx context.Context,
 	u *storage.User,
 	r *storage.Record,
 ) error {
 	saveDataRequest := handler.SaveDataRequest{
-		Key:  r.Name,
-		Data: string(r.Data),
+		Key:         r.Name,
+		Metainfo:    r.Metainfo,
+		ContentHash: r.ContentHash,
 	}
 
 	uri := makeURI(c.hostport, endpoint.BinaryDataEndpoint)
@@ -67,12 +132,8 @@ func (c *Client) UploadBinaryData(
 		"token": u.Token,
 	}
 
-	return requestAndHandle(ctx, uri, http.MethodPost, headers, saveDataRequest, func(r *http.Response) error {
-		if r.StatusCode == http.StatusConflict {
-			return errors.New("data already exist")
-		}
-
-		return defaultHttpResponseHandler(r)
+	return uploadByContentHash(r, &saveDataRequest.Data, func() error {
+		return request(ctx, uri, http.MethodPost, headers, saveDataRequest)
 	})
 }
 
@@ -82,9 +143,60 @@ func (c *Client) UpdateBinaryData(
 	r *storage.Record,
 ) error {
 	saveDataRequest := handler.UpdateDataRequest{
+		Key:         r.Name,
+		Revision:    r.Revision,
+		Metainfo:    r.Metainfo,
+		ContentHash: r.ContentHash,
+	}
+
+	uri := makeURI(c.hostport, endpoint.BinaryDataEndpoint)
+	headers := map[string]string{
+		"token": u.Token,
+	}
+
+	return uploadByContentHash(r, &saveDataRequest.Data, func() error {
+		return request(ctx, uri, http.MethodPut, headers, saveDataRequest)
+	})
+}
+
+// UpdateBinaryDataDelta uploads chunks which aren't in the previous revision of chunked data, the server assembles
+// the new revision, whole data is uploaded when it isn't chunked, previous revision has chunks of another format
+// or the server doesn't have the previous chunks
+func (c *Client) UpdateBinaryDataDelta(
+	ctx context.Context,
+	u *storage.User,
+	r *storage.Record,
+	previousData string,
+) error {
+	format, chunks, ok := handler.SplitChunks(r.Data)
+	if !ok {
+		return c.UpdateBinaryData(ctx, u, r)
+	}
+
+	previousFormat, previousChunks, ok := handler.SplitChunks(previousData)
+	if !ok || previousFormat != format {
+		return c.UpdateBinaryData(ctx, u, r)
+	}
+
+	previous := make(map[string]struct{}, len(previousChunks))
+	for _, chunk := range previousChunks {
+		previous[handler.ChunkHash(chunk)] = struct{}{}
+	}
+
+	patchDataRequest := handler.PatchDataRequest{
 		Key:      r.Name,
-		Data:     string(r.Data),
 		Revision: r.Revision,
+		Metainfo: r.Metainfo,
+		Chunks:   make([]handler.Chunk, 0, len(chunks)),
+	}
+
+	for _, chunk := range chunks {
+		hash := handler.ChunkHash(chunk)
+		if _, ok := previous[hash]; ok {
+			patchDataRequest.Chunks = append(patchDataRequest.Chunks, handler.Chunk{Hash: hash})
+		} else {
+			patchDataRequest.Chunks = append(patchDataRequest.Chunks, handler.Chunk{Data: chunk})
+		}
 	}
 
 	uri := makeURI(c.hostport, endpoint.BinaryDataEndpoint)
@@ -92,13 +204,26 @@ func (c *Client) UpdateBinaryData(
 		"token": u.Token,
 	}
 
-	return requestAndHandle(ctx, uri, http.MethodPut, headers, saveDataRequest, func(r *http.Response) error {
-		if r.StatusCode == http.StatusNotFound {
-			return errors.New("data isn't exist")
+	err := request(ctx, uri, http.MethodPatch, headers, patchDataRequest)
+	if errors.Is(err, ErrUnknownContent) {
+		return c.UpdateBinaryData(ctx, u, r)
+	}
+
+	return err
+}
+
+// uploadByContentHash sends request without large data at first, so data which the server already has isn't uploaded,
+// the data is set to the request when the server doesn't know its content hash or the record doesn't have it
+func uploadByContentHash(r *storage.Record, requestData *string, send func() error) error {
+	if len(r.Data) >= dedupMinSize && len(r.ContentHash) > 0 {
+		if err := send(); !errors.Is(err, ErrUnknownContent) {
+			return err
 		}
+	}
 
-		return defaultHttpResponseHandler(r)
-	})
+	*requestData = r.Data
+
+	return send()
 }
 
 func (c *Client) DownloadBinaryData(
@@ -115,18 +240,12 @@ func (c *Client) DownloadBinaryData(
 		Key: dataKey,
 	}
 
-	resp, err := requestHandleAndParse[handler.GetDataResponse](ctx, uri, http.MethodGet, headers, getDataRequest, func(r *http.Response) error {
-		if r.StatusCode == http.StatusNotFound {
-			return errors.New("data isn't exist")
-		}
-
-		return nil
-	})
+	resp, err := requestAndParse[handler.GetDataResponse](ctx, uri, http.MethodGet, headers, getDataRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	return &storage.Record{Name: dataKey, Data: resp.Data, Revision: resp.Revision}, nil
+	return &storage.Record{Name: dataKey, Data: resp.Data, Revision: resp.Revision, Metainfo: resp.Metainfo}, nil
 }
 
 func (c *Client) RegisterUser(
@@ -167,6 +286,26 @@ func (c *Client) DeleteBinaryData(
 	return request(ctx, uri, http.MethodDelete, headers, deleteRequest)
 }
 
+func (c *Client) ListBinaryData(
+	ctx context.Context,
+	u *storage.User,
+	opts *ListOptions,
+	cursor string,
+) ([]*handler.DataInfo, string, error) {
+	uri := makeListURI(c.hostport, endpoint.BinariesDataEndpoint, opts, cursor)
+
+	headers := map[string]string{
+		"token": u.Token,
+	}
+
+	resp, err := requestAndParse[handler.ListDataResponse](ctx, uri, http.MethodGet, headers, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resp.Data, resp.NextCursor, nil
+}
+
 func (c *Client) CreateCardData(
 	ctx context.Context,
 	userToken string,
@@ -192,18 +331,37 @@ func (c *Client) DeleteCardData(
 	return request(ctx, uri, http.MethodDelete, map[string]string{"token": userToken}, deleteRequest)
 }
 
-func (c *Client) ListCardData(
+func (c *Client) GetCardData(
 	ctx context.Context,
 	userToken string,
-) ([]*handler.CardData, error) {
+	cardNumber string,
+) (string, error) {
 	uri := makeURI(c.hostport, endpoint.WalletEndpoint)
 
+	request := handler.GetCardDataRequest{CardNumber: cardNumber}
+
+	resp, err := requestAndParse[handler.GetCardDataResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, request)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.CardData, nil
+}
+
+func (c *Client) ListCardData(
+	ctx context.Context,
+	userToken string,
+	opts *ListOptions,
+	cursor string,
+) ([]*handler.CardInfo, string, error) {
+	uri := makeListURI(c.hostport, endpoint.WalletsEndpoint, opts, cursor)
+
 	resp, err := requestAndParse[handler.GetCardsResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return resp.Cards, nil
+	return resp.Cards, resp.NextCursor, nil
 }
 
 func (c *Client) CreateSecret(
@@ -248,17 +406,237 @@ func (c *Client) GetSecret(
 	return &storage.Secret{Key: resp.Key, Value: resp.Data}, nil
 }
 
+func (c *Client) ListSecrets(
+	ctx context.Context,
+	userToken string,
+	opts *ListOptions,
+	cursor string,
+) ([]*handler.SecretInfo, string, error) {
+	uri := makeListURI(c.hostport, endpoint.SecretsEndpoint, opts, cursor)
+
+	resp, err := requestAndParse[handler.SecretListResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resp.Data, resp.NextCursor, nil
+}
+
+func (c *Client) CreateSSHKey(
+	ctx context.Context,
+	userToken string,
+	name string,
+	data string,
+) error {
+	uri := makeURI(c.hostport, endpoint.SSHKeyEndpoint)
+
+	saveRequest := &handler.SaveSSHKeyRequest{Name: name, Data: data}
+
+	return request(ctx, uri, http.MethodPut, map[string]string{"token": userToken}, saveRequest)
+}
+
+func (c *Client) DeleteSSHKey(
+	ctx context.Context,
+	userToken string,
+	name string,
+) error {
+	uri := makeURI(c.hostport, endpoint.SSHKeyEndpoint)
+
+	deleteRequest := &handler.DeleteSSHKeyRequest{Name: name}
+
+	return request(ctx, uri, http.MethodDelete, map[string]string{"token": userToken}, deleteRequest)
+}
+
+func (c *Client) GetSSHKey(
+	ctx context.Context,
+	userToken string,
+	name string,
+) (string, error) {
+	uri := makeURI(c.hostport, endpoint.SSHKeyEndpoint)
+
+	getRequest := &handler.GetSSHKeyRequest{Name: name}
+
+	resp, err := requestAndParse[handler.GetSSHKeyResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, getRequest)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Data, nil
+}
+
+func (c *Client) ListSSHKeys(
+	ctx context.Context,
+	userToken string,
+	opts *ListOptions,
+	cursor string,
+) ([]*handler.SSHKeyInfo, string, error) {
+	uri := makeListURI(c.hostport, endpoint.SSHKeysEndpoint, opts, cursor)
+
+	resp, err := requestAndParse[handler.SSHKeyListResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resp.Data, resp.NextCursor, nil
+}
+
+func (c *Client) CreateServiceAccount(
+	ctx context.Context,
+	userToken string,
+	name string,
+	scope *handler.Scope,
+) (string, error) {
+	uri := makeURI(c.hostport, endpoint.ServiceAccountEndpoint)
+
+	createRequest := &handler.CreateServiceAccountRequest{Name: name, Scope: *scope}
+
+	resp, err := requestAndParse[handler.CreateServiceAccountResponse](
+		ctx, uri, http.MethodPut, map[string]string{"token": userToken}, createRequest,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+func (c *Client) ListServiceAccounts(
+	ctx context.Context,
+	userToken string,
+) ([]*handler.ServiceAccount, error) {
+	uri := makeURI(c.hostport, endpoint.ServiceAccountsEndpoint)
+
+	resp, err := requestAndParse[handler.ServiceAccountListResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+func (c *Client) DeleteServiceAccount(
+	ctx context.Context,
+	userToken string,
+	name string,
+) error {
+	uri := makeURI(c.hostport, endpoint.ServiceAccountEndpoint)
+
+	deleteRequest := &handler.DeleteServiceAccountRequest{Name: name}
+
+	return request(ctx, uri, http.MethodDelete, map[string]string{"token": userToken}, deleteRequest)
+}
+
+func (c *Client) ListAuditEvents(
+	ctx context.Context,
+	userToken string,
+	limit int,
+) ([]*handler.AuditEvent, error) {
+	uri := makeURI(c.hostport, endpoint.AuditEndpoint) + "?" + url.Values{"limit": {strconv.Itoa(limit)}}.Encode()
+
+	resp, err := requestAndParse[handler.AuditResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Events, nil
+}
+
+// GetUsage returns consumption of server's storage by user's items and user's quota
+func (c *Client) GetUsage(ctx context.Context, userToken string) (*handler.UsageResponse, error) {
+	uri := makeURI(c.hostport, endpoint.UsageEndpoint)
+
+	return requestAndParse[handler.UsageResponse](ctx, uri, http.MethodGet, map[string]string{"token": userToken}, nil)
+}
+
+// WatchChanges calls onChange for every change from the server's stream.
+// It returns when the stream is closed, ctx is done or onChange fails.
+func (c *Client) WatchChanges(
+	ctx context.Context,
+	u *storage.User,
+	onChange func(*storage.Change) error,
+) error {
+	uri := makeURI(c.hostport, endpoint.ChangesEndpoint)
+
+	req, err := makeRequest(ctx, uri, http.MethodGet, map[string]string{"token": u.Token}, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := defaultHttpResponseHandler(resp); err != nil {
+		return err
+	}
+
+	if err := readChangeEvents(resp.Body, onChange); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// readChangeEvents parses server-sent events, comments and fields except data are skipped
+func readChangeEvents(body io.Reader, onChange func(*storage.Change) error) error {
+	reader := bufio.NewReader(body)
+
+	var data strings.Builder
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read changes stream, err=%w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+
+			continue
+		}
+
+		if len(line) != 0 || data.Len() == 0 {
+			continue
+		}
+
+		event := &handler.ChangeEvent{}
+		if err := json.Unmarshal([]byte(data.String()), event); err != nil {
+			return fmt.Errorf("unmarshal change event=%s, err=%w", data.String(), err)
+		}
+
+		data.Reset()
+
+		err = onChange(&storage.Change{
+			Kind:      event.Kind,
+			Operation: event.Operation,
+			Key:       event.Key,
+			Data:      event.Data,
+			Revision:  event.Revision,
+			Metainfo:  event.Metainfo,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
 type httpResponseHandler func(*http.Response) error
 
+// defaultHttpResponseHandler returns *APIError of failed response
 func defaultHttpResponseHandler(r *http.Response) error {
-	switch r.StatusCode {
-	case http.StatusOK:
+	if r.StatusCode == http.StatusOK {
 		return nil
-	case http.StatusUnauthorized:
-		return errors.New("user must be registered")
-	default:
-		return statusCodeToError(r.StatusCode)
 	}
+
+	return responseError(r)
 }
 
 func request(
@@ -271,10 +649,6 @@ func request(
 	return requestAndHandle(ctx, uri, method, headers, request, defaultHttpResponseHandler)
 }
 
-func statusCodeToError(statusCode int) error {
-	return fmt.Errorf("request failed code=%d error=%s", statusCode, http.StatusText(statusCode))
-}
-
 func requestAndHandle(
 	ctx context.Context,
 	uri string,
@@ -381,6 +755,7 @@ func makeRequest(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("device", deviceName)
 
 	for header, v := range additionalHeaders {
 		req.Header.Set(header, v)
@@ -395,12 +770,22 @@ var tryingIntervals []time.Duration = []time.Duration{
 	time.Millisecond * 500,
 }
 
+// maxRetryAfter is the longest server's Retry-After which is waited, longer limit is returned as error
+var maxRetryAfter = time.Second * 30
+
 func doRequest(req *http.Request) (*http.Response, error) {
 	maxTryingsNum := len(tryingIntervals)
 
 	var err error
 
 	for trying := 0; trying <= maxTryingsNum; trying++ {
+		if trying > 0 && req.GetBody != nil {
+			// body was read by the previous trying
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
 		var resp *http.Response
 
 		resp, err = http.DefaultClient.Do(req)
@@ -408,10 +793,45 @@ func doRequest(req *http.Request) (*http.Response, error) {
 			if trying < maxTryingsNum {
 				time.Sleep(tryingIntervals[trying])
 			}
-		} else {
-			return resp, nil
+
+			continue
 		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && trying < maxTryingsNum {
+			wait, ok := retryAfter(resp)
+			if ok && wait <= maxRetryAfter {
+				resp.Body.Close()
+
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+
+				continue
+			}
+		}
+
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("request error: %w: %w", ErrServerUnavailable, err)
+}
+
+// retryAfter parses Retry-After header which is seconds or http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
 	}
 
-	return nil, fmt.Errorf("request error: %w", err)
+	return 0, false
 }
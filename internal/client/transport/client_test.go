@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/client/config"
+	"github.com/kuzhukin/goph-keeper/internal/client/gophcrypto"
 	"github.com/kuzhukin/goph-keeper/internal/client/storage"
 	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
 	"github.com/kuzhukin/goph-keeper/internal/server/handler"
@@ -46,6 +47,49 @@ func TestUploadData(t *testing.T) {
 	require.True(t, finished)
 }
 
+func TestUploadDataByContentHash(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token", CryptoKey: []byte("0123456789abcdef")}
+	record := &storage.Record{Name: "n", Data: "large data", ContentHash: gophcrypto.ContentHash(user.CryptoKey, []byte("plain data"))}
+
+	defer func(size int) { dedupMinSize = size }(dedupMinSize)
+	dedupMinSize = len(record.Data)
+
+	for _, tc := range []struct {
+		name     string
+		stored   bool
+		requests int
+	}{
+		{name: "stored content isn't uploaded", stored: true, requests: 1},
+		{name: "unknown content is uploaded", stored: false, requests: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+
+			srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				req := parseRequest[handler.SaveDataRequest](t, r)
+				require.Equal(t, record.ContentHash, req.ContentHash)
+
+				if len(req.Data) == 0 && !tc.stored {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srvr.Close()
+
+			cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+			require.NoError(t, cl.UploadBinaryData(ctx, user, record))
+			require.Equal(t, tc.requests, requests)
+		})
+	}
+}
+
 func TestUpdateBinData(t *testing.T) {
 	ctx := context.Background()
 	user := &storage.User{Token: "token"}
@@ -77,6 +121,72 @@ func TestUpdateBinData(t *testing.T) {
 	require.True(t, finished)
 }
 
+func TestUpdateBinaryDataDelta(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+	record := &storage.Record{Name: "n", Data: handler.JoinChunks("c2", []string{"AAA", "CCC"}), Revision: 2}
+
+	for _, tc := range []struct {
+		name     string
+		previous string
+		assemble bool
+		methods  []string
+	}{
+		{
+			name:     "changed chunks are uploaded",
+			previous: handler.JoinChunks("c2", []string{"AAA", "BBB"}),
+			assemble: true,
+			methods:  []string{http.MethodPatch},
+		},
+		{
+			name:     "whole data is uploaded without previous chunks",
+			previous: handler.JoinChunks("c2", []string{"AAA", "BBB"}),
+			assemble: false,
+			methods:  []string{http.MethodPatch, http.MethodPut},
+		},
+		{
+			name:     "whole data is uploaded when previous chunks have another format",
+			previous: handler.JoinChunks("c1", []string{"AAA", "BBB"}),
+			methods:  []string{http.MethodPut},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			methods := make([]string, 0, 2)
+
+			srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+
+				if r.Method == http.MethodPut {
+					req := parseRequest[handler.UpdateDataRequest](t, r)
+					require.Equal(t, record.Data, req.Data)
+
+					w.WriteHeader(http.StatusOK)
+
+					return
+				}
+
+				req := parseRequest[handler.PatchDataRequest](t, r)
+				require.Equal(t, record.Revision, req.Revision)
+				require.Equal(t, []handler.Chunk{{Hash: handler.ChunkHash("AAA")}, {Data: "CCC"}}, req.Chunks)
+
+				if !tc.assemble {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srvr.Close()
+
+			cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+			require.NoError(t, cl.UpdateBinaryDataDelta(ctx, user, record, tc.previous))
+			require.Equal(t, tc.methods, methods)
+		})
+	}
+}
+
 func TestDownloadBinData(t *testing.T) {
 	ctx := context.Background()
 	user := &storage.User{Token: "token"}
@@ -142,6 +252,45 @@ func TestDeleteData(t *testing.T) {
 	require.True(t, finished)
 }
 
+func TestListBinData(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pages := map[string]*handler.ListDataResponse{
+		"":     {Data: []*handler.DataInfo{{Name: "a", Revision: 1, UpdatedAt: updated}}, NextCursor: "next"},
+		"next": {Data: []*handler.DataInfo{{Name: "b", Revision: 2, Metainfo: "m", UpdatedAt: updated}}},
+	}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.BinariesDataEndpoint, r.URL.Path)
+		require.Equal(t, "token", r.Header.Get("token"))
+
+		params := r.URL.Query()
+		require.Equal(t, "dir/", params.Get(handler.ListParamPrefix))
+		require.Equal(t, "-updated", params.Get(handler.ListParamSort))
+		require.Equal(t, "2024-05-01T10:00:00Z", params.Get(handler.ListParamUpdatedSince))
+		require.Equal(t, "1", params.Get(handler.ListParamLimit))
+
+		data, err := json.Marshal(pages[params.Get(handler.ListParamCursor)])
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+	opts := &ListOptions{Prefix: "dir/", UpdatedSince: updated, Sort: "-updated", PageSize: 1}
+
+	actual, err := ListAll(func(cursor string) ([]*handler.DataInfo, string, error) {
+		return cl.ListBinaryData(ctx, user, opts, cursor)
+	})
+	require.NoError(t, err)
+	require.Equal(t, append(pages[""].Data, pages["next"].Data...), actual)
+}
+
 func TestRegister(t *testing.T) {
 	ctx := context.Background()
 	user := &storage.User{Login: "login", Password: "password"}
@@ -237,18 +386,44 @@ func TestDeleteCard(t *testing.T) {
 	require.True(t, finished)
 }
 
+func TestGetCard(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.WalletEndpoint, r.URL.Path)
+		require.Equal(t, user.Token, r.Header.Get("token"))
+
+		req := parseRequest[handler.GetCardDataRequest](t, r)
+		require.Equal(t, "1234", req.CardNumber)
+
+		data, err := json.Marshal(&handler.GetCardDataResponse{CardNumber: "1234", CardData: "crypted_data"})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	data, err := cl.GetCardData(ctx, user.Token, "1234")
+	require.NoError(t, err)
+	require.Equal(t, "crypted_data", data)
+}
+
 func TestListCard(t *testing.T) {
 	ctx := context.Background()
 	user := &storage.User{Token: "token"}
-	cards := []*handler.CardData{{Number: "n", Data: "d"}}
+	cards := []*handler.CardInfo{{Number: "n", UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}}
 
 	finished := false
 
 	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet && r.URL.Path == endpoint.WalletEndpoint {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.WalletsEndpoint, r.URL.Path)
 		require.Equal(t, user.Token, r.Header.Get("token"))
 
 		l := &handler.GetCardsResponse{
@@ -270,9 +445,10 @@ func TestListCard(t *testing.T) {
 
 	cl := NewClient(&config.Config{Hostport: srvr.URL})
 
-	data, err := cl.ListCardData(ctx, user.Token)
+	data, cursor, err := cl.ListCardData(ctx, user.Token, nil, "")
 	require.NoError(t, err)
 	require.Equal(t, cards, data)
+	require.Empty(t, cursor)
 	require.True(t, finished)
 }
 
@@ -380,6 +556,243 @@ func TestGetSecret(t *testing.T) {
 	require.True(t, finished)
 }
 
+func TestCreateSSHKey(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+
+	finished := false
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != endpoint.SSHKeyEndpoint {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		req := parseRequest[handler.SaveSSHKeyRequest](t, r)
+
+		require.Equal(t, "deploy", req.Name)
+		require.Equal(t, "data", req.Data)
+		require.Equal(t, user.Token, r.Header.Get("token"))
+
+		w.WriteHeader(http.StatusOK)
+		finished = true
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.CreateSSHKey(ctx, user.Token, "deploy", "data")
+	require.NoError(t, err)
+	require.True(t, finished)
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	ctx := context.Background()
+	scope := &handler.Scope{Kinds: []string{handler.ItemKindSecret}, Prefixes: []string{"ci/"}, ReadOnly: true}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != endpoint.ServiceAccountEndpoint {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		req := parseRequest[handler.CreateServiceAccountRequest](t, r)
+
+		require.Equal(t, "ci", req.Name)
+		require.Equal(t, *scope, req.Scope)
+		require.Equal(t, "token", r.Header.Get("token"))
+
+		data, err := json.Marshal(&handler.CreateServiceAccountResponse{Token: "service_token"})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	token, err := cl.CreateServiceAccount(ctx, "token", "ci", scope)
+	require.NoError(t, err)
+	require.Equal(t, "service_token", token)
+}
+
+func TestForbiddenByScope(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.DeleteSecret(context.Background(), "service_token", "ci/password")
+	require.ErrorContains(t, err, "scope")
+}
+
+func TestListAuditEvents(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != endpoint.AuditEndpoint {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		require.Equal(t, "20", r.URL.Query().Get("limit"))
+		require.Equal(t, "token", r.Header.Get("token"))
+		require.NotEmpty(t, r.Header.Get("device"))
+
+		data, err := json.Marshal(&handler.AuditResponse{Events: []*handler.AuditEvent{{Action: handler.AuditActionLogin}}})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	events, err := cl.ListAuditEvents(context.Background(), "token", 20)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, handler.AuditActionLogin, events[0].Action)
+}
+
+func TestGetUsage(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != endpoint.UsageEndpoint {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		require.Equal(t, "token", r.Header.Get("token"))
+
+		data, err := json.Marshal(&handler.UsageResponse{Bytes: 10, Items: 1, MaxBytes: 100})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	usage, err := cl.GetUsage(context.Background(), "token")
+	require.NoError(t, err)
+	require.Equal(t, &handler.UsageResponse{Bytes: 10, Items: 1, MaxBytes: 100}, usage)
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInsufficientStorage)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.CreateSecret(context.Background(), "token", "key", "value")
+	require.ErrorContains(t, err, "quota is exceeded")
+}
+
+func TestAPIErrorCodes(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.WriteError(w, http.StatusConflict, handler.ErrorCodeRevisionConflict, "item was changed by another client")
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.UpdateBinaryData(context.Background(), &storage.User{Token: "token"}, &storage.Record{Name: "n", Data: "d", Revision: 1})
+	require.ErrorIs(t, err, ErrRevisionConflict)
+	require.NotErrorIs(t, err, ErrAlreadyExists)
+	require.EqualError(t, err, "item was changed by another client")
+
+	apiErr := &APIError{}
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusConflict, apiErr.StatusCode)
+}
+
+func TestAPIErrorWithoutBody(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == endpoint.SecretEndpoint {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	_, err := cl.GetSecret(context.Background(), "token", "key")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	err = cl.DeleteSSHKey(context.Background(), "token", "key")
+	require.EqualError(t, err, "request failed code=502 error=Bad Gateway")
+}
+
+func TestRetryAfterTooManyRequests(t *testing.T) {
+	requests := 0
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		// body is sent again by the retry
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "key")
+
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	require.NoError(t, cl.CreateSecret(context.Background(), "token", "key", "value"))
+	require.Equal(t, 2, requests)
+}
+
+func TestTooLongRetryAfter(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "900")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.CreateSecret(context.Background(), "token", "key", "value")
+	require.ErrorContains(t, err, "retry after 900s")
+}
+
+func TestServerUnavailable(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	err := cl.DeleteBinaryData(ctx, user, "n")
+	require.ErrorIs(t, err, ErrServerUnavailable)
+}
+
 func parseRequest[T any](t *testing.T, r *http.Request) *T {
 	bin, err := io.ReadAll(r.Body)
 	require.NoError(t, err)
@@ -391,3 +804,95 @@ func parseRequest[T any](t *testing.T, r *http.Request) *T {
 
 	return obj
 }
+
+func TestWatchChanges(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.ChangesEndpoint, r.URL.Path)
+		require.Equal(t, "token", r.Header.Get("token"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		_, err := w.Write([]byte(": subscribed\n\n" +
+			"event: change\ndata: {\"kind\":\"data\",\"operation\":\"update\",\"key\":\"k\",\"data\":\"d\",\"revision\":2}\n\n" +
+			": keep-alive\n\n" +
+			"event: change\ndata: {\"kind\":\"secret\",\"operation\":\"delete\",\"key\":\"s\"}\n\n"))
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	changes := make([]*storage.Change, 0, 2)
+
+	err := cl.WatchChanges(ctx, user, func(c *storage.Change) error {
+		changes = append(changes, c)
+
+		return nil
+	})
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, []*storage.Change{
+		{Kind: "data", Operation: "update", Key: "k", Data: "d", Revision: 2},
+		{Kind: "secret", Operation: "delete", Key: "s"},
+	}, changes)
+}
+
+func TestGetSSHKey(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.SSHKeyEndpoint, r.URL.Path)
+		require.Equal(t, user.Token, r.Header.Get("token"))
+
+		req := parseRequest[handler.GetSSHKeyRequest](t, r)
+		require.Equal(t, "deploy", req.Name)
+
+		data, err := json.Marshal(&handler.GetSSHKeyResponse{Name: "deploy", Data: "crypted_data"})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	data, err := cl.GetSSHKey(ctx, user.Token, "deploy")
+	require.NoError(t, err)
+	require.Equal(t, "crypted_data", data)
+}
+
+func TestListSSHKeys(t *testing.T) {
+	ctx := context.Background()
+	user := &storage.User{Token: "token"}
+	keys := []*handler.SSHKeyInfo{{Name: "deploy", UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}}
+
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, endpoint.SSHKeysEndpoint, r.URL.Path)
+		require.Equal(t, user.Token, r.Header.Get("token"))
+		require.Equal(t, "next", r.URL.Query().Get(handler.ListParamCursor))
+
+		data, err := json.Marshal(&handler.SSHKeyListResponse{Data: keys})
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}))
+
+	defer srvr.Close()
+
+	cl := NewClient(&config.Config{Hostport: srvr.URL})
+
+	data, cursor, err := cl.ListSSHKeys(ctx, user.Token, nil, "next")
+	require.NoError(t, err)
+	require.Equal(t, keys, data)
+	require.Empty(t, cursor)
+}
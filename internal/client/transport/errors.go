@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// APIError is the server's error response, it's matched with errors below by code:
+//
+//	errors.Is(err, transport.ErrRevisionConflict)
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+
+	return ok && len(t.Code) > 0 && t.Code == e.Code
+}
+
+// errors of server's error codes
+var (
+	ErrBadRequest       = &APIError{Code: handler.ErrorCodeBadRequest, Message: "request isn't valid"}
+	ErrUnauthorized     = &APIError{Code: handler.ErrorCodeUnauthorized, Message: "user must be registered"}
+	ErrForbidden        = &APIError{Code: handler.ErrorCodeForbidden, Message: "token's scope doesn't allow operation"}
+	ErrNotFound         = &APIError{Code: handler.ErrorCodeNotFound, Message: "item isn't found"}
+	ErrAlreadyExists    = &APIError{Code: handler.ErrorCodeAlreadyExists, Message: "item already exists"}
+	ErrRevisionConflict = &APIError{Code: handler.ErrorCodeRevisionConflict, Message: "item was changed by another client"}
+	ErrTooLarge         = &APIError{Code: handler.ErrorCodeTooLarge, Message: "item is larger than server allows"}
+	ErrUnknownContent   = &APIError{Code: handler.ErrorCodeUnknownContent, Message: "server doesn't have the content"}
+	ErrTooManyRequests  = &APIError{Code: handler.ErrorCodeTooManyRequests, Message: "too many requests"}
+	ErrQuotaExceeded    = &APIError{Code: handler.ErrorCodeQuotaExceeded, Message: "storage quota is exceeded"}
+	ErrInternal         = &APIError{Code: handler.ErrorCodeInternal, Message: "internal server error"}
+)
+
+// statusErrors are used when response doesn't have error's body, e.g. it's answered by proxy,
+// conflict's status isn't mapped because it's either existing item or changed revision
+var statusErrors = map[int]*APIError{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnprocessableEntity:   ErrUnknownContent,
+	http.StatusTooManyRequests:       ErrTooManyRequests,
+	http.StatusInternalServerError:   ErrInternal,
+	http.StatusInsufficientStorage:   ErrQuotaExceeded,
+}
+
+// maxErrorResponseSize limits read of error's body, server's errors are short
+const maxErrorResponseSize = 4096
+
+// responseError reads error of the failed response
+func responseError(r *http.Response) error {
+	apiErr := &APIError{StatusCode: r.StatusCode}
+
+	if known, ok := statusErrors[r.StatusCode]; ok {
+		apiErr.Code = known.Code
+		apiErr.Message = known.Message
+	} else {
+		apiErr.Message = fmt.Sprintf("request failed code=%d error=%s", r.StatusCode, http.StatusText(r.StatusCode))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxErrorResponseSize))
+	if err == nil {
+		resp := &handler.ErrorResponse{}
+		if err := json.Unmarshal(data, resp); err == nil && resp.Error != nil && len(resp.Error.Code) > 0 {
+			apiErr.Code = resp.Error.Code
+			apiErr.Message = resp.Error.Message
+		}
+	}
+
+	if r.StatusCode == http.StatusTooManyRequests {
+		apiErr.Message = fmt.Sprintf("%s, retry after %ss", apiErr.Message, r.Header.Get("Retry-After"))
+	}
+
+	return apiErr
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// ListOptions filters and sorts listed items, nil options list all items by names
+type ListOptions struct {
+	Prefix string
+	// UpdatedSince lists items which were changed at the time or later
+	UpdatedSince time.Time
+	// Sort is "name" or "updated", "-" before the order is a descending sort
+	Sort string
+	// PageSize is a number of items in the page, server's default is used when it's 0
+	PageSize int
+}
+
+func makeListURI(hostport string, endpoint string, opts *ListOptions, cursor string) string {
+	params := url.Values{}
+
+	if len(cursor) > 0 {
+		params.Set(handler.ListParamCursor, cursor)
+	}
+
+	if opts != nil {
+		if len(opts.Prefix) > 0 {
+			params.Set(handler.ListParamPrefix, opts.Prefix)
+		}
+
+		if !opts.UpdatedSince.IsZero() {
+			params.Set(handler.ListParamUpdatedSince, opts.UpdatedSince.Format(time.RFC3339))
+		}
+
+		if len(opts.Sort) > 0 {
+			params.Set(handler.ListParamSort, opts.Sort)
+		}
+
+		if opts.PageSize > 0 {
+			params.Set(handler.ListParamLimit, strconv.Itoa(opts.PageSize))
+		}
+	}
+
+	uri := makeURI(hostport, endpoint)
+	if len(params) == 0 {
+		return uri
+	}
+
+	return uri + "?" + params.Encode()
+}
+
+// ListAll requests pages of the list until the last one
+func ListAll[T any](listPage func(cursor string) ([]T, string, error)) ([]T, error) {
+	var (
+		items  []T
+		cursor string
+	)
+
+	for {
+		page, next, err := listPage(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page...)
+		if len(next) == 0 {
+			return items, nil
+		}
+
+		cursor = next
+	}
+}
@@ -65,6 +65,22 @@ func (mr *MockBinaryDataClientMockRecorder) DownloadBinaryData(ctx, u, dataKey i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBinaryData", reflect.TypeOf((*MockBinaryDataClient)(nil).DownloadBinaryData), ctx, u, dataKey)
 }
 
+// ListBinaryData mocks base method.
+func (m *MockBinaryDataClient) ListBinaryData(ctx context.Context, u *storage.User, opts *ListOptions, cursor string) ([]*handler.DataInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBinaryData", ctx, u, opts, cursor)
+	ret0, _ := ret[0].([]*handler.DataInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListBinaryData indicates an expected call of ListBinaryData.
+func (mr *MockBinaryDataClientMockRecorder) ListBinaryData(ctx, u, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBinaryData", reflect.TypeOf((*MockBinaryDataClient)(nil).ListBinaryData), ctx, u, opts, cursor)
+}
+
 // UpdateBinaryData mocks base method.
 func (m *MockBinaryDataClient) UpdateBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error {
 	m.ctrl.T.Helper()
@@ -79,6 +95,20 @@ func (mr *MockBinaryDataClientMockRecorder) UpdateBinaryData(ctx, u, r interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBinaryData", reflect.TypeOf((*MockBinaryDataClient)(nil).UpdateBinaryData), ctx, u, r)
 }
 
+// UpdateBinaryDataDelta mocks base method.
+func (m *MockBinaryDataClient) UpdateBinaryDataDelta(ctx context.Context, u *storage.User, r *storage.Record, previousData string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBinaryDataDelta", ctx, u, r, previousData)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBinaryDataDelta indicates an expected call of UpdateBinaryDataDelta.
+func (mr *MockBinaryDataClientMockRecorder) UpdateBinaryDataDelta(ctx, u, r, previousData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBinaryDataDelta", reflect.TypeOf((*MockBinaryDataClient)(nil).UpdateBinaryDataDelta), ctx, u, r, previousData)
+}
+
 // UploadBinaryData mocks base method.
 func (m *MockBinaryDataClient) UploadBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error {
 	m.ctrl.T.Helper()
@@ -197,6 +227,22 @@ func (mr *MockSecretDataClientMockRecorder) GetSecret(ctx, userToken, secretName
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockSecretDataClient)(nil).GetSecret), ctx, userToken, secretName)
 }
 
+// ListSecrets mocks base method.
+func (m *MockSecretDataClient) ListSecrets(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SecretInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.SecretInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *MockSecretDataClientMockRecorder) ListSecrets(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockSecretDataClient)(nil).ListSecrets), ctx, userToken, opts, cursor)
+}
+
 // MockWalletClient is a mock of WalletClient interface.
 type MockWalletClient struct {
 	ctrl     *gomock.Controller
@@ -248,17 +294,582 @@ func (mr *MockWalletClientMockRecorder) DeleteCardData(ctx, userToken, cardNumbe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCardData", reflect.TypeOf((*MockWalletClient)(nil).DeleteCardData), ctx, userToken, cardNumber)
 }
 
+// GetCardData mocks base method.
+func (m *MockWalletClient) GetCardData(ctx context.Context, userToken, cardNumber string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardData", ctx, userToken, cardNumber)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCardData indicates an expected call of GetCardData.
+func (mr *MockWalletClientMockRecorder) GetCardData(ctx, userToken, cardNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardData", reflect.TypeOf((*MockWalletClient)(nil).GetCardData), ctx, userToken, cardNumber)
+}
+
 // ListCardData mocks base method.
-func (m *MockWalletClient) ListCardData(ctx context.Context, userToken string) ([]*handler.CardData, error) {
+func (m *MockWalletClient) ListCardData(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.CardInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCardData", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.CardInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListCardData indicates an expected call of ListCardData.
+func (mr *MockWalletClientMockRecorder) ListCardData(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCardData", reflect.TypeOf((*MockWalletClient)(nil).ListCardData), ctx, userToken, opts, cursor)
+}
+
+// MockSSHKeyClient is a mock of SSHKeyClient interface.
+type MockSSHKeyClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSHKeyClientMockRecorder
+}
+
+// MockSSHKeyClientMockRecorder is the mock recorder for MockSSHKeyClient.
+type MockSSHKeyClientMockRecorder struct {
+	mock *MockSSHKeyClient
+}
+
+// NewMockSSHKeyClient creates a new mock instance.
+func NewMockSSHKeyClient(ctrl *gomock.Controller) *MockSSHKeyClient {
+	mock := &MockSSHKeyClient{ctrl: ctrl}
+	mock.recorder = &MockSSHKeyClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSSHKeyClient) EXPECT() *MockSSHKeyClientMockRecorder {
+	return m.recorder
+}
+
+// CreateSSHKey mocks base method.
+func (m *MockSSHKeyClient) CreateSSHKey(ctx context.Context, userToken, name, data string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSHKey", ctx, userToken, name, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSSHKey indicates an expected call of CreateSSHKey.
+func (mr *MockSSHKeyClientMockRecorder) CreateSSHKey(ctx, userToken, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSHKey", reflect.TypeOf((*MockSSHKeyClient)(nil).CreateSSHKey), ctx, userToken, name, data)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockSSHKeyClient) DeleteSSHKey(ctx context.Context, userToken, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockSSHKeyClientMockRecorder) DeleteSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockSSHKeyClient)(nil).DeleteSSHKey), ctx, userToken, name)
+}
+
+// GetSSHKey mocks base method.
+func (m *MockSSHKeyClient) GetSSHKey(ctx context.Context, userToken, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSSHKey indicates an expected call of GetSSHKey.
+func (mr *MockSSHKeyClientMockRecorder) GetSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSSHKey", reflect.TypeOf((*MockSSHKeyClient)(nil).GetSSHKey), ctx, userToken, name)
+}
+
+// ListSSHKeys mocks base method.
+func (m *MockSSHKeyClient) ListSSHKeys(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SSHKeyInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSSHKeys", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.SSHKeyInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListSSHKeys indicates an expected call of ListSSHKeys.
+func (mr *MockSSHKeyClientMockRecorder) ListSSHKeys(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSSHKeys", reflect.TypeOf((*MockSSHKeyClient)(nil).ListSSHKeys), ctx, userToken, opts, cursor)
+}
+
+// MockServiceAccountClient is a mock of ServiceAccountClient interface.
+type MockServiceAccountClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountClientMockRecorder
+}
+
+// MockServiceAccountClientMockRecorder is the mock recorder for MockServiceAccountClient.
+type MockServiceAccountClientMockRecorder struct {
+	mock *MockServiceAccountClient
+}
+
+// NewMockServiceAccountClient creates a new mock instance.
+func NewMockServiceAccountClient(ctrl *gomock.Controller) *MockServiceAccountClient {
+	mock := &MockServiceAccountClient{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountClient) EXPECT() *MockServiceAccountClientMockRecorder {
+	return m.recorder
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockServiceAccountClient) CreateServiceAccount(ctx context.Context, userToken, name string, scope *handler.Scope) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, userToken, name, scope)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockServiceAccountClientMockRecorder) CreateServiceAccount(ctx, userToken, name, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockServiceAccountClient)(nil).CreateServiceAccount), ctx, userToken, name, scope)
+}
+
+// DeleteServiceAccount mocks base method.
+func (m *MockServiceAccountClient) DeleteServiceAccount(ctx context.Context, userToken, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceAccount", ctx, userToken, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceAccount indicates an expected call of DeleteServiceAccount.
+func (mr *MockServiceAccountClientMockRecorder) DeleteServiceAccount(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceAccount", reflect.TypeOf((*MockServiceAccountClient)(nil).DeleteServiceAccount), ctx, userToken, name)
+}
+
+// ListServiceAccounts mocks base method.
+func (m *MockServiceAccountClient) ListServiceAccounts(ctx context.Context, userToken string) ([]*handler.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccounts", ctx, userToken)
+	ret0, _ := ret[0].([]*handler.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccounts indicates an expected call of ListServiceAccounts.
+func (mr *MockServiceAccountClientMockRecorder) ListServiceAccounts(ctx, userToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccounts", reflect.TypeOf((*MockServiceAccountClient)(nil).ListServiceAccounts), ctx, userToken)
+}
+
+// MockAuditClient is a mock of AuditClient interface.
+type MockAuditClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditClientMockRecorder
+}
+
+// MockAuditClientMockRecorder is the mock recorder for MockAuditClient.
+type MockAuditClientMockRecorder struct {
+	mock *MockAuditClient
+}
+
+// NewMockAuditClient creates a new mock instance.
+func NewMockAuditClient(ctrl *gomock.Controller) *MockAuditClient {
+	mock := &MockAuditClient{ctrl: ctrl}
+	mock.recorder = &MockAuditClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditClient) EXPECT() *MockAuditClientMockRecorder {
+	return m.recorder
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuditClient) ListAuditEvents(ctx context.Context, userToken string, limit int) ([]*handler.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, userToken, limit)
+	ret0, _ := ret[0].([]*handler.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditClientMockRecorder) ListAuditEvents(ctx, userToken, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuditClient)(nil).ListAuditEvents), ctx, userToken, limit)
+}
+
+// MockUsageClient is a mock of UsageClient interface.
+type MockUsageClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsageClientMockRecorder
+}
+
+// MockUsageClientMockRecorder is the mock recorder for MockUsageClient.
+type MockUsageClientMockRecorder struct {
+	mock *MockUsageClient
+}
+
+// NewMockUsageClient creates a new mock instance.
+func NewMockUsageClient(ctrl *gomock.Controller) *MockUsageClient {
+	mock := &MockUsageClient{ctrl: ctrl}
+	mock.recorder = &MockUsageClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsageClient) EXPECT() *MockUsageClientMockRecorder {
+	return m.recorder
+}
+
+// GetUsage mocks base method.
+func (m *MockUsageClient) GetUsage(ctx context.Context, userToken string) (*handler.UsageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, userToken)
+	ret0, _ := ret[0].(*handler.UsageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockUsageClientMockRecorder) GetUsage(ctx, userToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockUsageClient)(nil).GetUsage), ctx, userToken)
+}
+
+// MockChangesClient is a mock of ChangesClient interface.
+type MockChangesClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangesClientMockRecorder
+}
+
+// MockChangesClientMockRecorder is the mock recorder for MockChangesClient.
+type MockChangesClientMockRecorder struct {
+	mock *MockChangesClient
+}
+
+// NewMockChangesClient creates a new mock instance.
+func NewMockChangesClient(ctrl *gomock.Controller) *MockChangesClient {
+	mock := &MockChangesClient{ctrl: ctrl}
+	mock.recorder = &MockChangesClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangesClient) EXPECT() *MockChangesClientMockRecorder {
+	return m.recorder
+}
+
+// WatchChanges mocks base method.
+func (m *MockChangesClient) WatchChanges(ctx context.Context, u *storage.User, onChange func(*storage.Change) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchChanges", ctx, u, onChange)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchChanges indicates an expected call of WatchChanges.
+func (mr *MockChangesClientMockRecorder) WatchChanges(ctx, u, onChange interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchChanges", reflect.TypeOf((*MockChangesClient)(nil).WatchChanges), ctx, u, onChange)
+}
+
+// MockItemsClient is a mock of ItemsClient interface.
+type MockItemsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockItemsClientMockRecorder
+}
+
+// MockItemsClientMockRecorder is the mock recorder for MockItemsClient.
+type MockItemsClientMockRecorder struct {
+	mock *MockItemsClient
+}
+
+// NewMockItemsClient creates a new mock instance.
+func NewMockItemsClient(ctrl *gomock.Controller) *MockItemsClient {
+	mock := &MockItemsClient{ctrl: ctrl}
+	mock.recorder = &MockItemsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockItemsClient) EXPECT() *MockItemsClientMockRecorder {
+	return m.recorder
+}
+
+// CreateCardData mocks base method.
+func (m *MockItemsClient) CreateCardData(ctx context.Context, userToken, cardNumber, cardData string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCardData", ctx, userToken, cardNumber, cardData)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCardData indicates an expected call of CreateCardData.
+func (mr *MockItemsClientMockRecorder) CreateCardData(ctx, userToken, cardNumber, cardData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCardData", reflect.TypeOf((*MockItemsClient)(nil).CreateCardData), ctx, userToken, cardNumber, cardData)
+}
+
+// CreateSSHKey mocks base method.
+func (m *MockItemsClient) CreateSSHKey(ctx context.Context, userToken, name, data string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSHKey", ctx, userToken, name, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSSHKey indicates an expected call of CreateSSHKey.
+func (mr *MockItemsClientMockRecorder) CreateSSHKey(ctx, userToken, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSHKey", reflect.TypeOf((*MockItemsClient)(nil).CreateSSHKey), ctx, userToken, name, data)
+}
+
+// CreateSecret mocks base method.
+func (m *MockItemsClient) CreateSecret(ctx context.Context, userToken, secretName, secretData string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSecret", ctx, userToken, secretName, secretData)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSecret indicates an expected call of CreateSecret.
+func (mr *MockItemsClientMockRecorder) CreateSecret(ctx, userToken, secretName, secretData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSecret", reflect.TypeOf((*MockItemsClient)(nil).CreateSecret), ctx, userToken, secretName, secretData)
+}
+
+// DeleteBinaryData mocks base method.
+func (m *MockItemsClient) DeleteBinaryData(ctx context.Context, u *storage.User, dataKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBinaryData", ctx, u, dataKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBinaryData indicates an expected call of DeleteBinaryData.
+func (mr *MockItemsClientMockRecorder) DeleteBinaryData(ctx, u, dataKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBinaryData", reflect.TypeOf((*MockItemsClient)(nil).DeleteBinaryData), ctx, u, dataKey)
+}
+
+// DeleteCardData mocks base method.
+func (m *MockItemsClient) DeleteCardData(ctx context.Context, userToken, cardNumber string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCardData", ctx, userToken, cardNumber)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCardData indicates an expected call of DeleteCardData.
+func (mr *MockItemsClientMockRecorder) DeleteCardData(ctx, userToken, cardNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCardData", reflect.TypeOf((*MockItemsClient)(nil).DeleteCardData), ctx, userToken, cardNumber)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockItemsClient) DeleteSSHKey(ctx context.Context, userToken, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockItemsClientMockRecorder) DeleteSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockItemsClient)(nil).DeleteSSHKey), ctx, userToken, name)
+}
+
+// DeleteSecret mocks base method.
+func (m *MockItemsClient) DeleteSecret(ctx context.Context, userToken, secretKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecret", ctx, userToken, secretKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret.
+func (mr *MockItemsClientMockRecorder) DeleteSecret(ctx, userToken, secretKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockItemsClient)(nil).DeleteSecret), ctx, userToken, secretKey)
+}
+
+// DownloadBinaryData mocks base method.
+func (m *MockItemsClient) DownloadBinaryData(ctx context.Context, u *storage.User, dataKey string) (*storage.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadBinaryData", ctx, u, dataKey)
+	ret0, _ := ret[0].(*storage.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadBinaryData indicates an expected call of DownloadBinaryData.
+func (mr *MockItemsClientMockRecorder) DownloadBinaryData(ctx, u, dataKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBinaryData", reflect.TypeOf((*MockItemsClient)(nil).DownloadBinaryData), ctx, u, dataKey)
+}
+
+// GetCardData mocks base method.
+func (m *MockItemsClient) GetCardData(ctx context.Context, userToken, cardNumber string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardData", ctx, userToken, cardNumber)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCardData indicates an expected call of GetCardData.
+func (mr *MockItemsClientMockRecorder) GetCardData(ctx, userToken, cardNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardData", reflect.TypeOf((*MockItemsClient)(nil).GetCardData), ctx, userToken, cardNumber)
+}
+
+// GetSSHKey mocks base method.
+func (m *MockItemsClient) GetSSHKey(ctx context.Context, userToken, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSSHKey indicates an expected call of GetSSHKey.
+func (mr *MockItemsClientMockRecorder) GetSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSSHKey", reflect.TypeOf((*MockItemsClient)(nil).GetSSHKey), ctx, userToken, name)
+}
+
+// GetSecret mocks base method.
+func (m *MockItemsClient) GetSecret(ctx context.Context, userToken, secretName string) (*storage.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListCardData", ctx, userToken)
-	ret0, _ := ret[0].([]*handler.CardData)
+	ret := m.ctrl.Call(m, "GetSecret", ctx, userToken, secretName)
+	ret0, _ := ret[0].(*storage.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetSecret indicates an expected call of GetSecret.
+func (mr *MockItemsClientMockRecorder) GetSecret(ctx, userToken, secretName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockItemsClient)(nil).GetSecret), ctx, userToken, secretName)
+}
+
+// ListBinaryData mocks base method.
+func (m *MockItemsClient) ListBinaryData(ctx context.Context, u *storage.User, opts *ListOptions, cursor string) ([]*handler.DataInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBinaryData", ctx, u, opts, cursor)
+	ret0, _ := ret[0].([]*handler.DataInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListBinaryData indicates an expected call of ListBinaryData.
+func (mr *MockItemsClientMockRecorder) ListBinaryData(ctx, u, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBinaryData", reflect.TypeOf((*MockItemsClient)(nil).ListBinaryData), ctx, u, opts, cursor)
+}
+
+// ListCardData mocks base method.
+func (m *MockItemsClient) ListCardData(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.CardInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCardData", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.CardInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // ListCardData indicates an expected call of ListCardData.
-func (mr *MockWalletClientMockRecorder) ListCardData(ctx, userToken interface{}) *gomock.Call {
+func (mr *MockItemsClientMockRecorder) ListCardData(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCardData", reflect.TypeOf((*MockItemsClient)(nil).ListCardData), ctx, userToken, opts, cursor)
+}
+
+// ListSSHKeys mocks base method.
+func (m *MockItemsClient) ListSSHKeys(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SSHKeyInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSSHKeys", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.SSHKeyInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListSSHKeys indicates an expected call of ListSSHKeys.
+func (mr *MockItemsClientMockRecorder) ListSSHKeys(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSSHKeys", reflect.TypeOf((*MockItemsClient)(nil).ListSSHKeys), ctx, userToken, opts, cursor)
+}
+
+// ListSecrets mocks base method.
+func (m *MockItemsClient) ListSecrets(ctx context.Context, userToken string, opts *ListOptions, cursor string) ([]*handler.SecretInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, userToken, opts, cursor)
+	ret0, _ := ret[0].([]*handler.SecretInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *MockItemsClientMockRecorder) ListSecrets(ctx, userToken, opts, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockItemsClient)(nil).ListSecrets), ctx, userToken, opts, cursor)
+}
+
+// UpdateBinaryData mocks base method.
+func (m *MockItemsClient) UpdateBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBinaryData", ctx, u, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBinaryData indicates an expected call of UpdateBinaryData.
+func (mr *MockItemsClientMockRecorder) UpdateBinaryData(ctx, u, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBinaryData", reflect.TypeOf((*MockItemsClient)(nil).UpdateBinaryData), ctx, u, r)
+}
+
+// UpdateBinaryDataDelta mocks base method.
+func (m *MockItemsClient) UpdateBinaryDataDelta(ctx context.Context, u *storage.User, r *storage.Record, previousData string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBinaryDataDelta", ctx, u, r, previousData)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBinaryDataDelta indicates an expected call of UpdateBinaryDataDelta.
+func (mr *MockItemsClientMockRecorder) UpdateBinaryDataDelta(ctx, u, r, previousData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBinaryDataDelta", reflect.TypeOf((*MockItemsClient)(nil).UpdateBinaryDataDelta), ctx, u, r, previousData)
+}
+
+// UploadBinaryData mocks base method.
+func (m *MockItemsClient) UploadBinaryData(ctx context.Context, u *storage.User, r *storage.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadBinaryData", ctx, u, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadBinaryData indicates an expected call of UploadBinaryData.
+func (mr *MockItemsClientMockRecorder) UploadBinaryData(ctx, u, r interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCardData", reflect.TypeOf((*MockWalletClient)(nil).ListCardData), ctx, userToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadBinaryData", reflect.TypeOf((*MockItemsClient)(nil).UploadBinaryData), ctx, u, r)
 }
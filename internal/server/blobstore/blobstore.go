@@ -0,0 +1,24 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+)
+
+// blob store's drivers which are selected by server's config
+const (
+	DriverFS = "fs"
+	DriverS3 = "s3"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps encrypted payloads of binary data, db keeps only their keys and metadata
+type BlobStore interface {
+	// Put creates or overwrites blob with the key
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrBlobNotFound when the blob doesn't exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete doesn't fail when the blob doesn't exist
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,57 @@
+package blobstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, s)
+}
+
+func TestFileStoreBadKey(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.Error(t, s.Put(context.Background(), "../outside", []byte("data")))
+	require.Error(t, s.Put(context.Background(), "/abs", []byte("data")))
+}
+
+// testBlobStore checks behaviour which is common for all stores
+func testBlobStore(t *testing.T, s BlobStore) {
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "user/unknown")
+	require.ErrorIs(t, err, ErrBlobNotFound)
+
+	require.NoError(t, s.Put(ctx, "user/blob", []byte("data")))
+
+	data, err := s.Get(ctx, "user/blob")
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), data)
+
+	require.NoError(t, s.Put(ctx, "user/blob", []byte("new data")))
+
+	data, err = s.Get(ctx, "user/blob")
+	require.NoError(t, err)
+	require.Equal(t, []byte("new data"), data)
+
+	require.NoError(t, s.Put(ctx, "user/empty", []byte{}))
+
+	data, err = s.Get(ctx, "user/empty")
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	require.NoError(t, s.Delete(ctx, "user/blob"))
+
+	_, err = s.Get(ctx, "user/blob")
+	require.ErrorIs(t, err, ErrBlobNotFound)
+
+	// deletion is idempotent
+	require.NoError(t, s.Delete(ctx, "user/blob"))
+}
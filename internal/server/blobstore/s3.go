@@ -0,0 +1,143 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var _ BlobStore = &S3Store{}
+
+type S3Config struct {
+	// Endpoint is store's url, e.g. https://s3.eu-central-1.amazonaws.com or http://127.0.0.1:9000 for MinIO
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store keeps blobs as objects of S3-compatible store's bucket, bucket is addressed by path,
+// so the store works with MinIO and other self-hosted stores without DNS for buckets
+type S3Store struct {
+	endpoint string
+	bucket   string
+	signer   *signer
+	client   *http.Client
+
+	now func() time.Time
+}
+
+func NewS3Store(config *S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse s3 endpoint=%s, err=%w", config.Endpoint, err)
+	}
+
+	if len(endpoint.Host) == 0 || len(config.Bucket) == 0 {
+		return nil, fmt.Errorf("s3 endpoint=%s and bucket=%s are required", config.Endpoint, config.Bucket)
+	}
+
+	region := config.Region
+	if len(region) == 0 {
+		// MinIO's default region
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint: strings.TrimSuffix(config.Endpoint, "/"),
+		bucket:   config.Bucket,
+		signer: &signer{
+			accessKey: config.AccessKey,
+			secretKey: config.SecretKey,
+			region:    region,
+			service:   "s3",
+		},
+		client: &http.Client{},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp, http.MethodPut, key)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("key=%s, err=%w", key, ErrBlobNotFound)
+	default:
+		return nil, s3Error(resp, http.MethodGet, key)
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(resp, http.MethodDelete, key)
+	}
+}
+
+func (s *S3Store) do(ctx context.Context, method string, key string, data []byte) (*http.Response, error) {
+	// key is escaped the same way as in the signature, url keeps the escaping as it's valid
+	objectURL := s.endpoint + "/" + uriEncode(s.bucket, true) + "/" + uriEncode(key, false)
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	s.signer.sign(req, hashHex(data), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s key=%s, err=%w", method, key, err)
+	}
+
+	return resp, nil
+}
+
+// s3ErrorResponse is xml body of S3's failed responses
+type s3ErrorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func s3Error(resp *http.Response, method string, key string) error {
+	body := &s3ErrorResponse{}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(body)
+
+	return fmt.Errorf("s3 %s key=%s, status=%d, code=%s, message=%s", method, key, resp.StatusCode, body.Code, body.Message)
+}
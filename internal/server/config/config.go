@@ -1,7 +1,96 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 type Config struct {
-	Hostport       string `yaml:"hostport"`
+	Hostport string `yaml:"hostport"`
+	// StorageDriver is postgres (default), sqlite or memory
+	StorageDriver string `yaml:"storageDriver"`
+	// DataSourceName is postgres' connection string or sqlite's db file
 	DataSourceName string `yaml:"dataSourceName"`
 	DisableLogging bool   `yaml:"disableLogging"`
+	EnableMetrics  bool   `yaml:"enableMetrics"`
+	// BlobStore keeps binary data's payloads of postgres and sqlite drivers
+	BlobStore BlobStore `yaml:"blobStore"`
+	// Quota limits items of every user
+	Quota Quota `yaml:"quota"`
+	// MaxRequestSize limits requests' bodies in bytes, defaultMaxRequestSize is used when it's zero
+	MaxRequestSize int64 `yaml:"maxRequestSize"`
+	// RateLimit throttles clients and locks them out after failed authentications
+	RateLimit RateLimit `yaml:"rateLimit"`
+	// ShutdownDelay is waited after readiness probe starts failing, so orchestrator stops routing requests
+	// before server is stopped, defaultShutdownDelay is used when it's zero and negative delay isn't waited
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+}
+
+const defaultMaxRequestSize = 32 << 20
+
+func (c *Config) RequestSizeLimit() int64 {
+	if c.MaxRequestSize <= 0 {
+		return defaultMaxRequestSize
+	}
+
+	return c.MaxRequestSize
+}
+
+const defaultShutdownDelay = time.Second * 5
+
+func (c *Config) DrainDelay() time.Duration {
+	if c.ShutdownDelay == 0 {
+		return defaultShutdownDelay
+	}
+
+	return max(c.ShutdownDelay, 0)
+}
+
+// RateLimit's zero limit is unlimited
+type RateLimit struct {
+	// RequestsPerSecond and Burst limit requests of every ip, Burst is rounded up RequestsPerSecond when it's zero
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	Burst             int     `yaml:"burst"`
+	// LoginsPerMinute limits login attempts of every login
+	LoginsPerMinute float64 `yaml:"loginsPerMinute"`
+	// LockoutThreshold is a number of failed authentications of ip or login before lockout,
+	// lockout lasts LockoutBase which is doubled by every next failure up to LockoutMax
+	LockoutThreshold int           `yaml:"lockoutThreshold"`
+	LockoutBase      time.Duration `yaml:"lockoutBase"`
+	LockoutMax       time.Duration `yaml:"lockoutMax"`
+}
+
+// Quota's zero limit is unlimited
+type Quota struct {
+	// MaxBytes limits total size of user's encrypted items
+	MaxBytes int64 `yaml:"maxBytes"`
+	// MaxItems limits number of user's items of all kinds
+	MaxItems int `yaml:"maxItems"`
+	// MaxItemSize limits size of the one item's encrypted data
+	MaxItemSize int64 `yaml:"maxItemSize"`
+}
+
+type BlobStore struct {
+	// Driver is fs or s3, payloads are kept in db when it's empty
+	Driver string `yaml:"driver"`
+	// Path is fs driver's directory
+	Path string `yaml:"path"`
+
+	// s3 driver's bucket which is addressed by path, e.g. http://127.0.0.1:9000/bucket for MinIO
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+}
+
+// String hides secret key from server's logs
+func (c BlobStore) String() string {
+	secretKey := ""
+	if len(c.SecretKey) != 0 {
+		secretKey = "***"
+	}
+
+	return fmt.Sprintf("{Driver:%s Path:%s Endpoint:%s Region:%s Bucket:%s AccessKey:%s SecretKey:%s}",
+		c.Driver, c.Path, c.Endpoint, c.Region, c.Bucket, c.AccessKey, secretKey)
 }
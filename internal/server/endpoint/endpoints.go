@@ -26,4 +26,46 @@ const (
 
 	// GET
 	WalletsEndpoint = "/api/data/wallets"
+
+	// PUT, GET, DELETE
+	// encrypted ssh private key
+	SSHKeyEndpoint = "/api/data/ssh-key"
+
+	// GET
+	SSHKeysEndpoint = "/api/data/ssh-keys"
+
+	// PUT - create service account with scoped token
+	// DELETE - revoke service account
+	ServiceAccountEndpoint = "/api/user/service-account"
+
+	// GET - user's service accounts
+	ServiceAccountsEndpoint = "/api/user/service-accounts"
+
+	// GET ?limit= - caller's audit trail, the newest event is the first
+	AuditEndpoint = "/api/user/audit"
+
+	// GET - user's consumption of storage and quota
+	UsageEndpoint = "/api/user/usage"
+
+	// GET - stream of user's changes as server-sent events
+	ChangesEndpoint = "/api/data/changes"
+
+	// GET - prometheus metrics, it's available without token when metrics are enabled
+	MetricsEndpoint = "/metrics"
+
+	// GET - liveness probe, it's available without token
+	HealthEndpoint = "/healthz"
+
+	// GET - readiness probe with db check and migration version, it's available without token
+	ReadyEndpoint = "/readyz"
+
+	// GET - OpenAPI document of all endpoints, it's available without token
+	OpenAPIEndpoint = "/api/openapi.json"
+
+	// POST - registrer new user or auth existing user by credentials in JSON body
+	TokensV2Endpoint = "/api/v2/tokens"
+
+	// GET /{kind} - page of items' metadata
+	// GET, PUT, DELETE /{kind}/{key} - item, revision of data is sent in ETag and If-Match headers
+	ItemsV2Endpoint = "/api/v2/items"
 )
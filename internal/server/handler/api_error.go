@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// codes of JSON error responses, clients handle errors by codes and show messages
+const (
+	ErrorCodeBadRequest         = "bad_request"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeForbidden          = "forbidden"
+	ErrorCodeNotFound           = "not_found"
+	ErrorCodeMethodNotAllowed   = "method_not_allowed"
+	ErrorCodeAlreadyExists      = "already_exists"
+	ErrorCodeRevisionConflict   = "revision_conflict"
+	ErrorCodeTooLarge           = "too_large"
+	ErrorCodeUnknownContent     = "unknown_content"
+	ErrorCodeTooManyRequests    = "too_many_requests"
+	ErrorCodeQuotaExceeded      = "quota_exceeded"
+	ErrorCodeInternal           = "internal"
+	ErrorCodePreconditionFailed = "precondition_failed"
+)
+
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorStatuses is ordered by priority, the first matched error sets the response,
+// messages don't contain errors' texts, so internal details aren't sent to clients
+var errorStatuses = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{ErrBadRevision, http.StatusConflict, ErrorCodeRevisionConflict, "item was changed by another client"},
+	{ErrDataNotFound, http.StatusNotFound, ErrorCodeNotFound, "item isn't found"},
+	{ErrDataAlreadyExist, http.StatusConflict, ErrorCodeAlreadyExists, "item already exists"},
+	{ErrForbidden, http.StatusForbidden, ErrorCodeForbidden, "token's scope doesn't allow operation"},
+	{ErrUnknownUser, http.StatusUnauthorized, ErrorCodeUnauthorized, "unknown token"},
+	{ErrBadPassword, http.StatusUnauthorized, ErrorCodeUnauthorized, "bad login or password"},
+	{ErrItemTooLarge, http.StatusRequestEntityTooLarge, ErrorCodeTooLarge, "item is too large"},
+	{ErrQuotaExceeded, http.StatusInsufficientStorage, ErrorCodeQuotaExceeded, "storage quota is exceeded"},
+	{ErrContentNotFound, http.StatusUnprocessableEntity, ErrorCodeUnknownContent, "content isn't stored, upload data"},
+	{ErrBadListQuery, http.StatusBadRequest, ErrorCodeBadRequest, "bad list parameters"},
+	{ErrIsNotValid, http.StatusBadRequest, ErrorCodeBadRequest, "request isn't valid"},
+}
+
+// errorStatus maps handler's error to the response's status, code and message
+func errorStatus(err error) (int, string, string) {
+	for _, s := range errorStatuses {
+		if errors.Is(err, s.err) {
+			return s.status, s.code, s.message
+		}
+	}
+
+	return http.StatusInternalServerError, ErrorCodeInternal, "internal server error"
+}
+
+// WriteError writes JSON error response, it's used by middlewares too
+func WriteError(w http.ResponseWriter, status int, code string, message string) {
+	data, err := json.Marshal(&ErrorResponse{Error: &APIError{Code: code, Message: message}})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(data); err != nil {
+		zlog.Logger().Infof("write error response err=%s", err)
+	}
+}
+
+// responseAPIError writes the error of the handled request
+func responseAPIError(w http.ResponseWriter, err error) {
+	status, code, message := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		zlog.Logger().Errorf("handle err=%s", err)
+	}
+
+	WriteError(w, status, code, message)
+}
+
+// responseAPIRequestError writes the error of request which isn't read by readRequest
+func responseAPIRequestError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrItemTooLarge) {
+		responseAPIError(w, err)
+
+		return
+	}
+
+	WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "request isn't valid")
+}
+
+func responseMethodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	WriteError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "method isn't allowed")
+}
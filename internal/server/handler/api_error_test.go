@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireAPIError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	require.Equal(t, status, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	resp := &ErrorResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, code, resp.Error.Code)
+	require.NotEmpty(t, resp.Error.Message)
+}
+
+func TestResponseAPIError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{err: fmt.Errorf("data=key, revision=1, err=%w", ErrBadRevision), status: http.StatusConflict, code: ErrorCodeRevisionConflict},
+		{err: ErrDataNotFound, status: http.StatusNotFound, code: ErrorCodeNotFound},
+		{err: ErrDataAlreadyExist, status: http.StatusConflict, code: ErrorCodeAlreadyExists},
+		{err: ErrForbidden, status: http.StatusForbidden, code: ErrorCodeForbidden},
+		{err: ErrBadPassword, status: http.StatusUnauthorized, code: ErrorCodeUnauthorized},
+		{err: ErrItemTooLarge, status: http.StatusRequestEntityTooLarge, code: ErrorCodeTooLarge},
+		{err: ErrQuotaExceeded, status: http.StatusInsufficientStorage, code: ErrorCodeQuotaExceeded},
+		{err: ErrContentNotFound, status: http.StatusUnprocessableEntity, code: ErrorCodeUnknownContent},
+		{err: ErrBadListQuery, status: http.StatusBadRequest, code: ErrorCodeBadRequest},
+		{err: errors.New("connection refused"), status: http.StatusInternalServerError, code: ErrorCodeInternal},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		responseAPIError(w, test.err)
+
+		requireAPIError(t, w, test.status, test.code)
+	}
+}
+
+func TestResponseAPIErrorHidesInternalError(t *testing.T) {
+	w := httptest.NewRecorder()
+	responseAPIError(w, errors.New("pq: password authentication failed"))
+
+	require.NotContains(t, w.Body.String(), "password")
+}
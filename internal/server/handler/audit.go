@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// audit actions
+const (
+	AuditActionLogin  = "login"
+	AuditActionCreate = "create"
+	AuditActionRead   = "read"
+	AuditActionList   = "list"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// audit results
+const (
+	AuditResultOK       = "ok"
+	AuditResultDenied   = "denied"
+	AuditResultNotFound = "not-found"
+	AuditResultConflict = "conflict"
+	AuditResultQuota    = "quota-exceeded"
+	AuditResultError    = "error"
+)
+
+// ItemKindServiceAccount is audited kind of service accounts' management
+const ItemKindServiceAccount = "service-account"
+
+// header with name of client's device
+const deviceHeader = "device"
+
+// AuditEvent is a durable record of user's access to items
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	// Login is set for login events, otherwise user is found by UserToken
+	Login     string `json:"-"`
+	UserToken string `json:"-"`
+	// ServiceAccount is a name of service account which made request with own token
+	ServiceAccount string `json:"service_account,omitempty"`
+	Device         string `json:"device,omitempty"`
+	Action         string `json:"action"`
+	Kind           string `json:"kind,omitempty"`
+	Key            string `json:"key,omitempty"`
+	IP             string `json:"ip"`
+	Result         string `json:"result"`
+}
+
+//go:generate mockgen -source=audit.go -destination=./mock_auditor.go -package=handler
+type Auditor interface {
+	Audit(ctx context.Context, event *AuditEvent) error
+}
+
+// audit records result of handled operation, request isn't failed when audit log isn't available
+func audit(auditor Auditor, r *http.Request, action string, kind string, key string, err error) {
+	event := makeAuditEvent(r, action, kind, key, err)
+	event.UserToken = getTokenFromRequestContext(r)
+
+	writeAuditEvent(auditor, r, event)
+}
+
+// AuditDenied records request which was denied before it was handled, user is found by the token,
+// so attempts with unknown token aren't attributed to any user
+func AuditDenied(auditor Auditor, r *http.Request, userToken string, action string, kind string, key string, err error) {
+	event := makeAuditEvent(r, action, kind, key, err)
+	event.UserToken = userToken
+
+	writeAuditEvent(auditor, r, event)
+}
+
+func auditLogin(auditor Auditor, r *http.Request, login string, err error) {
+	event := makeAuditEvent(r, AuditActionLogin, "", "", err)
+	event.Login = login
+
+	writeAuditEvent(auditor, r, event)
+}
+
+func makeAuditEvent(r *http.Request, action string, kind string, key string, err error) *AuditEvent {
+	serviceAccount, _ := r.Context().Value(AuthInfo("service-account")).(string)
+
+	return &AuditEvent{
+		Time:           time.Now().UTC(),
+		ServiceAccount: serviceAccount,
+		Device:         r.Header.Get(deviceHeader),
+		Action:         action,
+		Kind:           kind,
+		Key:            key,
+		IP:             remoteIP(r),
+		Result:         auditResult(err),
+	}
+}
+
+func writeAuditEvent(auditor Auditor, r *http.Request, event *AuditEvent) {
+	if err := auditor.Audit(r.Context(), event); err != nil {
+		zlog.Logger().Errorf("write audit event action=%s kind=%s key=%s, err=%s", event.Action, event.Kind, event.Key, err)
+	}
+}
+
+func auditResult(err error) string {
+	switch {
+	case err == nil:
+		return AuditResultOK
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrBadPassword), errors.Is(err, ErrUnknownUser):
+		return AuditResultDenied
+	case errors.Is(err, ErrDataNotFound):
+		return AuditResultNotFound
+	case errors.Is(err, ErrDataAlreadyExist), errors.Is(err, ErrBadRevision):
+		return AuditResultConflict
+	case errors.Is(err, ErrItemTooLarge), errors.Is(err, ErrQuotaExceeded):
+		return AuditResultQuota
+	default:
+		return AuditResultError
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// limits of returned audit events
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+//go:generate mockgen -source=audit_handler.go -destination=./mock_audit_handler.go -package=handler
+type AuditStorage interface {
+	// ListAuditEvents returns user's latest events, the newest is the first
+	ListAuditEvents(ctx context.Context, userToken string, limit int) ([]*AuditEvent, error)
+}
+
+// AuditHandler returns caller's own audit trail
+type AuditHandler struct {
+	storage AuditStorage
+}
+
+func NewAuditHandler(storage AuditStorage) *AuditHandler {
+	return &AuditHandler{storage: storage}
+}
+
+type AuditResponse struct {
+	Events []*AuditEvent `json:"events"`
+}
+
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	// audit trail contains all user's items
+	if ScopeFromContext(r.Context()) != nil {
+		responseAPIError(w, ErrForbidden)
+
+		return
+	}
+
+	limit := defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); len(value) != 0 {
+		var err error
+
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "limit must be a positive number")
+
+			return
+		}
+
+		limit = min(limit, maxAuditLimit)
+	}
+
+	events, err := h.storage.ListAuditEvents(r.Context(), getTokenFromRequestContext(r), limit)
+	if err != nil {
+		responseAPIError(w, err)
+
+		return
+	}
+
+	if err := writeResponse(w, AuditResponse{Events: events}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+}
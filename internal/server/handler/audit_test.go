@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuditor keeps written events in memory
+type testAuditor struct {
+	mu     sync.Mutex
+	events []*AuditEvent
+}
+
+func (a *testAuditor) Audit(_ context.Context, event *AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.events = append(a.events, event)
+
+	return nil
+}
+
+func TestAuditDataAccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockDataStorage(ctrl)
+	auditor := &testAuditor{}
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), auditor, noQuota)
+
+	data, err := json.Marshal(GetDataRequest{Key: "key"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.BinaryDataEndpoint, bytes.NewBuffer(data))
+	r.RemoteAddr = "10.0.0.1:5555"
+	r.Header.Set("device", "laptop")
+
+	ctx := context.WithValue(r.Context(), AuthInfo("token"), testToken)
+	ctx = context.WithValue(ctx, AuthInfo("service-account"), "ci")
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+
+	mockStorage.EXPECT().LoadData(gomock.Any(), testToken, "key").Return(nil, ErrDataNotFound)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	require.Len(t, auditor.events, 1)
+
+	event := auditor.events[0]
+	require.False(t, event.Time.IsZero())
+	require.Equal(t, &AuditEvent{
+		Time:           event.Time,
+		UserToken:      testToken,
+		ServiceAccount: "ci",
+		Device:         "laptop",
+		Action:         AuditActionRead,
+		Kind:           ItemKindData,
+		Key:            "key",
+		IP:             "10.0.0.1",
+		Result:         AuditResultNotFound,
+	}, event)
+}
+
+func TestAuditLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRegistrator := NewMockRegistrator(ctrl)
+	auditor := &testAuditor{}
+	h := NewRegistrationHandler(mockRegistrator, auditor)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.RegisterEndpoint, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("user"), &User{Login: "user", Password: "bad"}))
+	w := httptest.NewRecorder()
+
+	mockRegistrator.EXPECT().Register(gomock.Any(), gomock.Any()).Return(ErrBadPassword)
+
+	h.ServeHTTP(w, r)
+
+	require.Len(t, auditor.events, 1)
+	require.Equal(t, "user", auditor.events[0].Login)
+	require.Equal(t, AuditActionLogin, auditor.events[0].Action)
+	require.Equal(t, AuditResultDenied, auditor.events[0].Result)
+}
+
+func TestAuditResult(t *testing.T) {
+	require.Equal(t, AuditResultOK, auditResult(nil))
+	require.Equal(t, AuditResultDenied, auditResult(ErrForbidden))
+	require.Equal(t, AuditResultConflict, auditResult(ErrBadRevision))
+	require.Equal(t, AuditResultError, auditResult(errors.New("failed")))
+}
+
+func TestAuditHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockAuditStorage(ctrl)
+	h := NewAuditHandler(mockStorage)
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.AuditEndpoint+"?limit=5000", nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	events := []*AuditEvent{{Action: AuditActionCreate, Kind: ItemKindSecret, Key: "s", IP: "127.0.0.1", Result: AuditResultOK}}
+	mockStorage.EXPECT().ListAuditEvents(gomock.Any(), testToken, maxAuditLimit).Return(events, nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &AuditResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Len(t, resp.Events, 1)
+	require.Equal(t, events[0].Key, resp.Events[0].Key)
+}
+
+func TestAuditHandlerBadLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewAuditHandler(NewMockAuditStorage(ctrl))
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.AuditEndpoint+"?limit=-1", nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
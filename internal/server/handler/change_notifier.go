@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"sync"
+)
+
+// kinds of changed items
+const (
+	ItemKindData   = "data"
+	ItemKindCard   = "card"
+	ItemKindSecret = "secret"
+	ItemKindSSHKey = "ssh-key"
+)
+
+// operations with items
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// ChangeEvent describes user's item which was changed on the server
+type ChangeEvent struct {
+	Kind      string `json:"kind"`
+	Operation string `json:"operation"`
+	Key       string `json:"key"`
+	Data      string `json:"data,omitempty"`
+	Revision  uint64 `json:"revision,omitempty"`
+	Metainfo  string `json:"metainfo,omitempty"`
+}
+
+// ChangePublisher notifies user's devices about changes of user's items
+type ChangePublisher interface {
+	Publish(userToken string, event *ChangeEvent)
+}
+
+// subscriber's buffer size, slow subscriber is disconnected when buffer is full
+const subscriberBufferSize = 64
+
+// ChangeNotifier delivers change events to subscribers of the same user
+type ChangeNotifier struct {
+	sync.Mutex
+
+	subscribers map[string]map[chan *ChangeEvent]struct{}
+	closed      bool
+}
+
+func NewChangeNotifier() *ChangeNotifier {
+	return &ChangeNotifier{
+		subscribers: make(map[string]map[chan *ChangeEvent]struct{}),
+	}
+}
+
+// Subscribe returns channel with user's change events and function for unsubscribing.
+// Channel is closed when the subscriber is too slow or the notifier is closed.
+func (n *ChangeNotifier) Subscribe(userToken string) (<-chan *ChangeEvent, func()) {
+	n.Lock()
+	defer n.Unlock()
+
+	ch := make(chan *ChangeEvent, subscriberBufferSize)
+
+	if n.closed {
+		close(ch)
+
+		return ch, func() {}
+	}
+
+	userSubscribers, ok := n.subscribers[userToken]
+	if !ok {
+		userSubscribers = make(map[chan *ChangeEvent]struct{})
+		n.subscribers[userToken] = userSubscribers
+	}
+
+	userSubscribers[ch] = struct{}{}
+
+	return ch, func() {
+		n.Lock()
+		defer n.Unlock()
+
+		n.removeSubscriber(userToken, ch)
+	}
+}
+
+func (n *ChangeNotifier) Publish(userToken string, event *ChangeEvent) {
+	n.Lock()
+	defer n.Unlock()
+
+	for ch := range n.subscribers[userToken] {
+		select {
+		case ch <- event:
+		default:
+			// subscriber will resync after reconnection
+			n.removeSubscriber(userToken, ch)
+		}
+	}
+}
+
+// Close disconnects all subscribers
+func (n *ChangeNotifier) Close() {
+	n.Lock()
+	defer n.Unlock()
+
+	for token, userSubscribers := range n.subscribers {
+		for ch := range userSubscribers {
+			n.removeSubscriber(token, ch)
+		}
+	}
+
+	n.closed = true
+}
+
+func (n *ChangeNotifier) removeSubscriber(userToken string, ch chan *ChangeEvent) {
+	userSubscribers, ok := n.subscribers[userToken]
+	if !ok {
+		return
+	}
+
+	if _, ok := userSubscribers[ch]; !ok {
+		return
+	}
+
+	delete(userSubscribers, ch)
+	close(ch)
+
+	if len(userSubscribers) == 0 {
+		delete(n.subscribers, userToken)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeNotifierPublish(t *testing.T) {
+	n := NewChangeNotifier()
+
+	events, unsubscribe := n.Subscribe(testToken)
+	defer unsubscribe()
+
+	otherEvents, otherUnsubscribe := n.Subscribe("other-token")
+	defer otherUnsubscribe()
+
+	event := &ChangeEvent{Kind: ItemKindData, Operation: OperationCreate, Key: "key"}
+	n.Publish(testToken, event)
+
+	require.Equal(t, event, <-events)
+	require.Empty(t, otherEvents)
+}
+
+func TestChangeNotifierDisconnectsSlowSubscriber(t *testing.T) {
+	n := NewChangeNotifier()
+
+	events, unsubscribe := n.Subscribe(testToken)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBufferSize; i++ {
+		n.Publish(testToken, &ChangeEvent{Kind: ItemKindData, Operation: OperationDelete, Key: "key"})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+
+	require.Equal(t, subscriberBufferSize, received)
+}
+
+func TestChangeNotifierClose(t *testing.T) {
+	n := NewChangeNotifier()
+
+	events, unsubscribe := n.Subscribe(testToken)
+	n.Close()
+	unsubscribe()
+
+	_, ok := <-events
+	require.False(t, ok)
+
+	events, _ = n.Subscribe(testToken)
+
+	_, ok = <-events
+	require.False(t, ok)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// interval of comments which keep idle connection alive
+const changesKeepAliveInterval = time.Second * 30
+
+type ChangesSubscriber interface {
+	Subscribe(userToken string) (<-chan *ChangeEvent, func())
+}
+
+// ChangesHandler streams user's change events as server-sent events
+type ChangesHandler struct {
+	subscriber ChangesSubscriber
+}
+
+func NewChangesHandler(subscriber ChangesSubscriber) *ChangesHandler {
+	return &ChangesHandler{subscriber: subscriber}
+}
+
+func (h *ChangesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		zlog.Logger().Infof("unhandled method %s", r.Method)
+
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	if err := h.handleChanges(w, r); err != nil {
+		zlog.Logger().Infof("handle error: %s", err)
+	}
+}
+
+func (h *ChangesHandler) handleChanges(w http.ResponseWriter, r *http.Request) error {
+	events, unsubscribe := h.subscriber.Subscribe(getTokenFromRequestContext(r))
+	defer unsubscribe()
+
+	// service account receives changes of available items only
+	scope := ScopeFromContext(r.Context())
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// client knows that subscription is made after first message
+	if err := writeEventStreamMessage(w, rc, ": subscribed\n\n"); err != nil {
+		return err
+	}
+
+	keepAlive := time.NewTicker(changesKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if err := writeEventStreamMessage(w, rc, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if !scope.Allows(event.Kind, event.Key, false) {
+				continue
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("marshal err=%w", err)
+			}
+
+			if err := writeEventStreamMessage(w, rc, fmt.Sprintf("event: change\ndata: %s\n\n", data)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeEventStreamMessage(w http.ResponseWriter, rc *http.ResponseController, msg string) error {
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("write event err=%w", err)
+	}
+
+	if err := rc.Flush(); err != nil {
+		return fmt.Errorf("flush event err=%w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangesHandlerStreamsEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	notifier := NewChangeNotifier()
+	mockStorage := NewMockDataStorage(ctrl)
+
+	router := http.NewServeMux()
+	router.Handle(endpoint.BinaryDataEndpoint, withTestToken(NewDataHandler(mockStorage, notifier, &testAuditor{}, noQuota)))
+	router.Handle(endpoint.ChangesEndpoint, withTestToken(NewChangesHandler(notifier)))
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + endpoint.ChangesEndpoint)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	// subscription is made
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, ":"))
+
+	mockStorage.EXPECT().CreateData(gomock.Any(), testToken, gomock.Any()).Return(nil)
+
+	req := &SaveDataRequest{Key: "key", Data: "user_data", Metainfo: "meta"}
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	createResp, err := http.Post(server.URL+endpoint.BinaryDataEndpoint, "application/json", bytes.NewBuffer(data))
+	require.NoError(t, err)
+	require.NoError(t, createResp.Body.Close())
+	require.Equal(t, http.StatusOK, createResp.StatusCode)
+
+	var eventData string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		if strings.HasPrefix(line, "data: ") {
+			eventData = strings.TrimPrefix(strings.TrimSpace(line), "data: ")
+
+			break
+		}
+	}
+
+	event := &ChangeEvent{}
+	require.NoError(t, json.Unmarshal([]byte(eventData), event))
+	require.Equal(t, &ChangeEvent{
+		Kind: ItemKindData, Operation: OperationCreate, Key: "key", Data: "user_data", Revision: 1, Metainfo: "meta",
+	}, event)
+}
+
+func TestChangesHandlerFiltersEventsByScope(t *testing.T) {
+	notifier := NewChangeNotifier()
+	defer notifier.Close()
+
+	scope := &Scope{Kinds: []string{ItemKindSecret}, Prefixes: []string{"ci/"}}
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), AuthInfo("token"), testToken)
+		NewChangesHandler(notifier).ServeHTTP(w, r.WithContext(context.WithValue(ctx, AuthInfo("scope"), scope)))
+	})
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + endpoint.ChangesEndpoint)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, ":"))
+
+	notifier.Publish(testToken, &ChangeEvent{Kind: ItemKindData, Operation: OperationCreate, Key: "ci/file"})
+	notifier.Publish(testToken, &ChangeEvent{Kind: ItemKindSecret, Operation: OperationCreate, Key: "prod/password"})
+	notifier.Publish(testToken, &ChangeEvent{Kind: ItemKindSecret, Operation: OperationCreate, Key: "ci/password"})
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		if strings.HasPrefix(line, "data: ") {
+			event := &ChangeEvent{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event))
+
+			// the first received event is the only available one
+			require.Equal(t, "ci/password", event.Key)
+
+			break
+		}
+	}
+}
+
+func TestChangesHandlerBadMethod(t *testing.T) {
+	h := NewChangesHandler(NewChangeNotifier())
+
+	r := httptest.NewRequest(http.MethodPost, endpoint.ChangesEndpoint, nil)
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func withTestToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken)))
+	})
+}
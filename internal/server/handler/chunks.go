@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// chunked data is client's format of chunks followed by a sequence of base64 ciphertexts of file's chunks,
+// separator isn't used by base64, so data of the one ciphertext isn't taken for chunked data
+const chunkSeparator = "."
+
+// JoinChunks makes data of the record from encrypted chunks of the format
+func JoinChunks(format string, chunks []string) string {
+	return format + chunkSeparator + strings.Join(chunks, chunkSeparator)
+}
+
+// SplitChunks returns format and encrypted chunks of the record's data, data which isn't chunked isn't split
+func SplitChunks(data string) (string, []string, bool) {
+	format, chunks, ok := strings.Cut(data, chunkSeparator)
+	if !ok {
+		return "", nil, false
+	}
+
+	return format, strings.Split(chunks, chunkSeparator), true
+}
+
+// ChunkHash identifies encrypted chunk of the stored revision, so client doesn't upload the chunk again,
+// the hash doesn't tell anything new, because server keeps the chunk
+func ChunkHash(chunk string) string {
+	sum := sha256.Sum256([]byte(chunk))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Chunk is new encrypted chunk or hash of the chunk which is kept in the stored revision
+type Chunk struct {
+	Hash string `json:"hash,omitempty"`
+	Data string `json:"data,omitempty"`
+}
+
+// assembleChunks makes data of the new revision from new chunks and chunks of the stored revision,
+// new revision keeps format of the stored one
+func assembleChunks(stored *Record, chunks []Chunk) (string, error) {
+	format, storedChunks, ok := SplitChunks(stored.Data)
+	if !ok {
+		return "", fmt.Errorf("data=%s isn't chunked, err=%w", stored.Name, ErrContentNotFound)
+	}
+
+	byHash := make(map[string]string, len(storedChunks))
+	for _, chunk := range storedChunks {
+		byHash[ChunkHash(chunk)] = chunk
+	}
+
+	assembled := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		if len(chunk.Data) > 0 {
+			assembled = append(assembled, chunk.Data)
+
+			continue
+		}
+
+		data, ok := byHash[chunk.Hash]
+		if !ok {
+			return "", fmt.Errorf("data=%s, chunk=%d, hash=%s, err=%w", stored.Name, i, chunk.Hash, ErrContentNotFound)
+		}
+
+		assembled = append(assembled, data)
+	}
+
+	return JoinChunks(format, assembled), nil
+}
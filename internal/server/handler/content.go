@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrContentNotFound is returned when record has only content hash, but user doesn't have stored data with the hash
+var ErrContentNotFound = errors.New("content isn't stored")
+
+// content hash is a hex HMAC-SHA256 which is computed by client with user's key, so server can't check it
+const contentHashLen = sha256.Size * 2
+
+// serverContentHashPrefix separates hashes of records which were sent without content hash from clients' hashes
+const serverContentHashPrefix = "sha256:"
+
+// ContentHash returns key of record's data in user's deduplicated blobs,
+// hash of the data is used when client didn't send the content hash
+func ContentHash(r *Record) string {
+	if len(r.ContentHash) > 0 {
+		return r.ContentHash
+	}
+
+	sum := sha256.Sum256([]byte(r.Data))
+
+	return serverContentHashPrefix + hex.EncodeToString(sum[:])
+}
+
+func validContentHash(hash string) bool {
+	if len(hash) == 0 {
+		return true
+	}
+
+	if len(hash) != contentHashLen {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+
+	return err == nil
+}
@@ -3,7 +3,10 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/zlog"
 )
@@ -17,12 +20,16 @@ var (
 	ErrBadPassword      = errors.New("bad password")
 )
 
+// DataStorage deduplicates user's data by content hashes, record without data references stored data
+// with the same hash, CreateData and UpdateData set the record's data from the stored one
+//
 //go:generate mockgen -source=data_handler.go -destination=./mock_data_storage.go -package=handler
 type DataStorage interface {
 	CreateData(ctx context.Context, userToken string, r *Record) error
 	UpdateData(ctx context.Context, userToken string, r *Record) error
 	LoadData(ctx context.Context, userToken string, name string) (*Record, error)
-	ListData(ctx context.Context, userToken string) ([]*Record, error)
+	// ListData returns records without data
+	ListData(ctx context.Context, userToken string, q *ListQuery) ([]*Record, error)
 	DeleteData(ctx context.Context, userToken string, r *Record) error
 }
 
@@ -30,6 +37,11 @@ type Record struct {
 	Name     string
 	Data     string
 	Revision uint64
+	Metainfo string
+	// ContentHash is client's keyed hash of the data, record without data references user's stored data with the hash
+	ContentHash string
+	// Updated is a time of data's last change, it's set by storage
+	Updated time.Time
 }
 
 type User struct {
@@ -39,11 +51,14 @@ type User struct {
 }
 
 type DataHandler struct {
-	storage DataStorage
+	storage   DataStorage
+	publisher ChangePublisher
+	auditor   Auditor
+	quota     QuotaChecker
 }
 
-func NewDataHandler(storage DataStorage) *DataHandler {
-	return &DataHandler{storage: storage}
+func NewDataHandler(storage DataStorage, publisher ChangePublisher, auditor Auditor, quota QuotaChecker) *DataHandler {
+	return &DataHandler{storage: storage, publisher: publisher, auditor: auditor, quota: quota}
 }
 
 func (h *DataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -55,12 +70,14 @@ func (h *DataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		err = h.handleSaveData(w, r)
 	case http.MethodPut:
 		err = h.handleUpdateData(w, r)
+	case http.MethodPatch:
+		err = h.handlePatchData(w, r)
 	case http.MethodDelete:
 		err = h.handleDeleteData(w, r)
 	default:
 		zlog.Logger().Infof("unhandled method %s", r.Method)
 
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		responseMethodNotAllowed(w, "GET, POST, PUT, PATCH, DELETE")
 	}
 
 	if err != nil {
@@ -80,20 +97,23 @@ type GetDataResponse struct {
 	Key      string `json:"key"`
 	Data     string `json:"data"`
 	Revision uint64 `json:"revision"`
+	Metainfo string `json:"metainfo,omitempty"`
 }
 
 func (h *DataHandler) handleGetData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*GetDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 		return err
 	}
 
 	token := getTokenFromRequestContext(r)
 
 	data, err := h.storage.LoadData(r.Context(), token, req.Key)
+	audit(h.auditor, r, AuditActionRead, ItemKindData, req.Key, err)
+
 	if err != nil {
-		responsestorageError(w, err)
+		responseAPIError(w, err)
 		return err
 	}
 
@@ -101,6 +121,7 @@ func (h *DataHandler) handleGetData(w http.ResponseWriter, r *http.Request) erro
 		Key:      data.Name,
 		Data:     data.Data,
 		Revision: data.Revision,
+		Metainfo: data.Metainfo,
 	}
 
 	if err := writeResponse(w, response); err != nil {
@@ -111,69 +132,172 @@ func (h *DataHandler) handleGetData(w http.ResponseWriter, r *http.Request) erro
 }
 
 type SaveDataRequest struct {
-	Key  string `json:"key"`
-	Data string `json:"data"`
+	Key         string `json:"key"`
+	Data        string `json:"data,omitempty"`
+	Metainfo    string `json:"metainfo,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
+// Validate allows request without data when it has content hash, so client doesn't upload stored content again
 func (r *SaveDataRequest) Validate() bool {
-	return len(r.Key) > 0 && len(r.Data) > 0
+	return len(r.Key) > 0 && (len(r.Data) > 0 || len(r.ContentHash) > 0) && validContentHash(r.ContentHash)
 }
 
 func (h *DataHandler) handleSaveData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*SaveDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
 
 	token := getTokenFromRequestContext(r)
-	data := &Record{Name: req.Key, Data: req.Data}
+	data := &Record{Name: req.Key, Data: req.Data, Metainfo: req.Metainfo, ContentHash: req.ContentHash}
 
-	if err = h.storage.CreateData(r.Context(), token, data); err != nil {
+	err = h.quota.CheckQuota(r.Context(), token, len(data.Data), true)
+	if err == nil {
+		err = h.storage.CreateData(r.Context(), token, data)
+	}
+	audit(h.auditor, r, AuditActionCreate, ItemKindData, data.Name, err)
 
-		responsestorageError(w, err)
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{
+		Kind: ItemKindData, Operation: OperationCreate, Key: data.Name, Data: data.Data, Revision: 1, Metainfo: data.Metainfo,
+	})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
 }
 
 type UpdateDataRequest struct {
-	Key      string `json:"key"`
-	Data     string `json:"data"`
-	Revision uint64 `json:"revision"`
+	Key         string `json:"key"`
+	Data        string `json:"data,omitempty"`
+	Revision    uint64 `json:"revision"`
+	Metainfo    string `json:"metainfo,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
 func (r *UpdateDataRequest) Validate() bool {
-	return len(r.Key) > 0 && len(r.Data) > 0 && r.Revision != 0
+	return len(r.Key) > 0 && (len(r.Data) > 0 || len(r.ContentHash) > 0) && validContentHash(r.ContentHash) && r.Revision != 0
 }
 
 func (h *DataHandler) handleUpdateData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*UpdateDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	token := getTokenFromRequestContext(r)
+	data := &Record{Name: req.Key, Data: req.Data, Revision: req.Revision, Metainfo: req.Metainfo, ContentHash: req.ContentHash}
+
+	err = h.quota.CheckQuota(r.Context(), token, len(data.Data), false)
+	if err == nil {
+		err = h.storage.UpdateData(r.Context(), token, data)
+	}
+	audit(h.auditor, r, AuditActionUpdate, ItemKindData, data.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	h.publisher.Publish(token, &ChangeEvent{
+		Kind: ItemKindData, Operation: OperationUpdate, Key: data.Name, Data: data.Data, Revision: data.Revision + 1, Metainfo: data.Metainfo,
+	})
+
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+// PatchDataRequest updates chunked data by chunks which aren't kept in the stored revision
+type PatchDataRequest struct {
+	Key      string  `json:"key"`
+	Revision uint64  `json:"revision"`
+	Metainfo string  `json:"metainfo,omitempty"`
+	Chunks   []Chunk `json:"chunks"`
+}
+
+func (r *PatchDataRequest) Validate() bool {
+	if len(r.Key) == 0 || r.Revision == 0 || len(r.Chunks) == 0 {
+		return false
+	}
+
+	for _, chunk := range r.Chunks {
+		// chunk is either uploaded or referenced by hash
+		if (len(chunk.Data) > 0) == (len(chunk.Hash) > 0) {
+			return false
+		}
+
+		if !validContentHash(chunk.Hash) || strings.Contains(chunk.Data, chunkSeparator) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// handlePatchData assembles the new revision from the stored one, so client uploads only changed chunks of large data
+func (h *DataHandler) handlePatchData(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*PatchDataRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
 
 		return err
 	}
 
 	token := getTokenFromRequestContext(r)
-	data := &Record{Name: req.Key, Data: req.Data, Revision: req.Revision}
+	data := &Record{Name: req.Key, Revision: req.Revision, Metainfo: req.Metainfo}
 
-	if err := h.storage.UpdateData(r.Context(), token, data); err != nil {
-		responsestorageError(w, err)
+	err = h.patchData(r, token, data, req.Chunks)
+	audit(h.auditor, r, AuditActionUpdate, ItemKindData, data.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{
+		Kind: ItemKindData, Operation: OperationUpdate, Key: data.Name, Data: data.Data, Revision: data.Revision + 1, Metainfo: data.Metainfo,
+	})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
 }
 
+func (h *DataHandler) patchData(r *http.Request, token string, data *Record, chunks []Chunk) error {
+	stored, err := h.storage.LoadData(r.Context(), token, data.Name)
+	if err != nil {
+		return err
+	}
+
+	if stored.Revision != data.Revision {
+		return fmt.Errorf("data=%s, revision=%d, err=%w", data.Name, data.Revision, ErrBadRevision)
+	}
+
+	if data.Data, err = assembleChunks(stored, chunks); err != nil {
+		return err
+	}
+
+	if err = h.quota.CheckQuota(r.Context(), token, len(data.Data), false); err != nil {
+		return err
+	}
+
+	// storage checks the revision again, so concurrent update isn't overwritten
+	return h.storage.UpdateData(r.Context(), token, data)
+}
+
 type DeleteDataRequest struct {
 	Key string `json:"key"`
 }
@@ -185,7 +309,7 @@ func (r *DeleteDataRequest) Validate() bool {
 func (h *DataHandler) handleDeleteData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*DeleteDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
@@ -194,27 +318,18 @@ func (h *DataHandler) handleDeleteData(w http.ResponseWriter, r *http.Request) e
 
 	data := &Record{Name: req.Key}
 
-	if err := h.storage.DeleteData(r.Context(), token, data); err != nil {
-		responsestorageError(w, err)
+	err = h.storage.DeleteData(r.Context(), token, data)
+	audit(h.auditor, r, AuditActionDelete, ItemKindData, data.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindData, Operation: OperationDelete, Key: data.Name})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
 }
-
-func responsestorageError(w http.ResponseWriter, err error) {
-	zlog.Logger().Infof("storage err=%s", err)
-
-	if errors.Is(err, ErrBadRevision) {
-		w.WriteHeader(http.StatusConflict)
-	} else if errors.Is(err, ErrDataNotFound) {
-		w.WriteHeader(http.StatusNotFound)
-	} else if errors.Is(err, ErrDataAlreadyExist) {
-		w.WriteHeader(http.StatusConflict)
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-	}
-}
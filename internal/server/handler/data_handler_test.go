@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -20,7 +21,7 @@ func TestDataHandlerGetData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := GetDataRequest{Key: "key"}
 	data, err := json.Marshal(req)
@@ -50,7 +51,7 @@ func TestDataHandlerCreateData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &SaveDataRequest{Key: "key", Data: "user_data"}
 	data, err := json.Marshal(req)
@@ -72,7 +73,7 @@ func TestDataHandlerCreatedDataAlreadyExist(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &SaveDataRequest{Key: "key", Data: "user_data"}
 	data, err := json.Marshal(req)
@@ -86,7 +87,7 @@ func TestDataHandlerCreatedDataAlreadyExist(t *testing.T) {
 	mockStorage.EXPECT().CreateData(gomock.Any(), testToken, rec).Return(ErrDataAlreadyExist)
 
 	h.ServeHTTP(w, r)
-	require.Equal(t, http.StatusConflict, w.Code)
+	requireAPIError(t, w, http.StatusConflict, ErrorCodeAlreadyExists)
 }
 
 func TestDataHandlerUpdateData(t *testing.T) {
@@ -94,7 +95,7 @@ func TestDataHandlerUpdateData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &UpdateDataRequest{Key: "key", Data: "user_data", Revision: 1}
 	data, err := json.Marshal(req)
@@ -116,7 +117,7 @@ func TestDataHandlerDeleteData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &DeleteDataRequest{Key: "key"}
 	data, err := json.Marshal(req)
@@ -138,14 +139,15 @@ func TestDataHandlerBadMethod(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	r := httptest.NewRequest(http.MethodOptions, endpoint.BinaryDataEndpoint, nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
 	w := httptest.NewRecorder()
 
 	h.ServeHTTP(w, r)
-	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	requireAPIError(t, w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed)
+	require.Equal(t, "GET, POST, PUT, PATCH, DELETE", w.Header().Get("Allow"))
 }
 
 func TestDataHandlerBadRevision(t *testing.T) {
@@ -153,7 +155,7 @@ func TestDataHandlerBadRevision(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &UpdateDataRequest{Key: "key", Data: "user_data", Revision: 1}
 	data, err := json.Marshal(req)
@@ -175,7 +177,7 @@ func TestDataHandlerDataIsNotExist(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewDataHandler(mockStorage)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := &UpdateDataRequest{Key: "key", Data: "user_data", Revision: 1}
 	data, err := json.Marshal(req)
@@ -191,3 +193,114 @@ func TestDataHandlerDataIsNotExist(t *testing.T) {
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestDataHandlerCreateDataByContentHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockDataStorage(ctrl)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	hash := strings.Repeat("0f", 32)
+
+	for _, tc := range []struct {
+		name   string
+		req    *SaveDataRequest
+		err    error
+		status int
+	}{
+		{name: "stored content", req: &SaveDataRequest{Key: "key", ContentHash: hash}, status: http.StatusOK},
+		{name: "unknown content", req: &SaveDataRequest{Key: "key", ContentHash: hash}, err: ErrContentNotFound, status: http.StatusUnprocessableEntity},
+		{name: "bad hash", req: &SaveDataRequest{Key: "key", Data: "user_data", ContentHash: "hash"}, status: http.StatusBadRequest},
+		{name: "without data and hash", req: &SaveDataRequest{Key: "key"}, status: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.req)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, endpoint.BinaryDataEndpoint, bytes.NewBuffer(data))
+			r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+			w := httptest.NewRecorder()
+
+			if tc.status != http.StatusBadRequest {
+				rec := &Record{Name: tc.req.Key, ContentHash: tc.req.ContentHash}
+				mockStorage.EXPECT().CreateData(gomock.Any(), testToken, rec).Return(tc.err)
+			}
+
+			h.ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestDataHandlerPatchData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockDataStorage(ctrl)
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	stored := &Record{Name: "key", Data: JoinChunks("c2", []string{"AAA", "BBB"}), Revision: 2}
+
+	for _, tc := range []struct {
+		name    string
+		stored  *Record
+		req     *PatchDataRequest
+		updated string
+		status  int
+	}{
+		{
+			name:    "changed chunk",
+			req:     &PatchDataRequest{Key: "key", Revision: 2, Chunks: []Chunk{{Hash: ChunkHash("AAA")}, {Data: "CCC"}}},
+			updated: "c2.AAA.CCC",
+			status:  http.StatusOK,
+		},
+		{
+			name:   "not chunked data",
+			stored: &Record{Name: "key", Data: "AAA", Revision: 2},
+			req:    &PatchDataRequest{Key: "key", Revision: 2, Chunks: []Chunk{{Hash: ChunkHash("AAA")}}},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "unknown chunk",
+			req:    &PatchDataRequest{Key: "key", Revision: 2, Chunks: []Chunk{{Hash: ChunkHash("CCC")}}},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "old revision",
+			req:    &PatchDataRequest{Key: "key", Revision: 1, Chunks: []Chunk{{Data: "CCC"}}},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "chunk with separator",
+			req:    &PatchDataRequest{Key: "key", Revision: 2, Chunks: []Chunk{{Data: "CCC.DDD"}}},
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.req)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPatch, endpoint.BinaryDataEndpoint, bytes.NewBuffer(data))
+			r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+			w := httptest.NewRecorder()
+
+			if tc.status != http.StatusBadRequest {
+				loaded := stored
+				if tc.stored != nil {
+					loaded = tc.stored
+				}
+
+				mockStorage.EXPECT().LoadData(gomock.Any(), testToken, tc.req.Key).Return(loaded, nil)
+			}
+
+			if len(tc.updated) > 0 {
+				rec := &Record{Name: tc.req.Key, Data: tc.updated, Revision: tc.req.Revision}
+				mockStorage.EXPECT().UpdateData(gomock.Any(), testToken, rec).Return(nil)
+			}
+
+			h.ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -2,23 +2,35 @@ package handler
 
 import (
 	"net/http"
+	"time"
 )
 
 type ListDataHandler struct {
 	storage DataStorage
+	auditor Auditor
 }
 
-func NewListDataHandler(storage DataStorage) *ListDataHandler {
-	return &ListDataHandler{storage: storage}
+func NewListDataHandler(storage DataStorage, auditor Auditor) *ListDataHandler {
+	return &ListDataHandler{storage: storage, auditor: auditor}
+}
+
+// DataInfo is a metadata of user's data, the data is loaded by its name
+type DataInfo struct {
+	Name      string    `json:"name"`
+	Revision  uint64    `json:"revision"`
+	Metainfo  string    `json:"metainfo,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type ListDataResponse struct {
-	Data []*Record `json:"data"`
+	Data []*DataInfo `json:"data"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (h *ListDataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusBadRequest)
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "list is requested by GET")
 
 		return
 	}
@@ -27,16 +39,38 @@ func (h *ListDataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ListDataHandler) handleListData(w http.ResponseWriter, r *http.Request) {
+	q, pageSize, err := parseListQuery(r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+		return
+	}
+
 	token := getTokenFromRequestContext(r)
 
-	data, err := h.storage.ListData(r.Context(), token)
+	records, err := h.storage.ListData(r.Context(), token, q)
+	audit(h.auditor, r, AuditActionList, ItemKindData, q.Prefix, err)
+
 	if err != nil {
-		responsestorageError(w, err)
+		responseAPIError(w, err)
 		return
 	}
 
+	records, cursor := nextPage(q, records, pageSize, func(r *Record) (string, time.Time) {
+		return r.Name, r.Updated
+	})
+
 	response := ListDataResponse{
-		Data: data,
+		Data:       make([]*DataInfo, 0, len(records)),
+		NextCursor: cursor,
+	}
+
+	for _, r := range records {
+		response.Data = append(response.Data, &DataInfo{
+			Name:      r.Name,
+			Revision:  r.Revision,
+			Metainfo:  r.Metainfo,
+			UpdatedAt: r.Updated,
+		})
 	}
 
 	if err := writeResponse(w, response); err != nil {
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
@@ -17,17 +18,19 @@ func TestDataListHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStorage := NewMockDataStorage(ctrl)
-	h := NewListDataHandler(mockStorage)
+	h := NewListDataHandler(mockStorage, &testAuditor{})
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.BinariesDataEndpoint, nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
 	w := httptest.NewRecorder()
 
-	expectedData := []*Record{
-		{Name: "name1", Data: "data", Revision: 1},
-		{Name: "name2", Data: "data2", Revision: 2},
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	stored := []*Record{
+		{Name: "name1", Revision: 1, Metainfo: "meta", Updated: updated},
+		{Name: "name2", Revision: 2, Updated: updated},
 	}
-	mockStorage.EXPECT().ListData(gomock.Any(), testToken).Return(expectedData, nil)
+	q := &ListQuery{SortBy: SortByName, Limit: DefaultPageSize + 1}
+	mockStorage.EXPECT().ListData(gomock.Any(), testToken, q).Return(stored, nil)
 
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusOK, w.Code)
@@ -37,5 +40,67 @@ func TestDataListHandler(t *testing.T) {
 	err := json.Unmarshal(answer, resp)
 	require.NoError(t, err)
 
-	require.Equal(t, expectedData, resp.Data)
+	require.Equal(t, []*DataInfo{
+		{Name: "name1", Revision: 1, Metainfo: "meta", UpdatedAt: updated},
+		{Name: "name2", Revision: 2, UpdatedAt: updated},
+	}, resp.Data)
+	require.Empty(t, resp.NextCursor)
+}
+
+func TestDataListHandlerPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockDataStorage(ctrl)
+	h := NewListDataHandler(mockStorage, &testAuditor{})
+
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// storage returns the item of the next page, so handler knows that the page isn't last
+	first := &ListQuery{SortBy: SortByUpdated, Limit: 3}
+	mockStorage.EXPECT().ListData(gomock.Any(), testToken, first).Return([]*Record{
+		{Name: "a", Revision: 1, Updated: updated},
+		{Name: "b", Revision: 1, Updated: updated},
+		{Name: "c", Revision: 1, Updated: updated},
+	}, nil)
+
+	resp := listData(t, h, "?limit=2&sort=updated", http.StatusOK)
+	require.Len(t, resp.Data, 2)
+	require.Equal(t, "b", resp.Data[1].Name)
+	require.NotEmpty(t, resp.NextCursor)
+
+	second := &ListQuery{SortBy: SortByUpdated, Limit: 3, After: &ListCursor{Sort: SortByUpdated, Name: "b", Updated: updated}}
+	mockStorage.EXPECT().ListData(gomock.Any(), testToken, second).Return([]*Record{
+		{Name: "c", Revision: 1, Updated: updated},
+	}, nil)
+
+	resp = listData(t, h, "?limit=2&sort=updated&cursor="+resp.NextCursor, http.StatusOK)
+	require.Len(t, resp.Data, 1)
+	require.Empty(t, resp.NextCursor)
+
+	// cursor of other sort order
+	cursor := encodeListCursor(&ListCursor{Sort: SortByName, Name: "b"})
+	listData(t, h, "?sort=-name&cursor="+cursor, http.StatusBadRequest)
+
+	listData(t, h, "?limit=0", http.StatusBadRequest)
+	listData(t, h, "?limit=1001", http.StatusBadRequest)
+	listData(t, h, "?sort=size", http.StatusBadRequest)
+	listData(t, h, "?updated_since=yesterday", http.StatusBadRequest)
+	listData(t, h, "?cursor=bad", http.StatusBadRequest)
+}
+
+func listData(t *testing.T, h *ListDataHandler, params string, expectedCode int) *ListDataResponse {
+	r := httptest.NewRequest(http.MethodGet, endpoint.BinariesDataEndpoint+params, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, expectedCode, w.Code)
+
+	resp := &ListDataResponse{}
+	if expectedCode == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	}
+
+	return resp
 }
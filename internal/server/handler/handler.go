@@ -21,6 +21,11 @@ func readRequest[T Validaitable](r *http.Request) (T, error) {
 
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
+		maxBytesErr := &http.MaxBytesError{}
+		if errors.As(err, &maxBytesErr) {
+			return parsedRequest, fmt.Errorf("request is larger than %d bytes, err=%w", maxBytesErr.Limit, ErrItemTooLarge)
+		}
+
 		return parsedRequest, fmt.Errorf("read all err=%w", err)
 	}
 
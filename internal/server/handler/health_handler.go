@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// timeout of storage's check, orchestrator's probe must be answered faster than it's timed out
+const readinessCheckTimeout = time.Second * 2
+
+// health statuses
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusReady    = "ready"
+	HealthStatusNotReady = "not-ready"
+	HealthStatusStopping = "stopping"
+)
+
+// readiness checks, errors of failed checks are only logged, because they have db's details
+const (
+	HealthCheckDB         = "db"
+	HealthCheckMigrations = "migrations"
+)
+
+//go:generate mockgen -source=health_handler.go -destination=./mock_health_handler.go -package=handler
+type ReadinessChecker interface {
+	// Ping checks connection to db
+	Ping(ctx context.Context) error
+	// MigrationVersion returns version of db's schema
+	MigrationVersion(ctx context.Context) (int, error)
+}
+
+type HealthResponse struct {
+	Status           string `json:"status"`
+	MigrationVersion int    `json:"migration_version,omitempty"`
+	// FailedCheck is a name of the check which made server not ready
+	FailedCheck string `json:"failed_check,omitempty"`
+}
+
+// HealthHandler answers liveness probe, process is alive while it handles requests
+type HealthHandler struct{}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	writeHealthResponse(w, http.StatusOK, &HealthResponse{Status: HealthStatusOK})
+}
+
+// ReadinessHandler answers readiness probe, server is ready when db is available and it isn't stopping
+type ReadinessHandler struct {
+	checker  ReadinessChecker
+	stopping atomic.Bool
+}
+
+func NewReadinessHandler(checker ReadinessChecker) *ReadinessHandler {
+	return &ReadinessHandler{checker: checker}
+}
+
+// SetStopping makes server not ready, so orchestrator stops routing new requests while server is shutting down
+func (h *ReadinessHandler) SetStopping() {
+	h.stopping.Store(true)
+}
+
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	if h.stopping.Load() {
+		writeHealthResponse(w, http.StatusServiceUnavailable, &HealthResponse{Status: HealthStatusStopping})
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	if err := h.checker.Ping(ctx); err != nil {
+		zlog.Logger().Errorf("db ping err=%s", err)
+		writeHealthResponse(w, http.StatusServiceUnavailable, &HealthResponse{Status: HealthStatusNotReady, FailedCheck: HealthCheckDB})
+
+		return
+	}
+
+	version, err := h.checker.MigrationVersion(ctx)
+	if err != nil {
+		zlog.Logger().Errorf("get migration version err=%s", err)
+		writeHealthResponse(w, http.StatusServiceUnavailable, &HealthResponse{Status: HealthStatusNotReady, FailedCheck: HealthCheckMigrations})
+
+		return
+	}
+
+	writeHealthResponse(w, http.StatusOK, &HealthResponse{Status: HealthStatusReady, MigrationVersion: version})
+}
+
+func writeHealthResponse(w http.ResponseWriter, status int, response *HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zlog.Logger().Infof("write response err=%s", err)
+	}
+}
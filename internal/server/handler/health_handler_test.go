@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler(t *testing.T) {
+	h := NewHealthHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.HealthEndpoint, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, &HealthResponse{Status: HealthStatusOK}, decodeHealthResponse(t, w))
+}
+
+func TestReadinessHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChecker := NewMockReadinessChecker(ctrl)
+	h := NewReadinessHandler(mockChecker)
+
+	mockChecker.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockChecker.EXPECT().MigrationVersion(gomock.Any()).Return(3, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.ReadyEndpoint, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, &HealthResponse{Status: HealthStatusReady, MigrationVersion: 3}, decodeHealthResponse(t, w))
+
+	// db is unavailable
+	mockChecker.EXPECT().Ping(gomock.Any()).Return(errors.New("dial tcp db.internal:5432: connection refused"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.ReadyEndpoint, nil))
+
+	// db's details aren't exposed by unauthenticated probe
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NotContains(t, w.Body.String(), "db.internal")
+	require.Equal(t, &HealthResponse{Status: HealthStatusNotReady, FailedCheck: HealthCheckDB}, decodeHealthResponse(t, w))
+
+	// schema's version isn't available
+	mockChecker.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockChecker.EXPECT().MigrationVersion(gomock.Any()).Return(0, errors.New("no such table: schema_migrations"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.ReadyEndpoint, nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, &HealthResponse{Status: HealthStatusNotReady, FailedCheck: HealthCheckMigrations}, decodeHealthResponse(t, w))
+
+	// db isn't checked while server is stopping
+	h.SetStopping()
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.ReadyEndpoint, nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, &HealthResponse{Status: HealthStatusStopping}, decodeHealthResponse(t, w))
+}
+
+func decodeHealthResponse(t *testing.T, w *httptest.ResponseRecorder) *HealthResponse {
+	response := &HealthResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(response))
+
+	return response
+}
@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// ItemsStorage keeps all kinds of items which are served by v2 API
+type ItemsStorage interface {
+	DataStorage
+	WalletStorage
+	SecretStorage
+	SSHKeyStorage
+}
+
+// ItemsHandler serves v2 API of items, item is addressed by path /{kind}/{key} instead of JSON body,
+// data's revision is an ETag, so it's updated by PUT with If-Match and conflict is responded with 412
+type ItemsHandler struct {
+	storage   ItemsStorage
+	publisher ChangePublisher
+	auditor   Auditor
+	quota     QuotaChecker
+}
+
+func NewItemsHandler(storage ItemsStorage, publisher ChangePublisher, auditor Auditor, quota QuotaChecker) *ItemsHandler {
+	return &ItemsHandler{storage: storage, publisher: publisher, auditor: auditor, quota: quota}
+}
+
+// Item is a v2 representation of user's item, only data has revision and metainfo
+type Item struct {
+	Kind     string `json:"kind"`
+	Key      string `json:"key"`
+	Data     string `json:"data"`
+	Revision uint64 `json:"revision,omitempty"`
+	Metainfo string `json:"metainfo,omitempty"`
+}
+
+// ItemInfo is a metadata of item in v2 lists
+type ItemInfo struct {
+	Key       string    `json:"key"`
+	Revision  uint64    `json:"revision,omitempty"`
+	Metainfo  string    `json:"metainfo,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ItemListResponse struct {
+	Items []*ItemInfo `json:"items"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PutItemRequest creates or updates item, content hash and metainfo are allowed only for data
+type PutItemRequest struct {
+	Data        string `json:"data,omitempty"`
+	Metainfo    string `json:"metainfo,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+func (r *PutItemRequest) Validate() bool {
+	return (len(r.Data) > 0 || len(r.ContentHash) > 0) && validContentHash(r.ContentHash)
+}
+
+// methods of v2 items
+var itemsMethods = map[string]struct {
+	collection string
+	item       string
+}{
+	ItemKindData:   {collection: "GET", item: "GET, PUT, DELETE"},
+	ItemKindCard:   {collection: "GET", item: "GET, PUT, DELETE"},
+	ItemKindSecret: {collection: "GET", item: "GET, PUT, DELETE"},
+	ItemKindSSHKey: {collection: "GET", item: "GET, PUT, DELETE"},
+}
+
+// ParseItemPath splits the path of v2 items to item's kind and unescaped key,
+// key is empty for the kind's collection and it can contain slashes
+func ParseItemPath(escapedPath string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(escapedPath, endpoint.ItemsV2Endpoint+"/")
+	if !ok {
+		return "", "", false
+	}
+
+	kind, escapedKey, _ := strings.Cut(rest, "/")
+	if _, ok := itemsMethods[kind]; !ok {
+		return "", "", false
+	}
+
+	key, err := url.PathUnescape(escapedKey)
+	if err != nil {
+		return "", "", false
+	}
+
+	return kind, key, true
+}
+
+func (h *ItemsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kind, key, ok := ParseItemPath(r.URL.EscapedPath())
+	if !ok {
+		WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "unknown resource")
+
+		return
+	}
+
+	allowed := itemsMethods[kind].collection
+	if len(key) > 0 {
+		allowed = itemsMethods[kind].item
+	}
+
+	if !isMethodAllowed(allowed, r.Method) {
+		zlog.Logger().Infof("unhandled method %s", r.Method)
+		responseMethodNotAllowed(w, allowed)
+
+		return
+	}
+
+	var err error
+	switch {
+	case len(key) == 0:
+		err = h.handleList(w, r, kind)
+	case r.Method == http.MethodGet:
+		err = h.handleGet(w, r, kind, key)
+	case r.Method == http.MethodPut:
+		err = h.handlePut(w, r, kind, key)
+	case r.Method == http.MethodDelete:
+		err = h.handleDelete(w, r, kind, key)
+	}
+
+	if err != nil {
+		zlog.Logger().Infof("handle error: %s", err)
+	}
+}
+
+func isMethodAllowed(allowed string, method string) bool {
+	for _, m := range strings.Split(allowed, ", ") {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *ItemsHandler) handleList(w http.ResponseWriter, r *http.Request, kind string) error {
+	q, pageSize, err := parseListQuery(r)
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	items, err := h.listItems(r.Context(), getTokenFromRequestContext(r), kind, q)
+	audit(h.auditor, r, AuditActionList, kind, q.Prefix, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	items, cursor := nextPage(q, items, pageSize, func(i *ItemInfo) (string, time.Time) {
+		return i.Key, i.UpdatedAt
+	})
+
+	return writeResponse(w, &ItemListResponse{Items: items, NextCursor: cursor})
+}
+
+func (h *ItemsHandler) listItems(ctx context.Context, token string, kind string, q *ListQuery) ([]*ItemInfo, error) {
+	items := []*ItemInfo{}
+
+	switch kind {
+	case ItemKindData:
+		records, err := h.storage.ListData(ctx, token, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range records {
+			items = append(items, &ItemInfo{Key: r.Name, Revision: r.Revision, Metainfo: r.Metainfo, UpdatedAt: r.Updated})
+		}
+	case ItemKindCard:
+		cards, err := h.storage.ListCard(ctx, token, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range cards {
+			items = append(items, &ItemInfo{Key: c.Number, UpdatedAt: c.Updated})
+		}
+	case ItemKindSecret:
+		secrets, err := h.storage.ListSecret(ctx, token, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range secrets {
+			items = append(items, &ItemInfo{Key: s.Key, UpdatedAt: s.Updated})
+		}
+	case ItemKindSSHKey:
+		keys, err := h.storage.ListSSHKey(ctx, token, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, k := range keys {
+			items = append(items, &ItemInfo{Key: k.Name, UpdatedAt: k.Updated})
+		}
+	}
+
+	return items, nil
+}
+
+func (h *ItemsHandler) handleGet(w http.ResponseWriter, r *http.Request, kind string, key string) error {
+	item, err := h.getItem(r.Context(), getTokenFromRequestContext(r), kind, key)
+	audit(h.auditor, r, AuditActionRead, kind, key, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	if item.Revision != 0 {
+		etag := makeETag(item.Revision)
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+
+			return nil
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	return writeResponse(w, item)
+}
+
+func (h *ItemsHandler) getItem(ctx context.Context, token string, kind string, key string) (*Item, error) {
+	switch kind {
+	case ItemKindData:
+		record, err := h.storage.LoadData(ctx, token, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Item{Kind: kind, Key: record.Name, Data: record.Data, Revision: record.Revision, Metainfo: record.Metainfo}, nil
+	case ItemKindCard:
+		card, err := h.storage.GetCard(ctx, token, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Item{Kind: kind, Key: card.Number, Data: card.Data}, nil
+	case ItemKindSSHKey:
+		sshKey, err := h.storage.GetSSHKey(ctx, token, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Item{Kind: kind, Key: sshKey.Name, Data: sshKey.Data}, nil
+	default:
+		secret, err := h.storage.GetSecret(ctx, token, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Item{Kind: kind, Key: secret.Key, Data: secret.Value}, nil
+	}
+}
+
+// handlePut creates item when request hasn't If-Match header, otherwise it updates data of the matched revision
+func (h *ItemsHandler) handlePut(w http.ResponseWriter, r *http.Request, kind string, key string) error {
+	req, err := readRequest[*PutItemRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	if kind != ItemKindData && (len(req.Data) == 0 || len(req.Metainfo) > 0) {
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "item of the kind has only data")
+
+		return fmt.Errorf("kind=%s, err=%w", kind, ErrIsNotValid)
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if len(ifMatch) == 0 {
+		return h.createItem(w, r, kind, key, req)
+	}
+
+	revision, ok := parseETag(ifMatch)
+	if !ok || kind != ItemKindData {
+		WriteError(w, http.StatusPreconditionFailed, ErrorCodePreconditionFailed, "If-Match must be a revision of data")
+
+		return fmt.Errorf("if-match=%s of kind=%s, err=%w", ifMatch, kind, ErrIsNotValid)
+	}
+
+	return h.updateData(w, r, &Record{Name: key, Data: req.Data, Revision: revision, Metainfo: req.Metainfo, ContentHash: req.ContentHash})
+}
+
+func (h *ItemsHandler) createItem(w http.ResponseWriter, r *http.Request, kind string, key string, req *PutItemRequest) error {
+	ctx := r.Context()
+	token := getTokenFromRequestContext(r)
+
+	err := h.quota.CheckQuota(ctx, token, len(req.Data), true)
+	if err == nil {
+		switch kind {
+		case ItemKindData:
+			record := &Record{Name: key, Data: req.Data, Metainfo: req.Metainfo, ContentHash: req.ContentHash}
+			err = h.storage.CreateData(ctx, token, record)
+			// storage fills data of the record which is stored by content hash
+			req.Data = record.Data
+		case ItemKindCard:
+			err = h.storage.CreateCard(ctx, token, &CardData{Number: key, Data: req.Data})
+		case ItemKindSecret:
+			err = h.storage.CreateSecret(ctx, token, &Secret{Key: key, Value: req.Data})
+		case ItemKindSSHKey:
+			err = h.storage.CreateSSHKey(ctx, token, &SSHKey{Name: key, Data: req.Data})
+		}
+	}
+	audit(h.auditor, r, AuditActionCreate, kind, key, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	event := &ChangeEvent{Kind: kind, Operation: OperationCreate, Key: key, Data: req.Data}
+	if kind == ItemKindData {
+		event.Revision = 1
+		event.Metainfo = req.Metainfo
+		w.Header().Set("ETag", makeETag(1))
+	}
+
+	h.publisher.Publish(token, event)
+
+	w.Header().Set("Location", r.URL.EscapedPath())
+	w.WriteHeader(http.StatusCreated)
+
+	return nil
+}
+
+func (h *ItemsHandler) updateData(w http.ResponseWriter, r *http.Request, data *Record) error {
+	token := getTokenFromRequestContext(r)
+
+	err := h.quota.CheckQuota(r.Context(), token, len(data.Data), false)
+	if err == nil {
+		err = h.storage.UpdateData(r.Context(), token, data)
+	}
+	audit(h.auditor, r, AuditActionUpdate, ItemKindData, data.Name, err)
+
+	if err != nil {
+		status, code, message := errorStatus(err)
+		if status == http.StatusConflict {
+			// revision of If-Match isn't the stored one
+			status = http.StatusPreconditionFailed
+		}
+
+		WriteError(w, status, code, message)
+
+		return err
+	}
+
+	h.publisher.Publish(token, &ChangeEvent{
+		Kind: ItemKindData, Operation: OperationUpdate, Key: data.Name, Data: data.Data, Revision: data.Revision + 1, Metainfo: data.Metainfo,
+	})
+
+	w.Header().Set("ETag", makeETag(data.Revision+1))
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (h *ItemsHandler) handleDelete(w http.ResponseWriter, r *http.Request, kind string, key string) error {
+	ctx := r.Context()
+	token := getTokenFromRequestContext(r)
+
+	var err error
+	switch kind {
+	case ItemKindData:
+		err = h.storage.DeleteData(ctx, token, &Record{Name: key})
+	case ItemKindCard:
+		err = h.storage.DeleteCard(ctx, token, &CardData{Number: key})
+	case ItemKindSecret:
+		err = h.storage.DeleteSecret(ctx, token, key)
+	case ItemKindSSHKey:
+		err = h.storage.DeleteSSHKey(ctx, token, key)
+	}
+	audit(h.auditor, r, AuditActionDelete, kind, key, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	h.publisher.Publish(token, &ChangeEvent{Kind: kind, Operation: OperationDelete, Key: key})
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func makeETag(revision uint64) string {
+	return strconv.Quote(strconv.FormatUint(revision, 10))
+}
+
+// parseETag reads revision from strong ETag, weak ETags don't match data's revisions
+func parseETag(etag string) (uint64, bool) {
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil || !strings.HasPrefix(etag, `"`) {
+		return 0, false
+	}
+
+	revision, err := strconv.ParseUint(unquoted, 10, 64)
+	if err != nil || revision == 0 {
+		return 0, false
+	}
+
+	return revision, true
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+type testItemsStorage struct {
+	*MockDataStorage
+	*MockWalletStorage
+	*MockSecretStorage
+	*MockSSHKeyStorage
+}
+
+func newTestItemsStorage(ctrl *gomock.Controller) *testItemsStorage {
+	return &testItemsStorage{
+		MockDataStorage:   NewMockDataStorage(ctrl),
+		MockWalletStorage: NewMockWalletStorage(ctrl),
+		MockSecretStorage: NewMockSecretStorage(ctrl),
+		MockSSHKeyStorage: NewMockSSHKeyStorage(ctrl),
+	}
+}
+
+func serveItems(h http.Handler, method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, endpoint.ItemsV2Endpoint+path, strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestItemsHandlerGetData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockDataStorage.EXPECT().LoadData(gomock.Any(), testToken, "dir/key").
+		Return(&Record{Name: "dir/key", Data: "user data", Revision: 3, Metainfo: "meta"}, nil).Times(2)
+
+	w := serveItems(h, http.MethodGet, "/data/dir%2Fkey", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	item := &Item{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), item))
+	require.Equal(t, &Item{Kind: ItemKindData, Key: "dir/key", Data: "user data", Revision: 3, Metainfo: "meta"}, item)
+
+	w = serveItems(h, http.MethodGet, "/data/dir%2Fkey", "", map[string]string{"If-None-Match": `"3"`})
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Empty(t, w.Body.Bytes())
+}
+
+func TestItemsHandlerGetSSHKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockSSHKeyStorage.EXPECT().GetSSHKey(gomock.Any(), testToken, "id").Return(&SSHKey{Name: "id", Data: "key"}, nil)
+
+	w := serveItems(h, http.MethodGet, "/ssh-key/id", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("ETag"))
+
+	item := &Item{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), item))
+	require.Equal(t, &Item{Kind: ItemKindSSHKey, Key: "id", Data: "key"}, item)
+
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	storage.MockSSHKeyStorage.EXPECT().ListSSHKey(gomock.Any(), testToken, gomock.Any()).Return([]*SSHKey{{Name: "id", Updated: updated}}, nil)
+
+	w = serveItems(h, http.MethodGet, "/ssh-key", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &ItemListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, []*ItemInfo{{Key: "id", UpdatedAt: updated}}, resp.Items)
+}
+
+func TestItemsHandlerGetNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockSecretStorage.EXPECT().GetSecret(gomock.Any(), testToken, "key").Return(nil, ErrDataNotFound)
+
+	w := serveItems(h, http.MethodGet, "/secret/key", "", nil)
+	requireAPIError(t, w, http.StatusNotFound, ErrorCodeNotFound)
+}
+
+func TestItemsHandlerCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockDataStorage.EXPECT().CreateData(gomock.Any(), testToken, &Record{Name: "key", Data: "data", Metainfo: "meta"}).Return(nil)
+
+	w := serveItems(h, http.MethodPut, "/data/key", `{"data":"data","metainfo":"meta"}`, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, `"1"`, w.Header().Get("ETag"))
+	require.Equal(t, endpoint.ItemsV2Endpoint+"/data/key", w.Header().Get("Location"))
+
+	storage.MockWalletStorage.EXPECT().CreateCard(gomock.Any(), testToken, &CardData{Number: "1234", Data: "card"}).Return(nil)
+
+	w = serveItems(h, http.MethodPut, "/card/1234", `{"data":"card"}`, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Empty(t, w.Header().Get("ETag"))
+
+	storage.MockSSHKeyStorage.EXPECT().CreateSSHKey(gomock.Any(), testToken, &SSHKey{Name: "id", Data: "key"}).Return(ErrDataAlreadyExist)
+
+	w = serveItems(h, http.MethodPut, "/ssh-key/id", `{"data":"key"}`, nil)
+	requireAPIError(t, w, http.StatusConflict, ErrorCodeAlreadyExists)
+
+	w = serveItems(h, http.MethodPut, "/secret/key", `{"data":"value","metainfo":"meta"}`, nil)
+	requireAPIError(t, w, http.StatusBadRequest, ErrorCodeBadRequest)
+
+	w = serveItems(h, http.MethodPut, "/secret/key", `{}`, nil)
+	requireAPIError(t, w, http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestItemsHandlerUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockDataStorage.EXPECT().UpdateData(gomock.Any(), testToken, &Record{Name: "key", Data: "new", Revision: 2}).Return(nil)
+
+	w := serveItems(h, http.MethodPut, "/data/key", `{"data":"new"}`, map[string]string{"If-Match": `"2"`})
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	storage.MockDataStorage.EXPECT().UpdateData(gomock.Any(), testToken, &Record{Name: "key", Data: "new", Revision: 1}).Return(ErrBadRevision)
+
+	w = serveItems(h, http.MethodPut, "/data/key", `{"data":"new"}`, map[string]string{"If-Match": `"1"`})
+	requireAPIError(t, w, http.StatusPreconditionFailed, ErrorCodeRevisionConflict)
+
+	for _, etag := range []string{`W/"2"`, "2", `"0"`, `"abc"`} {
+		w = serveItems(h, http.MethodPut, "/data/key", `{"data":"new"}`, map[string]string{"If-Match": etag})
+		requireAPIError(t, w, http.StatusPreconditionFailed, ErrorCodePreconditionFailed)
+	}
+
+	// only data has revisions
+	w = serveItems(h, http.MethodPut, "/card/1234", `{"data":"card"}`, map[string]string{"If-Match": `"1"`})
+	requireAPIError(t, w, http.StatusPreconditionFailed, ErrorCodePreconditionFailed)
+}
+
+func TestItemsHandlerDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	storage.MockWalletStorage.EXPECT().DeleteCard(gomock.Any(), testToken, &CardData{Number: "1234"}).Return(nil)
+
+	w := serveItems(h, http.MethodDelete, "/card/1234", "", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	storage.MockSSHKeyStorage.EXPECT().DeleteSSHKey(gomock.Any(), testToken, "id").Return(ErrForbidden)
+
+	w = serveItems(h, http.MethodDelete, "/ssh-key/id", "", nil)
+	requireAPIError(t, w, http.StatusForbidden, ErrorCodeForbidden)
+}
+
+func TestItemsHandlerList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := newTestItemsStorage(ctrl)
+	h := NewItemsHandler(storage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	storage.MockWalletStorage.EXPECT().ListCard(gomock.Any(), testToken, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, q *ListQuery) ([]*CardData, error) {
+			require.Equal(t, 3, q.Limit)
+
+			return []*CardData{{Number: "1", Updated: updated}, {Number: "2", Updated: updated}, {Number: "3", Updated: updated}}, nil
+		})
+
+	w := serveItems(h, http.MethodGet, "/card?limit=2", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &ItemListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, []*ItemInfo{{Key: "1", UpdatedAt: updated}, {Key: "2", UpdatedAt: updated}}, resp.Items)
+	require.NotEmpty(t, resp.NextCursor)
+
+	w = serveItems(h, http.MethodGet, "/card?sort=size", "", nil)
+	requireAPIError(t, w, http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestItemsHandlerUnknownResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewItemsHandler(newTestItemsStorage(ctrl), NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	w := serveItems(h, http.MethodGet, "/service-account/key", "", nil)
+	requireAPIError(t, w, http.StatusNotFound, ErrorCodeNotFound)
+
+	w = serveItems(h, http.MethodPost, "/ssh-key/id", "", nil)
+	requireAPIError(t, w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed)
+	require.Equal(t, "GET, PUT, DELETE", w.Header().Get("Allow"))
+
+	w = serveItems(h, http.MethodPost, "/data", "", nil)
+	requireAPIError(t, w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed)
+	require.Equal(t, "GET", w.Header().Get("Allow"))
+}
+
+func TestTokenHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRegistrator := NewMockRegistrator(ctrl)
+	h := NewTokenHandler(mockRegistrator, &testAuditor{})
+
+	mockRegistrator.EXPECT().Register(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *User) error {
+		require.Equal(t, "user", user.Login)
+		require.NotEmpty(t, user.Token)
+
+		return nil
+	})
+
+	r := httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, strings.NewReader(`{"login":"user","password":"1234"}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &RegistrationResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.NotEmpty(t, resp.Token)
+
+	mockRegistrator.EXPECT().Register(gomock.Any(), gomock.Any()).Return(ErrBadPassword)
+
+	r = httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, strings.NewReader(`{"login":"user","password":"bad"}`))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	requireAPIError(t, w, http.StatusUnauthorized, ErrorCodeUnauthorized)
+
+	r = httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, strings.NewReader(`{"login":"user"}`))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	requireAPIError(t, w, http.StatusBadRequest, ErrorCodeBadRequest)
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadListQuery = errors.New("bad list query")
+
+// sort orders of list endpoints, "-" before the order is a descending sort
+const (
+	SortByName    = "name"
+	SortByUpdated = "updated"
+
+	descSortPrefix = "-"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// query's parameters of list endpoints
+const (
+	ListParamLimit        = "limit"
+	ListParamCursor       = "cursor"
+	ListParamPrefix       = "prefix"
+	ListParamUpdatedSince = "updated_since"
+	ListParamSort         = "sort"
+)
+
+// ListQuery selects the page of user's items, items are ordered by the sort and then by name
+type ListQuery struct {
+	// Prefix of items' names, all names are listed when it's empty
+	Prefix string
+	// UpdatedSince lists items which were changed at the time or later, zero time doesn't filter items
+	UpdatedSince time.Time
+	SortBy       string
+	Desc         bool
+	// After is a position of the last item of the previous page, it's nil for the first page
+	After *ListCursor
+	// Limit is the max number of returned items
+	Limit int
+}
+
+// ListCursor is a position of the item in the sort order, it's sent to clients as an opaque string
+type ListCursor struct {
+	Sort    string    `json:"s"`
+	Name    string    `json:"n"`
+	Updated time.Time `json:"u"`
+}
+
+// parseListQuery reads the list query from request's parameters, the query's limit is one more than page size,
+// so handler knows whether the next page exists
+func parseListQuery(r *http.Request) (*ListQuery, int, error) {
+	params := r.URL.Query()
+	q := &ListQuery{Prefix: params.Get(ListParamPrefix), SortBy: SortByName}
+
+	pageSize := DefaultPageSize
+	if limit := params.Get(ListParamLimit); len(limit) > 0 {
+		size, err := strconv.Atoi(limit)
+		if err != nil || size <= 0 || size > MaxPageSize {
+			return nil, 0, fmt.Errorf("limit=%s, err=%w", limit, ErrBadListQuery)
+		}
+
+		pageSize = size
+	}
+
+	q.Limit = pageSize + 1
+
+	if since := params.Get(ListParamUpdatedSince); len(since) > 0 {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, 0, fmt.Errorf("updated_since=%s, err=%w", since, ErrBadListQuery)
+		}
+
+		q.UpdatedSince = t.UTC()
+	}
+
+	sort := params.Get(ListParamSort)
+	if len(sort) > 0 {
+		q.Desc = strings.HasPrefix(sort, descSortPrefix)
+		q.SortBy = strings.TrimPrefix(sort, descSortPrefix)
+
+		if q.SortBy != SortByName && q.SortBy != SortByUpdated {
+			return nil, 0, fmt.Errorf("sort=%s, err=%w", sort, ErrBadListQuery)
+		}
+	}
+
+	if cursor := params.Get(ListParamCursor); len(cursor) > 0 {
+		after, err := decodeListCursor(cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// position in other order doesn't select the next page
+		if after.Sort != q.sort() {
+			return nil, 0, fmt.Errorf("cursor of sort=%s, err=%w", after.Sort, ErrBadListQuery)
+		}
+
+		q.After = after
+	}
+
+	return q, pageSize, nil
+}
+
+func (q *ListQuery) sort() string {
+	if q.Desc {
+		return descSortPrefix + q.SortBy
+	}
+
+	return q.SortBy
+}
+
+// nextPage cuts items to the page size and returns cursor of the next page, cursor is empty for the last page
+func nextPage[T any](q *ListQuery, items []T, pageSize int, position func(T) (string, time.Time)) ([]T, string) {
+	if len(items) <= pageSize {
+		return items, ""
+	}
+
+	items = items[:pageSize]
+	name, updated := position(items[pageSize-1])
+
+	return items, encodeListCursor(&ListCursor{Sort: q.sort(), Name: name, Updated: updated})
+}
+
+func encodeListCursor(c *ListCursor) string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(cursor string) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor=%s, err=%w", cursor, ErrBadListQuery)
+	}
+
+	c := &ListCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unmarshal cursor=%s, err=%w", cursor, ErrBadListQuery)
+	}
+
+	return c, nil
+}
+
+// MatchListQuery checks item's filters and its position after the query's cursor,
+// it's used by storages which filter items in memory
+func MatchListQuery(q *ListQuery, name string, updated time.Time) bool {
+	if !strings.HasPrefix(name, q.Prefix) {
+		return false
+	}
+
+	if !q.UpdatedSince.IsZero() && updated.Before(q.UpdatedSince) {
+		return false
+	}
+
+	return q.After == nil || CompareListPosition(q, name, updated, q.After.Name, q.After.Updated) > 0
+}
+
+// CompareListPosition compares positions of two items in the query's order
+func CompareListPosition(q *ListQuery, name string, updated time.Time, otherName string, otherUpdated time.Time) int {
+	result := 0
+	if q.SortBy == SortByUpdated {
+		result = updated.Compare(otherUpdated)
+	}
+
+	if result == 0 {
+		result = strings.Compare(name, otherName)
+	}
+
+	if q.Desc {
+		return -result
+	}
+
+	return result
+}
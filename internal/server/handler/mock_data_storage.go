@@ -63,18 +63,18 @@ func (mr *MockDataStorageMockRecorder) DeleteData(ctx, userToken, r interface{})
 }
 
 // ListData mocks base method.
-func (m *MockDataStorage) ListData(ctx context.Context, userToken string) ([]*Record, error) {
+func (m *MockDataStorage) ListData(ctx context.Context, userToken string, q *ListQuery) ([]*Record, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListData", ctx, userToken)
+	ret := m.ctrl.Call(m, "ListData", ctx, userToken, q)
 	ret0, _ := ret[0].([]*Record)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListData indicates an expected call of ListData.
-func (mr *MockDataStorageMockRecorder) ListData(ctx, userToken interface{}) *gomock.Call {
+func (mr *MockDataStorageMockRecorder) ListData(ctx, userToken, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockDataStorage)(nil).ListData), ctx, userToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockDataStorage)(nil).ListData), ctx, userToken, q)
 }
 
 // LoadData mocks base method.
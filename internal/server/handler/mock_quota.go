@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: quota.go
+
+// Package handler is a generated GoMock package.
+package handler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUsageStorage is a mock of UsageStorage interface.
+type MockUsageStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsageStorageMockRecorder
+}
+
+// MockUsageStorageMockRecorder is the mock recorder for MockUsageStorage.
+type MockUsageStorageMockRecorder struct {
+	mock *MockUsageStorage
+}
+
+// NewMockUsageStorage creates a new mock instance.
+func NewMockUsageStorage(ctrl *gomock.Controller) *MockUsageStorage {
+	mock := &MockUsageStorage{ctrl: ctrl}
+	mock.recorder = &MockUsageStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsageStorage) EXPECT() *MockUsageStorageMockRecorder {
+	return m.recorder
+}
+
+// Usage mocks base method.
+func (m *MockUsageStorage) Usage(ctx context.Context, userToken string) (*Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, userToken)
+	ret0, _ := ret[0].(*Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockUsageStorageMockRecorder) Usage(ctx, userToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockUsageStorage)(nil).Usage), ctx, userToken)
+}
+
+// MockQuotaChecker is a mock of QuotaChecker interface.
+type MockQuotaChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaCheckerMockRecorder
+}
+
+// MockQuotaCheckerMockRecorder is the mock recorder for MockQuotaChecker.
+type MockQuotaCheckerMockRecorder struct {
+	mock *MockQuotaChecker
+}
+
+// NewMockQuotaChecker creates a new mock instance.
+func NewMockQuotaChecker(ctrl *gomock.Controller) *MockQuotaChecker {
+	mock := &MockQuotaChecker{ctrl: ctrl}
+	mock.recorder = &MockQuotaCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaChecker) EXPECT() *MockQuotaCheckerMockRecorder {
+	return m.recorder
+}
+
+// CheckQuota mocks base method.
+func (m *MockQuotaChecker) CheckQuota(ctx context.Context, userToken string, itemSize int, newItem bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckQuota", ctx, userToken, itemSize, newItem)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckQuota indicates an expected call of CheckQuota.
+func (mr *MockQuotaCheckerMockRecorder) CheckQuota(ctx, userToken, itemSize, newItem interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckQuota", reflect.TypeOf((*MockQuotaChecker)(nil).CheckQuota), ctx, userToken, itemSize, newItem)
+}
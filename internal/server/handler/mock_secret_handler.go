@@ -78,16 +78,16 @@ func (mr *MockSecretStorageMockRecorder) GetSecret(ctx, userToken, secretKey int
 }
 
 // ListSecret mocks base method.
-func (m *MockSecretStorage) ListSecret(ctx context.Context, userToken string) ([]*Secret, error) {
+func (m *MockSecretStorage) ListSecret(ctx context.Context, userToken string, q *ListQuery) ([]*Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSecret", ctx, userToken)
+	ret := m.ctrl.Call(m, "ListSecret", ctx, userToken, q)
 	ret0, _ := ret[0].([]*Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListSecret indicates an expected call of ListSecret.
-func (mr *MockSecretStorageMockRecorder) ListSecret(ctx, userToken interface{}) *gomock.Call {
+func (mr *MockSecretStorageMockRecorder) ListSecret(ctx, userToken, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecret", reflect.TypeOf((*MockSecretStorage)(nil).ListSecret), ctx, userToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecret", reflect.TypeOf((*MockSecretStorage)(nil).ListSecret), ctx, userToken, q)
 }
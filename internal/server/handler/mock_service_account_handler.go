@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service_account_handler.go
+
+// Package handler is a generated GoMock package.
+package handler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockServiceAccountStorage is a mock of ServiceAccountStorage interface.
+type MockServiceAccountStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceAccountStorageMockRecorder
+}
+
+// MockServiceAccountStorageMockRecorder is the mock recorder for MockServiceAccountStorage.
+type MockServiceAccountStorageMockRecorder struct {
+	mock *MockServiceAccountStorage
+}
+
+// NewMockServiceAccountStorage creates a new mock instance.
+func NewMockServiceAccountStorage(ctrl *gomock.Controller) *MockServiceAccountStorage {
+	mock := &MockServiceAccountStorage{ctrl: ctrl}
+	mock.recorder = &MockServiceAccountStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceAccountStorage) EXPECT() *MockServiceAccountStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockServiceAccountStorage) CreateServiceAccount(ctx context.Context, userToken string, account *ServiceAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, userToken, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockServiceAccountStorageMockRecorder) CreateServiceAccount(ctx, userToken, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockServiceAccountStorage)(nil).CreateServiceAccount), ctx, userToken, account)
+}
+
+// DeleteServiceAccount mocks base method.
+func (m *MockServiceAccountStorage) DeleteServiceAccount(ctx context.Context, userToken, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceAccount", ctx, userToken, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceAccount indicates an expected call of DeleteServiceAccount.
+func (mr *MockServiceAccountStorageMockRecorder) DeleteServiceAccount(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceAccount", reflect.TypeOf((*MockServiceAccountStorage)(nil).DeleteServiceAccount), ctx, userToken, name)
+}
+
+// ListServiceAccounts mocks base method.
+func (m *MockServiceAccountStorage) ListServiceAccounts(ctx context.Context, userToken string) ([]*ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListServiceAccounts", ctx, userToken)
+	ret0, _ := ret[0].([]*ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListServiceAccounts indicates an expected call of ListServiceAccounts.
+func (mr *MockServiceAccountStorageMockRecorder) ListServiceAccounts(ctx, userToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServiceAccounts", reflect.TypeOf((*MockServiceAccountStorage)(nil).ListServiceAccounts), ctx, userToken)
+}
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ssh_key_handler.go
+
+// Package handler is a generated GoMock package.
+package handler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSSHKeyStorage is a mock of SSHKeyStorage interface.
+type MockSSHKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockSSHKeyStorageMockRecorder
+}
+
+// MockSSHKeyStorageMockRecorder is the mock recorder for MockSSHKeyStorage.
+type MockSSHKeyStorageMockRecorder struct {
+	mock *MockSSHKeyStorage
+}
+
+// NewMockSSHKeyStorage creates a new mock instance.
+func NewMockSSHKeyStorage(ctrl *gomock.Controller) *MockSSHKeyStorage {
+	mock := &MockSSHKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockSSHKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSSHKeyStorage) EXPECT() *MockSSHKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateSSHKey mocks base method.
+func (m *MockSSHKeyStorage) CreateSSHKey(ctx context.Context, userToken string, key *SSHKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSHKey", ctx, userToken, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSSHKey indicates an expected call of CreateSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) CreateSSHKey(ctx, userToken, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).CreateSSHKey), ctx, userToken, key)
+}
+
+// DeleteSSHKey mocks base method.
+func (m *MockSSHKeyStorage) DeleteSSHKey(ctx context.Context, userToken, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSSHKey indicates an expected call of DeleteSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) DeleteSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).DeleteSSHKey), ctx, userToken, name)
+}
+
+// GetSSHKey mocks base method.
+func (m *MockSSHKeyStorage) GetSSHKey(ctx context.Context, userToken, name string) (*SSHKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSSHKey", ctx, userToken, name)
+	ret0, _ := ret[0].(*SSHKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSSHKey indicates an expected call of GetSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) GetSSHKey(ctx, userToken, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).GetSSHKey), ctx, userToken, name)
+}
+
+// ListSSHKey mocks base method.
+func (m *MockSSHKeyStorage) ListSSHKey(ctx context.Context, userToken string, q *ListQuery) ([]*SSHKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSSHKey", ctx, userToken, q)
+	ret0, _ := ret[0].([]*SSHKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSSHKey indicates an expected call of ListSSHKey.
+func (mr *MockSSHKeyStorageMockRecorder) ListSSHKey(ctx, userToken, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSSHKey", reflect.TypeOf((*MockSSHKeyStorage)(nil).ListSSHKey), ctx, userToken, q)
+}
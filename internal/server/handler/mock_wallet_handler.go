@@ -62,17 +62,32 @@ func (mr *MockWalletStorageMockRecorder) DeleteCard(ctx, userToken, d interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCard", reflect.TypeOf((*MockWalletStorage)(nil).DeleteCard), ctx, userToken, d)
 }
 
+// GetCard mocks base method.
+func (m *MockWalletStorage) GetCard(ctx context.Context, userToken, number string) (*CardData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCard", ctx, userToken, number)
+	ret0, _ := ret[0].(*CardData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCard indicates an expected call of GetCard.
+func (mr *MockWalletStorageMockRecorder) GetCard(ctx, userToken, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCard", reflect.TypeOf((*MockWalletStorage)(nil).GetCard), ctx, userToken, number)
+}
+
 // ListCard mocks base method.
-func (m *MockWalletStorage) ListCard(ctx context.Context, userToken string) ([]*CardData, error) {
+func (m *MockWalletStorage) ListCard(ctx context.Context, userToken string, q *ListQuery) ([]*CardData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListCard", ctx, userToken)
+	ret := m.ctrl.Call(m, "ListCard", ctx, userToken, q)
 	ret0, _ := ret[0].([]*CardData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListCard indicates an expected call of ListCard.
-func (mr *MockWalletStorageMockRecorder) ListCard(ctx, userToken interface{}) *gomock.Call {
+func (mr *MockWalletStorageMockRecorder) ListCard(ctx, userToken, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCard", reflect.TypeOf((*MockWalletStorage)(nil).ListCard), ctx, userToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCard", reflect.TypeOf((*MockWalletStorage)(nil).ListCard), ctx, userToken, q)
 }
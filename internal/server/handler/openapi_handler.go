@@ -0,0 +1,35 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// OpenAPISpec describes all routes of the server, it's checked against the router by tests
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+// OpenAPIHandler serves the API's document, so clients in other languages are generated from it
+type OpenAPIHandler struct{}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(OpenAPISpec); err != nil {
+		zlog.Logger().Infof("write openapi document err=%s", err)
+	}
+}
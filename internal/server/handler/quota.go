@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrItemTooLarge  = errors.New("item is too large")
+	ErrQuotaExceeded = errors.New("user's quota is exceeded")
+)
+
+// Quota limits items of every user, zero limit is unlimited
+type Quota struct {
+	// MaxBytes limits total size of user's encrypted items
+	MaxBytes int64
+	// MaxItems limits number of user's items of all kinds
+	MaxItems int
+	// MaxItemSize limits size of the one item's encrypted data
+	MaxItemSize int64
+}
+
+// Usage is consumption of storage by user's items of all kinds
+type Usage struct {
+	Bytes int64
+	Items int
+}
+
+//go:generate mockgen -source=quota.go -destination=./mock_quota.go -package=handler
+type UsageStorage interface {
+	Usage(ctx context.Context, userToken string) (*Usage, error)
+}
+
+// QuotaChecker is called by items' handlers before writing to storage
+type QuotaChecker interface {
+	CheckQuota(ctx context.Context, userToken string, itemSize int, newItem bool) error
+}
+
+var _ QuotaChecker = &QuotaController{}
+
+// QuotaController compares user's usage with the quota, check isn't atomic with writing,
+// so concurrent writes of the one user can exceed the quota by their items
+type QuotaController struct {
+	quota   *Quota
+	storage UsageStorage
+}
+
+func NewQuotaController(quota *Quota, storage UsageStorage) *QuotaController {
+	return &QuotaController{quota: quota, storage: storage}
+}
+
+func (c *QuotaController) CheckQuota(ctx context.Context, userToken string, itemSize int, newItem bool) error {
+	if c.quota.MaxItemSize > 0 && int64(itemSize) > c.quota.MaxItemSize {
+		return fmt.Errorf("item's size=%d, max=%d, err=%w", itemSize, c.quota.MaxItemSize, ErrItemTooLarge)
+	}
+
+	if c.quota.MaxBytes <= 0 && c.quota.MaxItems <= 0 {
+		return nil
+	}
+
+	usage, err := c.storage.Usage(ctx, userToken)
+	if err != nil {
+		return err
+	}
+
+	if newItem && c.quota.MaxItems > 0 && usage.Items >= c.quota.MaxItems {
+		return fmt.Errorf("items=%d, max=%d, err=%w", usage.Items, c.quota.MaxItems, ErrQuotaExceeded)
+	}
+
+	// updated item's previous version is counted too, because it's removed after the new version is written
+	if c.quota.MaxBytes > 0 && usage.Bytes+int64(itemSize) > c.quota.MaxBytes {
+		return fmt.Errorf("bytes=%d, item's size=%d, max=%d, err=%w", usage.Bytes, itemSize, c.quota.MaxBytes, ErrQuotaExceeded)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+// noQuota doesn't limit users and doesn't read their usage
+var noQuota = NewQuotaController(&Quota{}, nil)
+
+func TestQuotaController(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	usageStorage := NewMockUsageStorage(ctrl)
+	usageStorage.EXPECT().Usage(gomock.Any(), testToken).Return(&Usage{Bytes: 90, Items: 2}, nil).AnyTimes()
+
+	c := NewQuotaController(&Quota{MaxBytes: 100, MaxItems: 3, MaxItemSize: 20}, usageStorage)
+
+	require.NoError(t, c.CheckQuota(ctx, testToken, 10, true))
+	require.ErrorIs(t, c.CheckQuota(ctx, testToken, 21, true), ErrItemTooLarge)
+	require.ErrorIs(t, c.CheckQuota(ctx, testToken, 11, false), ErrQuotaExceeded)
+
+	c = NewQuotaController(&Quota{MaxItems: 2}, usageStorage)
+
+	require.ErrorIs(t, c.CheckQuota(ctx, testToken, 1, true), ErrQuotaExceeded)
+	// update doesn't add an item
+	require.NoError(t, c.CheckQuota(ctx, testToken, 1, false))
+}
+
+func TestDataHandlerQuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockDataStorage(ctrl)
+	quota := NewMockQuotaChecker(ctrl)
+	auditor := &testAuditor{}
+	h := NewDataHandler(mockStorage, NewChangeNotifier(), auditor, quota)
+
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{err: ErrItemTooLarge, status: http.StatusRequestEntityTooLarge},
+		{err: ErrQuotaExceeded, status: http.StatusInsufficientStorage},
+	} {
+		req := &SaveDataRequest{Key: "key", Data: "user_data"}
+		data, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPost, endpoint.BinaryDataEndpoint, bytes.NewBuffer(data))
+		r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+		w := httptest.NewRecorder()
+
+		quota.EXPECT().CheckQuota(gomock.Any(), testToken, len(req.Data), true).Return(tc.err)
+
+		h.ServeHTTP(w, r)
+		require.Equal(t, tc.status, w.Code)
+	}
+
+	require.Len(t, auditor.events, 2)
+	require.Equal(t, AuditResultQuota, auditor.events[0].Result)
+}
+
+func TestReadRequestTooLarge(t *testing.T) {
+	h := NewSecretDataHandler(nil, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	data, err := json.Marshal(&SaveSecretRequest{Key: "key", Value: "large value"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.SecretEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	r.Body = http.MaxBytesReader(w, r.Body, 10)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestUsageHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usageStorage := NewMockUsageStorage(ctrl)
+	usageStorage.EXPECT().Usage(gomock.Any(), testToken).Return(&Usage{Bytes: 90, Items: 2}, nil)
+
+	h := NewUsageHandler(usageStorage, &Quota{MaxBytes: 100})
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.UsageEndpoint, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &UsageResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, &UsageResponse{Bytes: 90, Items: 2, MaxBytes: 100}, resp)
+}
@@ -16,11 +16,13 @@ type Registrator interface {
 
 type RegisterHandler struct {
 	registrator Registrator
+	auditor     Auditor
 }
 
-func NewRegistrationHandler(registrator Registrator) *RegisterHandler {
+func NewRegistrationHandler(registrator Registrator, auditor Auditor) *RegisterHandler {
 	return &RegisterHandler{
 		registrator: registrator,
+		auditor:     auditor,
 	}
 }
 
@@ -30,34 +32,90 @@ type RegistrationResponse struct {
 
 func (h *RegisterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		responseMethodNotAllowed(w, http.MethodPut)
 
 		return
 	}
 
 	user := getUserFromRequestContext(r)
 
+	if err := h.register(r, user); err != nil {
+		// failed login is counted by rate limiter
+		responseAPIError(w, err)
+
+		return
+	}
+
+	if err := writeResponse(w, &RegistrationResponse{Token: user.Token}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+}
+
+// register sets user's token and registers the new user or checks password of the existing one
+func (h *RegisterHandler) register(r *http.Request, user *User) error {
 	token, err := makeUserToken(user.Login, user.Password)
 	if err != nil {
 		zlog.Logger().Errorf("can't make user token")
-		w.WriteHeader(http.StatusInternalServerError)
 
-		return
+		return err
 	}
 
 	user.Token = token
 
-	if err := h.registrator.Register(r.Context(), user); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	err = h.registrator.Register(r.Context(), user)
+	auditLogin(h.auditor, r, user.Login, err)
+
+	return err
+}
+
+// TokenHandler is v2 registration, it reads credentials from JSON body instead of headers
+type TokenHandler struct {
+	RegisterHandler
+}
+
+func NewTokenHandler(registrator Registrator, auditor Auditor) *TokenHandler {
+	return &TokenHandler{RegisterHandler{registrator: registrator, auditor: auditor}}
+}
+
+type TokenRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func (r *TokenRequest) Validate() bool {
+	return len(r.Login) > 0 && len(r.Password) > 0
+}
+
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		responseMethodNotAllowed(w, http.MethodPost)
 
 		return
 	}
 
-	if err := writeResponse(w, &RegistrationResponse{Token: token}); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	req, err := readRequest[*TokenRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
 
 		return
 	}
+
+	user := &User{Login: req.Login, Password: req.Password}
+
+	if err := h.register(r, user); err != nil {
+		// failed login is counted by rate limiter
+		responseAPIError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := writeResponse(w, &RegistrationResponse{Token: user.Token}); err != nil {
+		zlog.Logger().Infof("write token response err=%s", err)
+	}
 }
 
 func makeUserToken(login, password string) (string, error) {
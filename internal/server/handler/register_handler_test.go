@@ -18,7 +18,7 @@ func TestRegisterHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRegistrator := NewMockRegistrator(ctrl)
-	h := NewRegistrationHandler(mockRegistrator)
+	h := NewRegistrationHandler(mockRegistrator, &testAuditor{})
 
 	r := httptest.NewRequest(http.MethodPut, endpoint.RegisterEndpoint, nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("user"), &User{Login: "user", Password: "1234"}))
@@ -39,7 +39,7 @@ func TestRegisterStorageError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRegistrator := NewMockRegistrator(ctrl)
-	h := NewRegistrationHandler(mockRegistrator)
+	h := NewRegistrationHandler(mockRegistrator, &testAuditor{})
 
 	r := httptest.NewRequest(http.MethodPut, endpoint.RegisterEndpoint, nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("user"), &User{Login: "user", Password: "1234"}))
@@ -50,3 +50,20 @@ func TestRegisterStorageError(t *testing.T) {
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestRegisterBadPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRegistrator := NewMockRegistrator(ctrl)
+	h := NewRegistrationHandler(mockRegistrator, &testAuditor{})
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.RegisterEndpoint, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("user"), &User{Login: "user", Password: "bad"}))
+	w := httptest.NewRecorder()
+
+	mockRegistrator.EXPECT().Register(gomock.Any(), gomock.Any()).Return(ErrBadPassword)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrForbidden = errors.New("token's scope doesn't allow operation")
+
+// Access is a result of token's authentication
+type Access struct {
+	// UserToken is a token of the user who owns items
+	UserToken string
+	// Scope of service account's token, it's nil for user's token
+	Scope *Scope
+	// ServiceAccount is a name of token's service account
+	ServiceAccount string
+}
+
+// Scope limits service account's access to user's items.
+// Nil scope is a full access of user's own token.
+type Scope struct {
+	// Kinds of available items, all kinds are available when it's empty
+	Kinds []string `json:"kinds,omitempty"`
+	// Prefixes of available items' names, all names are available when it's empty
+	Prefixes []string `json:"prefixes,omitempty"`
+	ReadOnly bool     `json:"read_only"`
+}
+
+var itemKinds = []string{ItemKindData, ItemKindCard, ItemKindSecret, ItemKindSSHKey}
+
+func (s *Scope) Validate() bool {
+	for _, kind := range s.Kinds {
+		if !slices.Contains(itemKinds, kind) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AllowsKind checks kind and permission, it's used when item's name isn't known yet
+func (s *Scope) AllowsKind(kind string, write bool) bool {
+	if s == nil {
+		return true
+	}
+
+	if write && s.ReadOnly {
+		return false
+	}
+
+	return len(s.Kinds) == 0 || slices.Contains(s.Kinds, kind)
+}
+
+func (s *Scope) Allows(kind string, name string, write bool) bool {
+	if !s.AllowsKind(kind, write) {
+		return false
+	}
+
+	if s == nil || len(s.Prefixes) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(s.Prefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+func (s *Scope) Check(kind string, name string, write bool) error {
+	if !s.Allows(kind, name, write) {
+		return fmt.Errorf("%s=%s, err=%w", kind, name, ErrForbidden)
+	}
+
+	return nil
+}
+
+// ScopeFromContext returns scope of request's token, nil is returned for user's token
+func ScopeFromContext(ctx context.Context) *Scope {
+	scope, _ := ctx.Value(AuthInfo("scope")).(*Scope)
+
+	return scope
+}
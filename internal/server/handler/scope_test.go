@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeAllows(t *testing.T) {
+	var full *Scope
+
+	require.True(t, full.Allows(ItemKindData, "any", true))
+
+	scope := &Scope{Kinds: []string{ItemKindSecret}, Prefixes: []string{"ci/", "deploy/"}, ReadOnly: true}
+
+	require.True(t, scope.Allows(ItemKindSecret, "ci/password", false))
+	require.True(t, scope.Allows(ItemKindSecret, "deploy/key", false))
+	require.False(t, scope.Allows(ItemKindSecret, "ci/password", true))
+	require.False(t, scope.Allows(ItemKindSecret, "prod/password", false))
+	require.False(t, scope.Allows(ItemKindData, "ci/file", false))
+
+	require.True(t, scope.AllowsKind(ItemKindSecret, false))
+	require.False(t, scope.AllowsKind(ItemKindSecret, true))
+
+	require.ErrorIs(t, scope.Check(ItemKindSecret, "prod/password", false), ErrForbidden)
+
+	// empty kinds and prefixes allow everything
+	readWrite := &Scope{}
+	require.True(t, readWrite.Allows(ItemKindCard, "1234", true))
+}
+
+func TestScopeValidate(t *testing.T) {
+	require.True(t, (&Scope{Kinds: []string{ItemKindData, ItemKindSSHKey}}).Validate())
+	require.False(t, (&Scope{Kinds: []string{"unknown"}}).Validate())
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/zlog"
 )
@@ -12,21 +13,30 @@ type SecretStorage interface {
 	CreateSecret(ctx context.Context, userToken string, secret *Secret) error
 	GetSecret(ctx context.Context, userToken string, secretKey string) (*Secret, error)
 	DeleteSecret(ctx context.Context, userToken string, secretKey string) error
-	ListSecret(ctx context.Context, userToken string) ([]*Secret, error)
+	// ListSecret returns secrets without values
+	ListSecret(ctx context.Context, userToken string, q *ListQuery) ([]*Secret, error)
 }
 
 type Secret struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
+	// Updated is a time of secret's last change, it's set by storage
+	Updated time.Time `json:"-"`
 }
 
 type SecretDataHandler struct {
-	storage SecretStorage
+	storage   SecretStorage
+	publisher ChangePublisher
+	auditor   Auditor
+	quota     QuotaChecker
 }
 
-func NewSecretDataHandler(secretStorage SecretStorage) *SecretDataHandler {
+func NewSecretDataHandler(secretStorage SecretStorage, publisher ChangePublisher, auditor Auditor, quota QuotaChecker) *SecretDataHandler {
 	return &SecretDataHandler{
-		storage: secretStorage,
+		storage:   secretStorage,
+		publisher: publisher,
+		auditor:   auditor,
+		quota:     quota,
 	}
 }
 
@@ -42,7 +52,7 @@ func (h *SecretDataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	default:
 		zlog.Logger().Infof("unhandled method %s", r.Method)
 
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		responseMethodNotAllowed(w, "GET, PUT, DELETE")
 	}
 
 	if err != nil {
@@ -66,15 +76,17 @@ type GetSecretResponse struct {
 func (h *SecretDataHandler) handleGetData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*GetSecretDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 		return err
 	}
 
 	token := getTokenFromRequestContext(r)
 
 	secret, err := h.storage.GetSecret(r.Context(), token, req.Key)
+	audit(h.auditor, r, AuditActionRead, ItemKindSecret, req.Key, err)
+
 	if err != nil {
-		responsestorageError(w, err)
+		responseAPIError(w, err)
 		return err
 	}
 
@@ -102,7 +114,7 @@ func (r *SaveSecretRequest) Validate() bool {
 func (h *SecretDataHandler) handleSaveSecret(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*SaveSecretRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
@@ -111,13 +123,20 @@ func (h *SecretDataHandler) handleSaveSecret(w http.ResponseWriter, r *http.Requ
 
 	secret := &Secret{Key: req.Key, Value: req.Value}
 
-	if err = h.storage.CreateSecret(r.Context(), token, secret); err != nil {
+	err = h.quota.CheckQuota(r.Context(), token, len(secret.Value), true)
+	if err == nil {
+		err = h.storage.CreateSecret(r.Context(), token, secret)
+	}
+	audit(h.auditor, r, AuditActionCreate, ItemKindSecret, secret.Key, err)
 
-		responsestorageError(w, err)
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindSecret, Operation: OperationCreate, Key: secret.Key, Data: secret.Value})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
@@ -134,19 +153,24 @@ func (r *DeleteSecretRequest) Validate() bool {
 func (h *SecretDataHandler) handleDeleteSecret(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*DeleteSecretRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
 
 	token := getTokenFromRequestContext(r)
 
-	if err := h.storage.DeleteSecret(r.Context(), token, req.Key); err != nil {
-		responsestorageError(w, err)
+	err = h.storage.DeleteSecret(r.Context(), token, req.Key)
+	audit(h.auditor, r, AuditActionDelete, ItemKindSecret, req.Key, err)
+
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindSecret, Operation: OperationDelete, Key: req.Key})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
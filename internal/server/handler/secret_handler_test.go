@@ -18,7 +18,7 @@ func TestSecretHandlerCreate(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := SaveSecretRequest{Key: "key", Value: "value"}
 	data, err := json.Marshal(req)
@@ -40,7 +40,7 @@ func TestSecretHandlerCreateBadRequest(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	// request without body
 	r := httptest.NewRequest(http.MethodPut, endpoint.WalletEndpoint, nil)
@@ -56,7 +56,7 @@ func TestSecretHandlerGet(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := SaveSecretRequest{Key: "key", Value: "value"}
 	data, err := json.Marshal(req)
@@ -86,7 +86,7 @@ func TestSecretHandlerGetBadRequest(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	// request without body
 	r := httptest.NewRequest(http.MethodGet, endpoint.WalletEndpoint, nil)
@@ -102,7 +102,7 @@ func TestSecretHandlerDelete(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := DeleteSecretRequest{Key: "key"}
 	data, err := json.Marshal(req)
@@ -123,7 +123,7 @@ func TestSecretHandlerDeleteBadRequest(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretDataHandler(mockSecretStorage)
+	h := NewSecretDataHandler(mockSecretStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	// request without body
 	r := httptest.NewRequest(http.MethodDelete, endpoint.WalletEndpoint, nil)
@@ -2,23 +2,33 @@ package handler
 
 import (
 	"net/http"
+	"time"
 )
 
 type SecretListHandler struct {
 	storage SecretStorage
+	auditor Auditor
 }
 
-func NewSecretListHandler(storage SecretStorage) *SecretListHandler {
-	return &SecretListHandler{storage: storage}
+func NewSecretListHandler(storage SecretStorage, auditor Auditor) *SecretListHandler {
+	return &SecretListHandler{storage: storage, auditor: auditor}
+}
+
+// SecretInfo is a metadata of user's secret, the secret's value is got by its key
+type SecretInfo struct {
+	Key       string    `json:"key"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type SecretListResponse struct {
-	Data []*Secret `json:"data"`
+	Data []*SecretInfo `json:"data"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (h *SecretListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusBadRequest)
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "list is requested by GET")
 
 		return
 	}
@@ -27,16 +37,33 @@ func (h *SecretListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *SecretListHandler) handleListData(w http.ResponseWriter, r *http.Request) {
+	q, pageSize, err := parseListQuery(r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+		return
+	}
+
 	token := getTokenFromRequestContext(r)
 
-	data, err := h.storage.ListSecret(r.Context(), token)
+	secrets, err := h.storage.ListSecret(r.Context(), token, q)
+	audit(h.auditor, r, AuditActionList, ItemKindSecret, q.Prefix, err)
+
 	if err != nil {
-		responsestorageError(w, err)
+		responseAPIError(w, err)
 		return
 	}
 
+	secrets, cursor := nextPage(q, secrets, pageSize, func(s *Secret) (string, time.Time) {
+		return s.Key, s.Updated
+	})
+
 	response := SecretListResponse{
-		Data: data,
+		Data:       make([]*SecretInfo, 0, len(secrets)),
+		NextCursor: cursor,
+	}
+
+	for _, s := range secrets {
+		response.Data = append(response.Data, &SecretInfo{Key: s.Key, UpdatedAt: s.Updated})
 	}
 
 	if err := writeResponse(w, response); err != nil {
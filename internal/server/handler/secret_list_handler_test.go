@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
@@ -17,17 +18,20 @@ func TestListSecretHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretListHandler(mockSecretStorage)
+	h := NewSecretListHandler(mockSecretStorage, &testAuditor{})
 
-	r := httptest.NewRequest(http.MethodGet, endpoint.WalletsEndpoint, nil)
+	r := httptest.NewRequest(http.MethodGet, endpoint.SecretsEndpoint+"?sort=-updated&updated_since=2024-05-01T12:00:00%2B02:00", nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
 	w := httptest.NewRecorder()
 
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	q := &ListQuery{UpdatedSince: updated, SortBy: SortByUpdated, Desc: true, Limit: DefaultPageSize + 1}
+
 	expected := []*Secret{
-		{Key: "key_1", Value: "val_2"},
-		{Key: "key_2", Value: "val_2"},
+		{Key: "key_1", Updated: updated.Add(time.Minute)},
+		{Key: "key_2", Updated: updated},
 	}
-	mockSecretStorage.EXPECT().ListSecret(gomock.Any(), testToken).Return(expected, nil)
+	mockSecretStorage.EXPECT().ListSecret(gomock.Any(), testToken, q).Return(expected, nil)
 
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusOK, w.Code)
@@ -37,7 +41,10 @@ func TestListSecretHandler(t *testing.T) {
 	err := json.Unmarshal(answer, resp)
 	require.NoError(t, err)
 
-	require.Equal(t, expected, resp.Data)
+	require.Equal(t, []*SecretInfo{
+		{Key: "key_1", UpdatedAt: updated.Add(time.Minute)},
+		{Key: "key_2", UpdatedAt: updated},
+	}, resp.Data)
 }
 
 func TestListSecretHandlerBadMethod(t *testing.T) {
@@ -45,7 +52,7 @@ func TestListSecretHandlerBadMethod(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSecretStorage := NewMockSecretStorage(ctrl)
-	h := NewSecretListHandler(mockSecretStorage)
+	h := NewSecretListHandler(mockSecretStorage, &testAuditor{})
 
 	r := httptest.NewRequest(http.MethodDelete, endpoint.WalletsEndpoint, nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// ServiceAccount is owned by user and has token with limited access to user's items
+type ServiceAccount struct {
+	Name string `json:"name"`
+	// TokenHash is stored instead of token, token is shown once on creation
+	TokenHash string    `json:"-"`
+	Scope     *Scope    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//go:generate mockgen -source=service_account_handler.go -destination=./mock_service_account_handler.go -package=handler
+type ServiceAccountStorage interface {
+	CreateServiceAccount(ctx context.Context, userToken string, account *ServiceAccount) error
+	ListServiceAccounts(ctx context.Context, userToken string) ([]*ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, userToken string, name string) error
+}
+
+type ServiceAccountHandler struct {
+	storage ServiceAccountStorage
+	auditor Auditor
+}
+
+func NewServiceAccountHandler(storage ServiceAccountStorage, auditor Auditor) *ServiceAccountHandler {
+	return &ServiceAccountHandler{storage: storage, auditor: auditor}
+}
+
+func (h *ServiceAccountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// service account can't manage other service accounts
+	if ScopeFromContext(r.Context()) != nil {
+		responseAPIError(w, ErrForbidden)
+
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		err = h.handleCreateAccount(w, r)
+	case http.MethodDelete:
+		err = h.handleDeleteAccount(w, r)
+	default:
+		zlog.Logger().Infof("unhandled method %s", r.Method)
+
+		responseMethodNotAllowed(w, "PUT, DELETE")
+	}
+
+	if err != nil {
+		zlog.Logger().Infof("handle error: %s", err)
+	}
+}
+
+type CreateServiceAccountRequest struct {
+	Name  string `json:"name"`
+	Scope Scope  `json:"scope"`
+}
+
+func (r *CreateServiceAccountRequest) Validate() bool {
+	return len(r.Name) > 0 && r.Scope.Validate()
+}
+
+type CreateServiceAccountResponse struct {
+	Token string `json:"token"`
+}
+
+func (h *ServiceAccountHandler) handleCreateAccount(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*CreateServiceAccountRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	token, err := makeServiceAccountToken()
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	account := &ServiceAccount{Name: req.Name, TokenHash: HashServiceAccountToken(token), Scope: &req.Scope}
+
+	err = h.storage.CreateServiceAccount(r.Context(), getTokenFromRequestContext(r), account)
+	audit(h.auditor, r, AuditActionCreate, ItemKindServiceAccount, account.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	return writeResponse(w, &CreateServiceAccountResponse{Token: token})
+}
+
+type DeleteServiceAccountRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *DeleteServiceAccountRequest) Validate() bool {
+	return len(r.Name) > 0
+}
+
+func (h *ServiceAccountHandler) handleDeleteAccount(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*DeleteServiceAccountRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	err = h.storage.DeleteServiceAccount(r.Context(), getTokenFromRequestContext(r), req.Name)
+	audit(h.auditor, r, AuditActionDelete, ItemKindServiceAccount, req.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+// HashServiceAccountToken returns hash which is used for searching of service account by token
+func HashServiceAccountToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func makeServiceAccountToken() (string, error) {
+	const tokenSize = 32
+
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountHandlerCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockServiceAccountStorage(ctrl)
+	h := NewServiceAccountHandler(mockStorage, &testAuditor{})
+
+	scope := Scope{Kinds: []string{ItemKindSecret}, Prefixes: []string{"ci/"}, ReadOnly: true}
+
+	data, err := json.Marshal(CreateServiceAccountRequest{Name: "ci", Scope: scope})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.ServiceAccountEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	var stored *ServiceAccount
+	mockStorage.EXPECT().CreateServiceAccount(gomock.Any(), testToken, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, account *ServiceAccount) error {
+			stored = account
+
+			return nil
+		},
+	)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &CreateServiceAccountResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.NotEmpty(t, resp.Token)
+
+	// token isn't stored
+	require.Equal(t, "ci", stored.Name)
+	require.Equal(t, &scope, stored.Scope)
+	require.Equal(t, HashServiceAccountToken(resp.Token), stored.TokenHash)
+	require.NotEqual(t, resp.Token, stored.TokenHash)
+}
+
+func TestServiceAccountHandlerCreateWithUnknownKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewServiceAccountHandler(NewMockServiceAccountStorage(ctrl), &testAuditor{})
+
+	data, err := json.Marshal(CreateServiceAccountRequest{Name: "ci", Scope: Scope{Kinds: []string{"unknown"}}})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.ServiceAccountEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServiceAccountHandlerForbiddenForServiceAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewServiceAccountHandler(NewMockServiceAccountStorage(ctrl), &testAuditor{})
+
+	data, err := json.Marshal(DeleteServiceAccountRequest{Name: "ci"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodDelete, endpoint.ServiceAccountEndpoint, bytes.NewBuffer(data))
+	ctx := context.WithValue(r.Context(), AuthInfo("token"), testToken)
+	r = r.WithContext(context.WithValue(ctx, AuthInfo("scope"), &Scope{}))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestServiceAccountHandlerDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockServiceAccountStorage(ctrl)
+	h := NewServiceAccountHandler(mockStorage, &testAuditor{})
+
+	data, err := json.Marshal(DeleteServiceAccountRequest{Name: "ci"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodDelete, endpoint.ServiceAccountEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	mockStorage.EXPECT().DeleteServiceAccount(gomock.Any(), testToken, "ci").Return(nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServiceAccountListHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockServiceAccountStorage(ctrl)
+	h := NewServiceAccountListHandler(mockStorage, &testAuditor{})
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.ServiceAccountsEndpoint, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	accounts := []*ServiceAccount{{Name: "ci", Scope: &Scope{ReadOnly: true}}}
+	mockStorage.EXPECT().ListServiceAccounts(gomock.Any(), testToken).Return(accounts, nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &ServiceAccountListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Len(t, resp.Data, 1)
+	require.Equal(t, "ci", resp.Data[0].Name)
+	require.Equal(t, accounts[0].Scope, resp.Data[0].Scope)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+)
+
+type ServiceAccountListHandler struct {
+	storage ServiceAccountStorage
+	auditor Auditor
+}
+
+func NewServiceAccountListHandler(storage ServiceAccountStorage, auditor Auditor) *ServiceAccountListHandler {
+	return &ServiceAccountListHandler{storage: storage, auditor: auditor}
+}
+
+type ServiceAccountListResponse struct {
+	Data []*ServiceAccount `json:"data"`
+}
+
+func (h *ServiceAccountListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "list is requested by GET")
+
+		return
+	}
+
+	if ScopeFromContext(r.Context()) != nil {
+		responseAPIError(w, ErrForbidden)
+
+		return
+	}
+
+	accounts, err := h.storage.ListServiceAccounts(r.Context(), getTokenFromRequestContext(r))
+	audit(h.auditor, r, AuditActionList, ItemKindServiceAccount, "", err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return
+	}
+
+	if err := writeResponse(w, ServiceAccountListResponse{Data: accounts}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+type SSHKey struct {
+	Name string
+	Data string
+	// Updated is a time of key's last change, it's set by storage
+	Updated time.Time
+}
+
+//go:generate mockgen -source=ssh_key_handler.go -destination=./mock_ssh_key_handler.go -package=handler
+type SSHKeyStorage interface {
+	CreateSSHKey(ctx context.Context, userToken string, key *SSHKey) error
+	GetSSHKey(ctx context.Context, userToken string, name string) (*SSHKey, error)
+	DeleteSSHKey(ctx context.Context, userToken string, name string) error
+	// ListSSHKey returns keys without data
+	ListSSHKey(ctx context.Context, userToken string, q *ListQuery) ([]*SSHKey, error)
+}
+
+type SSHKeyHandler struct {
+	storage   SSHKeyStorage
+	publisher ChangePublisher
+	auditor   Auditor
+	quota     QuotaChecker
+}
+
+func NewSSHKeyHandler(storage SSHKeyStorage, publisher ChangePublisher, auditor Auditor, quota QuotaChecker) *SSHKeyHandler {
+	return &SSHKeyHandler{storage: storage, publisher: publisher, auditor: auditor, quota: quota}
+}
+
+func (h *SSHKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		err = h.handleGetKey(w, r)
+	case http.MethodPut:
+		err = h.handleSaveKey(w, r)
+	case http.MethodDelete:
+		err = h.handleDeleteKey(w, r)
+	default:
+		zlog.Logger().Infof("unhandled method %s", r.Method)
+
+		responseMethodNotAllowed(w, "GET, PUT, DELETE")
+	}
+
+	if err != nil {
+		zlog.Logger().Infof("handle error: %s", err)
+	}
+}
+
+type GetSSHKeyRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *GetSSHKeyRequest) Validate() bool {
+	return len(r.Name) > 0
+}
+
+type GetSSHKeyResponse struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func (h *SSHKeyHandler) handleGetKey(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*GetSSHKeyRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	token := getTokenFromRequestContext(r)
+
+	key, err := h.storage.GetSSHKey(r.Context(), token, req.Name)
+	audit(h.auditor, r, AuditActionRead, ItemKindSSHKey, req.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	return writeResponse(w, GetSSHKeyResponse{Name: key.Name, Data: key.Data})
+}
+
+type SaveSSHKeyRequest struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func (r *SaveSSHKeyRequest) Validate() bool {
+	return len(r.Name) > 0 && len(r.Data) > 0
+}
+
+func (h *SSHKeyHandler) handleSaveKey(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*SaveSSHKeyRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	token := getTokenFromRequestContext(r)
+	key := &SSHKey{Name: req.Name, Data: req.Data}
+
+	err = h.quota.CheckQuota(r.Context(), token, len(key.Data), true)
+	if err == nil {
+		err = h.storage.CreateSSHKey(r.Context(), token, key)
+	}
+	audit(h.auditor, r, AuditActionCreate, ItemKindSSHKey, key.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindSSHKey, Operation: OperationCreate, Key: key.Name, Data: key.Data})
+
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+type DeleteSSHKeyRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *DeleteSSHKeyRequest) Validate() bool {
+	return len(r.Name) > 0
+}
+
+func (h *SSHKeyHandler) handleDeleteKey(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*DeleteSSHKeyRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	token := getTokenFromRequestContext(r)
+
+	err = h.storage.DeleteSSHKey(r.Context(), token, req.Name)
+	audit(h.auditor, r, AuditActionDelete, ItemKindSSHKey, req.Name, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindSSHKey, Operation: OperationDelete, Key: req.Name})
+
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
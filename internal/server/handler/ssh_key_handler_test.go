@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHKeyHandlerCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	notifier := NewChangeNotifier()
+	defer notifier.Close()
+
+	events, unsubscribe := notifier.Subscribe(testToken)
+	defer unsubscribe()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyHandler(mockStorage, notifier, &testAuditor{}, noQuota)
+
+	data, err := json.Marshal(SaveSSHKeyRequest{Name: "deploy", Data: "crypted"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.SSHKeyEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	mockStorage.EXPECT().CreateSSHKey(gomock.Any(), testToken, &SSHKey{Name: "deploy", Data: "crypted"}).Return(nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	event := <-events
+	require.Equal(t, &ChangeEvent{Kind: ItemKindSSHKey, Operation: OperationCreate, Key: "deploy", Data: "crypted"}, event)
+}
+
+func TestSSHKeyHandlerCreateAlreadyExist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	data, err := json.Marshal(SaveSSHKeyRequest{Name: "deploy", Data: "crypted"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.SSHKeyEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	mockStorage.EXPECT().CreateSSHKey(gomock.Any(), testToken, gomock.Any()).Return(ErrDataAlreadyExist)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestSSHKeyHandlerGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "stored key", status: http.StatusOK},
+		{name: "unknown key", err: ErrDataNotFound, status: http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(GetSSHKeyRequest{Name: "deploy"})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, endpoint.SSHKeyEndpoint, bytes.NewBuffer(data))
+			r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+			w := httptest.NewRecorder()
+
+			if tc.err != nil {
+				mockStorage.EXPECT().GetSSHKey(gomock.Any(), testToken, "deploy").Return(nil, tc.err)
+			} else {
+				mockStorage.EXPECT().GetSSHKey(gomock.Any(), testToken, "deploy").Return(&SSHKey{Name: "deploy", Data: "crypted"}, nil)
+			}
+
+			h.ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+
+			if tc.err == nil {
+				resp := &GetSSHKeyResponse{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+				require.Equal(t, &GetSSHKeyResponse{Name: "deploy", Data: "crypted"}, resp)
+			}
+		})
+	}
+}
+
+func TestSSHKeyHandlerDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	data, err := json.Marshal(DeleteSSHKeyRequest{Name: "deploy"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodDelete, endpoint.SSHKeyEndpoint, bytes.NewBuffer(data))
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	mockStorage.EXPECT().DeleteSSHKey(gomock.Any(), testToken, "deploy").Return(nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSSHKeyHandlerBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyHandler(mockStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
+
+	r := httptest.NewRequest(http.MethodPut, endpoint.SSHKeyEndpoint, nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
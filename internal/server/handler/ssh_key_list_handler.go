@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+type SSHKeyListHandler struct {
+	storage SSHKeyStorage
+	auditor Auditor
+}
+
+func NewSSHKeyListHandler(storage SSHKeyStorage, auditor Auditor) *SSHKeyListHandler {
+	return &SSHKeyListHandler{storage: storage, auditor: auditor}
+}
+
+// SSHKeyInfo is a metadata of user's ssh key, the key's data is got by its name
+type SSHKeyInfo struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SSHKeyListResponse struct {
+	Data []*SSHKeyInfo `json:"data"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (h *SSHKeyListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "list is requested by GET")
+
+		return
+	}
+
+	h.handleListKeys(w, r)
+}
+
+func (h *SSHKeyListHandler) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	q, pageSize, err := parseListQuery(r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+		return
+	}
+
+	token := getTokenFromRequestContext(r)
+
+	keys, err := h.storage.ListSSHKey(r.Context(), token, q)
+	audit(h.auditor, r, AuditActionList, ItemKindSSHKey, q.Prefix, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+		return
+	}
+
+	keys, cursor := nextPage(q, keys, pageSize, func(k *SSHKey) (string, time.Time) {
+		return k.Name, k.Updated
+	})
+
+	response := SSHKeyListResponse{
+		Data:       make([]*SSHKeyInfo, 0, len(keys)),
+		NextCursor: cursor,
+	}
+
+	for _, k := range keys {
+		response.Data = append(response.Data, &SSHKeyInfo{Name: k.Name, UpdatedAt: k.Updated})
+	}
+
+	if err := writeResponse(w, response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
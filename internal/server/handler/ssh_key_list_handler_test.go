@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSSHKeyHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockSSHKeyStorage(ctrl)
+	h := NewSSHKeyListHandler(mockStorage, &testAuditor{})
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.SSHKeysEndpoint+"?limit=1", nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
+	w := httptest.NewRecorder()
+
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	q := &ListQuery{SortBy: SortByName, Limit: 2}
+
+	mockStorage.EXPECT().ListSSHKey(gomock.Any(), testToken, q).Return([]*SSHKey{
+		{Name: "deploy", Updated: updated},
+		{Name: "personal", Updated: updated},
+	}, nil)
+
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &SSHKeyListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, []*SSHKeyInfo{{Name: "deploy", UpdatedAt: updated}}, resp.Data)
+	require.NotEmpty(t, resp.NextCursor)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// UsageHandler returns user's consumption of storage with the user's quota
+type UsageHandler struct {
+	storage UsageStorage
+	quota   *Quota
+}
+
+func NewUsageHandler(storage UsageStorage, quota *Quota) *UsageHandler {
+	return &UsageHandler{storage: storage, quota: quota}
+}
+
+// UsageResponse's zero limit is unlimited
+type UsageResponse struct {
+	Bytes       int64 `json:"bytes"`
+	Items       int   `json:"items"`
+	MaxBytes    int64 `json:"max_bytes"`
+	MaxItems    int   `json:"max_items"`
+	MaxItemSize int64 `json:"max_item_size"`
+}
+
+func (h *UsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		responseMethodNotAllowed(w, http.MethodGet)
+
+		return
+	}
+
+	usage, err := h.storage.Usage(r.Context(), getTokenFromRequestContext(r))
+	if err != nil {
+		responseAPIError(w, err)
+
+		return
+	}
+
+	response := UsageResponse{
+		Bytes:       usage.Bytes,
+		Items:       usage.Items,
+		MaxBytes:    h.quota.MaxBytes,
+		MaxItems:    h.quota.MaxItems,
+		MaxItemSize: h.quota.MaxItemSize,
+	}
+
+	if err := writeResponse(w, response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/kuzhukin/goph-keeper/internal/zlog"
 )
@@ -10,26 +11,35 @@ import (
 type CardData struct {
 	Number string
 	Data   string
+	// Updated is a time of card's last change, it's set by storage
+	Updated time.Time
 }
 
 //go:generate mockgen -source=wallet_handler.go -destination=./mock_wallet_handler.go -package=handler
 type WalletStorage interface {
 	CreateCard(ctx context.Context, userToken string, d *CardData) error
-	ListCard(ctx context.Context, userToken string) ([]*CardData, error)
+	GetCard(ctx context.Context, userToken string, number string) (*CardData, error)
+	// ListCard returns cards without data
+	ListCard(ctx context.Context, userToken string, q *ListQuery) ([]*CardData, error)
 	DeleteCard(ctx context.Context, userToken string, d *CardData) error
 }
 
 type WalletHandler struct {
-	storage WalletStorage
+	storage   WalletStorage
+	publisher ChangePublisher
+	auditor   Auditor
+	quota     QuotaChecker
 }
 
-func NewWalletHandler(storage WalletStorage) *WalletHandler {
-	return &WalletHandler{storage: storage}
+func NewWalletHandler(storage WalletStorage, publisher ChangePublisher, auditor Auditor, quota QuotaChecker) *WalletHandler {
+	return &WalletHandler{storage: storage, publisher: publisher, auditor: auditor, quota: quota}
 }
 
 func (h *WalletHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var err error
 	switch r.Method {
+	case http.MethodGet:
+		err = h.handleGetData(w, r)
 	case http.MethodPut:
 		err = h.handleSaveData(w, r)
 	case http.MethodDelete:
@@ -37,7 +47,7 @@ func (h *WalletHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	default:
 		zlog.Logger().Infof("unhandled method %s", r.Method)
 
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		responseMethodNotAllowed(w, "GET, PUT, DELETE")
 	}
 
 	if err != nil {
@@ -45,6 +55,39 @@ func (h *WalletHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type GetCardDataRequest struct {
+	CardNumber string `json:"number"`
+}
+
+func (r *GetCardDataRequest) Validate() bool {
+	return len(r.CardNumber) > 0
+}
+
+type GetCardDataResponse struct {
+	CardNumber string `json:"number"`
+	CardData   string `json:"data"`
+}
+
+func (h *WalletHandler) handleGetData(w http.ResponseWriter, r *http.Request) error {
+	req, err := readRequest[*GetCardDataRequest](r)
+	if err != nil {
+		responseAPIRequestError(w, err)
+
+		return err
+	}
+
+	card, err := h.storage.GetCard(r.Context(), getTokenFromRequestContext(r), req.CardNumber)
+	audit(h.auditor, r, AuditActionRead, ItemKindCard, req.CardNumber, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+
+		return err
+	}
+
+	return writeResponse(w, GetCardDataResponse{CardNumber: card.Number, CardData: card.Data})
+}
+
 type SaveCardDataRequest struct {
 	CardNumber string `json:"number"`
 	CardData   string `json:"data"`
@@ -57,20 +100,28 @@ func (r *SaveCardDataRequest) Validate() bool {
 func (h *WalletHandler) handleSaveData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*SaveCardDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
 
 	card := &CardData{Number: req.CardNumber, Data: req.CardData}
+	token := getTokenFromRequestContext(r)
 
-	if err := h.storage.CreateCard(r.Context(), getTokenFromRequestContext(r), card); err != nil {
+	err = h.quota.CheckQuota(r.Context(), token, len(card.Data), true)
+	if err == nil {
+		err = h.storage.CreateCard(r.Context(), token, card)
+	}
+	audit(h.auditor, r, AuditActionCreate, ItemKindCard, card.Number, err)
 
-		responseErrorCardDataRequest(w, err)
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindCard, Operation: OperationCreate, Key: card.Number, Data: card.Data})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
@@ -87,26 +138,26 @@ func (r *DeleteCardDataRequest) Validate() bool {
 func (h *WalletHandler) handleDeleteData(w http.ResponseWriter, r *http.Request) error {
 	req, err := readRequest[*DeleteCardDataRequest](r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		responseAPIRequestError(w, err)
 
 		return err
 	}
 
 	data := &CardData{Number: req.CardNumber}
+	token := getTokenFromRequestContext(r)
 
-	if err := h.storage.DeleteCard(r.Context(), getTokenFromRequestContext(r), data); err != nil {
-		responseErrorCardDataRequest(w, err)
+	err = h.storage.DeleteCard(r.Context(), token, data)
+	audit(h.auditor, r, AuditActionDelete, ItemKindCard, data.Number, err)
+
+	if err != nil {
+		responseAPIError(w, err)
 
 		return err
 	}
 
+	h.publisher.Publish(token, &ChangeEvent{Kind: ItemKindCard, Operation: OperationDelete, Key: data.Number})
+
 	w.WriteHeader(http.StatusOK)
 
 	return nil
 }
-
-func responseErrorCardDataRequest(w http.ResponseWriter, err error) {
-	zlog.Logger().Infof("storage err=%s", err)
-
-	w.WriteHeader(http.StatusInternalServerError)
-}
@@ -23,7 +23,7 @@ func TestWalletHandlerAddCard(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockWalletStorage := NewMockWalletStorage(ctrl)
-	h := NewWalletHandler(mockWalletStorage)
+	h := NewWalletHandler(mockWalletStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := SaveCardDataRequest{CardNumber: testCardNumber, CardData: testCardData}
 	data, err := json.Marshal(req)
@@ -40,28 +40,31 @@ func TestWalletHandlerAddCard(t *testing.T) {
 	require.Equal(t, http.StatusOK, w.Code)
 }
 
-func TestWalletHandlerListCard(t *testing.T) {
+func TestWalletHandlerGetCard(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockWalletStorage := NewMockWalletStorage(ctrl)
-	h := NewWalletListHandler(mockWalletStorage)
+	h := NewWalletHandler(mockWalletStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
-	r := httptest.NewRequest(http.MethodGet, endpoint.WalletEndpoint, nil)
+	data, err := json.Marshal(GetCardDataRequest{CardNumber: testCardNumber})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, endpoint.WalletEndpoint, bytes.NewBuffer(data))
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
 	w := httptest.NewRecorder()
 
-	expectedList := []*CardData{{"1234", "xxxx"}, {"1234", "yyyy"}}
-	mockWalletStorage.EXPECT().ListCard(gomock.Any(), testToken).Return(expectedList, nil)
+	card := &CardData{Number: testCardNumber, Data: testCardData}
+	mockWalletStorage.EXPECT().GetCard(gomock.Any(), testToken, testCardNumber).Return(card, nil)
 
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusOK, w.Code)
 
-	resp := &GetCardsResponse{}
-	err := json.Unmarshal(w.Body.Bytes(), resp)
+	resp := &GetCardDataResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), resp)
 	require.NoError(t, err)
 
-	require.Equal(t, expectedList, resp.Cards)
+	require.Equal(t, &GetCardDataResponse{CardNumber: testCardNumber, CardData: testCardData}, resp)
 }
 
 func TestWalletHandlerDeleteCard(t *testing.T) {
@@ -69,7 +72,7 @@ func TestWalletHandlerDeleteCard(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockWalletStorage := NewMockWalletStorage(ctrl)
-	h := NewWalletHandler(mockWalletStorage)
+	h := NewWalletHandler(mockWalletStorage, NewChangeNotifier(), &testAuditor{}, noQuota)
 
 	req := DeleteCardDataRequest{CardNumber: "1234"}
 	data, err := json.Marshal(req)
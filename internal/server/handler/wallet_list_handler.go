@@ -2,19 +2,21 @@ package handler
 
 import (
 	"net/http"
+	"time"
 )
 
 type WalletListHandler struct {
 	storage WalletStorage
+	auditor Auditor
 }
 
-func NewWalletListHandler(storage WalletStorage) *WalletListHandler {
-	return &WalletListHandler{storage: storage}
+func NewWalletListHandler(storage WalletStorage, auditor Auditor) *WalletListHandler {
+	return &WalletListHandler{storage: storage, auditor: auditor}
 }
 
 func (h *WalletListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusBadRequest)
+		WriteError(w, http.StatusBadRequest, ErrorCodeBadRequest, "list is requested by GET")
 
 		return
 	}
@@ -22,18 +24,44 @@ func (h *WalletListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.handleGetData(w, r)
 }
 
+// CardInfo is a metadata of user's card, the card's data is got by its number
+type CardInfo struct {
+	Number    string    `json:"number"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type GetCardsResponse struct {
-	Cards []*CardData `json:"cards"`
+	Cards []*CardInfo `json:"cards"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (h *WalletListHandler) handleGetData(w http.ResponseWriter, r *http.Request) {
-	cards, err := h.storage.ListCard(r.Context(), getTokenFromRequestContext(r))
+	q, pageSize, err := parseListQuery(r)
 	if err != nil {
-		responseErrorCardDataRequest(w, err)
+		responseAPIRequestError(w, err)
+		return
 	}
 
+	cards, err := h.storage.ListCard(r.Context(), getTokenFromRequestContext(r), q)
+	audit(h.auditor, r, AuditActionList, ItemKindCard, q.Prefix, err)
+
+	if err != nil {
+		responseAPIError(w, err)
+		return
+	}
+
+	cards, cursor := nextPage(q, cards, pageSize, func(c *CardData) (string, time.Time) {
+		return c.Number, c.Updated
+	})
+
 	response := GetCardsResponse{
-		Cards: cards,
+		Cards:      make([]*CardInfo, 0, len(cards)),
+		NextCursor: cursor,
+	}
+
+	for _, c := range cards {
+		response.Cards = append(response.Cards, &CardInfo{Number: c.Number, UpdatedAt: c.Updated})
 	}
 
 	if err := writeResponse(w, response); err != nil {
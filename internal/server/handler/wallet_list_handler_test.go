@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
@@ -17,17 +18,19 @@ func TestWalletListHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockWalletStorage := NewMockWalletStorage(ctrl)
-	h := NewWalletListHandler(mockWalletStorage)
+	h := NewWalletListHandler(mockWalletStorage, &testAuditor{})
 
-	r := httptest.NewRequest(http.MethodGet, endpoint.WalletEndpoint, nil)
+	r := httptest.NewRequest(http.MethodGet, endpoint.WalletsEndpoint+"?prefix=1234", nil)
 	r = r.WithContext(context.WithValue(r.Context(), AuthInfo("token"), testToken))
 	w := httptest.NewRecorder()
 
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
 	cards := []*CardData{
-		{Number: "1234123412341234", Data: "secretdata1"},
-		{Number: "5678567856785670", Data: "secretdata2"},
+		{Number: "1234123412341234", Updated: updated},
+		{Number: "1234567856785670", Updated: updated},
 	}
-	mockWalletStorage.EXPECT().ListCard(gomock.Any(), testToken).Return(cards, nil)
+	q := &ListQuery{Prefix: "1234", SortBy: SortByName, Limit: DefaultPageSize + 1}
+	mockWalletStorage.EXPECT().ListCard(gomock.Any(), testToken, q).Return(cards, nil)
 
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusOK, w.Code)
@@ -37,5 +40,9 @@ func TestWalletListHandler(t *testing.T) {
 	err := json.Unmarshal(answer, resp)
 	require.NoError(t, err)
 
-	require.Equal(t, cards, resp.Cards)
+	require.Equal(t, []*CardInfo{
+		{Number: "1234123412341234", UpdatedAt: updated},
+		{Number: "1234567856785670", UpdatedAt: updated},
+	}, resp.Cards)
+	require.Empty(t, resp.NextCursor)
 }
@@ -12,27 +12,37 @@ import (
 
 type AuthModdleware struct {
 	checker UserChecker
+	auditor handler.Auditor
 }
 
-func NewAuthMiddleware(c UserChecker) *AuthModdleware {
+func NewAuthMiddleware(c UserChecker, auditor handler.Auditor) *AuthModdleware {
 	return &AuthModdleware{
 		checker: c,
+		auditor: auditor,
 	}
 }
 
 //go:generate mockgen -source=auth.go -destination=./mock_user_checker.go -package=middleware
 type UserChecker interface {
-	Check(ctx context.Context, token string) error
+	// Check returns owner's token and scope of service account's token
+	Check(ctx context.Context, token string) (*handler.Access, error)
 }
 
 func (a *AuthModdleware) Middleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// v2 registration reads credentials from body
+		if r.URL.Path == endpoint.TokensV2Endpoint {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
 		if r.URL.Path == endpoint.RegisterEndpoint {
 			login := r.Header.Get("login")
 			if len(login) == 0 {
 				zlog.Logger().Debug("headers don't have login field")
 
-				w.WriteHeader(http.StatusBadRequest)
+				handler.WriteError(w, http.StatusBadRequest, handler.ErrorCodeBadRequest, "request doesn't have login header")
 
 				return
 			}
@@ -41,7 +51,7 @@ func (a *AuthModdleware) Middleware(h http.Handler) http.Handler {
 			if len(password) == 0 {
 				zlog.Logger().Debug("headers don't have password field")
 
-				w.WriteHeader(http.StatusBadRequest)
+				handler.WriteError(w, http.StatusBadRequest, handler.ErrorCodeBadRequest, "request doesn't have password header")
 
 				return
 			}
@@ -57,24 +67,100 @@ func (a *AuthModdleware) Middleware(h http.Handler) http.Handler {
 
 		token := r.Header.Get("token")
 		if len(token) == 0 {
-			w.WriteHeader(http.StatusBadRequest)
+			handler.WriteError(w, http.StatusBadRequest, handler.ErrorCodeBadRequest, "request doesn't have token header")
 
 			return
 		}
 
-		ctxWithAuthInfo := context.WithValue(r.Context(), handler.AuthInfo("token"), token)
-		r = r.WithContext(ctxWithAuthInfo)
+		kind, key, _ := requestItem(r)
 
-		if err := a.checker.Check(r.Context(), token); err != nil {
+		access, err := a.checker.Check(r.Context(), token)
+		if err != nil {
 			if errors.Is(err, handler.ErrUnknownUser) {
-				w.WriteHeader(http.StatusUnauthorized)
+				zlog.Logger().Warnf("unknown token, %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+
+				handler.AuditDenied(a.auditor, r, token, requestAction(r.Method), kind, key, err)
+				handler.WriteError(w, http.StatusUnauthorized, handler.ErrorCodeUnauthorized, "unknown token")
 			} else {
-				w.WriteHeader(http.StatusInternalServerError)
+				zlog.Logger().Errorf("check token err=%s", err)
+				handler.WriteError(w, http.StatusInternalServerError, handler.ErrorCodeInternal, "internal server error")
 			}
 
 			return
 		}
 
+		// handlers work with owner's items, so service account's token is replaced with owner's token
+		ctxWithAuthInfo := context.WithValue(r.Context(), handler.AuthInfo("token"), access.UserToken)
+		if access.Scope != nil {
+			ctxWithAuthInfo = context.WithValue(ctxWithAuthInfo, handler.AuthInfo("scope"), access.Scope)
+			ctxWithAuthInfo = context.WithValue(ctxWithAuthInfo, handler.AuthInfo("service-account"), access.ServiceAccount)
+		}
+
+		r = r.WithContext(ctxWithAuthInfo)
+
+		if !isAllowedByScope(access.Scope, r) {
+			zlog.Logger().Debugf("token's scope doesn't allow %s %s", r.Method, r.URL.Path)
+
+			handler.AuditDenied(a.auditor, r, access.UserToken, requestAction(r.Method), kind, key, handler.ErrForbidden)
+			handler.WriteError(w, http.StatusForbidden, handler.ErrorCodeForbidden, "token's scope doesn't allow operation")
+
+			return
+		}
+
 		h.ServeHTTP(w, r)
 	})
 }
+
+// item kinds of endpoints which are available for service accounts
+var endpointKinds = map[string]string{
+	endpoint.BinaryDataEndpoint:   handler.ItemKindData,
+	endpoint.BinariesDataEndpoint: handler.ItemKindData,
+	endpoint.WalletEndpoint:       handler.ItemKindCard,
+	endpoint.WalletsEndpoint:      handler.ItemKindCard,
+	endpoint.SecretEndpoint:       handler.ItemKindSecret,
+	endpoint.SecretsEndpoint:      handler.ItemKindSecret,
+	endpoint.SSHKeyEndpoint:       handler.ItemKindSSHKey,
+	endpoint.SSHKeysEndpoint:      handler.ItemKindSSHKey,
+}
+
+// isAllowedByScope checks item's kind and permission, items' names are checked by storage
+func isAllowedByScope(scope *handler.Scope, r *http.Request) bool {
+	if scope == nil {
+		return true
+	}
+
+	// stream is filtered by handler
+	if r.URL.Path == endpoint.ChangesEndpoint {
+		return r.Method == http.MethodGet
+	}
+
+	kind, _, ok := requestItem(r)
+	if !ok {
+		return false
+	}
+
+	return scope.AllowsKind(kind, r.Method != http.MethodGet)
+}
+
+// requestItem returns item's kind and key of v2 items, key of v1 endpoints is in request's body or query
+func requestItem(r *http.Request) (string, string, bool) {
+	if kind, ok := endpointKinds[r.URL.Path]; ok {
+		return kind, "", true
+	}
+
+	return handler.ParseItemPath(r.URL.EscapedPath())
+}
+
+// requestAction is audited action of the request which was denied before it was handled
+func requestAction(method string) string {
+	switch method {
+	case http.MethodGet:
+		return handler.AuditActionRead
+	case http.MethodPost:
+		return handler.AuditActionCreate
+	case http.MethodDelete:
+		return handler.AuditActionDelete
+	default:
+		return handler.AuditActionUpdate
+	}
+}
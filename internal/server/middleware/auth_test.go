@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,7 +20,7 @@ func TestAuthRegisterNewUser(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	authMiddleware := NewAuthMiddleware(mockChecker, handler.NewMockAuditor(ctrl))
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.RegisterEndpoint, nil)
 	r.Header.Add("login", "user")
@@ -47,7 +49,7 @@ func TestAuthRegisterWithouLoginHeader(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	authMiddleware := NewAuthMiddleware(mockChecker, handler.NewMockAuditor(ctrl))
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.RegisterEndpoint, nil)
 	r.Header.Add("password", "1234")
@@ -67,7 +69,7 @@ func TestAuthRegisterWithouPasswordHeader(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	authMiddleware := NewAuthMiddleware(mockChecker, handler.NewMockAuditor(ctrl))
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.RegisterEndpoint, nil)
 	r.Header.Add("login", "user")
@@ -87,7 +89,7 @@ func TestAuthHandledDataEndpoint(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	authMiddleware := NewAuthMiddleware(mockChecker, handler.NewMockAuditor(ctrl))
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.BinaryDataEndpoint, nil)
 
@@ -98,7 +100,7 @@ func TestAuthHandledDataEndpoint(t *testing.T) {
 
 	wrappedHandler := authMiddleware.Middleware(mockHandler)
 
-	mockChecker.EXPECT().Check(gomock.Any(), expectedToken).Return(nil)
+	mockChecker.EXPECT().Check(gomock.Any(), expectedToken).Return(&handler.Access{UserToken: expectedToken}, nil)
 
 	mockHandler.EXPECT().ServeHTTP(gomock.Any(), gomock.Any()).DoAndReturn(func(w http.ResponseWriter, r *http.Request) {
 		o := r.Context().Value(handler.AuthInfo("token"))
@@ -119,7 +121,7 @@ func TestAuthHandledDataEndpointWithoutToken(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	authMiddleware := NewAuthMiddleware(mockChecker, handler.NewMockAuditor(ctrl))
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.BinaryDataEndpoint, nil)
 
@@ -138,7 +140,9 @@ func TestAuthHandledDataEndpointUnknownUser(t *testing.T) {
 	mockChecker := NewMockUserChecker(ctrl)
 	mockHandler := NewMockHTTPHandler(ctrl)
 
-	authMiddleware := NewAuthMiddleware(mockChecker)
+	mockAuditor := handler.NewMockAuditor(ctrl)
+
+	authMiddleware := NewAuthMiddleware(mockChecker, mockAuditor)
 
 	r := httptest.NewRequest(http.MethodGet, endpoint.BinaryDataEndpoint, nil)
 
@@ -149,8 +153,103 @@ func TestAuthHandledDataEndpointUnknownUser(t *testing.T) {
 
 	wrappedHandler := authMiddleware.Middleware(mockHandler)
 
-	mockChecker.EXPECT().Check(gomock.Any(), expectedToken).Return(handler.ErrUnknownUser)
+	mockChecker.EXPECT().Check(gomock.Any(), expectedToken).Return(nil, handler.ErrUnknownUser)
+	mockAuditor.EXPECT().Audit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *handler.AuditEvent) error {
+		require.Equal(t, expectedToken, event.UserToken)
+		require.Equal(t, handler.AuditActionRead, event.Action)
+		require.Equal(t, handler.ItemKindData, event.Kind)
+		require.Equal(t, "192.0.2.1", event.IP)
+		require.Equal(t, handler.AuditResultDenied, event.Result)
+
+		return nil
+	})
 
 	wrappedHandler.ServeHTTP(w, r)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthServiceAccountToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChecker := NewMockUserChecker(ctrl)
+	mockHandler := NewMockHTTPHandler(ctrl)
+
+	mockAuditor := handler.NewMockAuditor(ctrl)
+
+	wrappedHandler := NewAuthMiddleware(mockChecker, mockAuditor).Middleware(mockHandler)
+
+	serviceToken := "service"
+	ownerToken := "owner"
+	scope := &handler.Scope{Kinds: []string{handler.ItemKindSecret}, Prefixes: []string{"ci/"}, ReadOnly: true}
+	access := &handler.Access{UserToken: ownerToken, Scope: scope, ServiceAccount: "ci"}
+
+	mockChecker.EXPECT().Check(gomock.Any(), serviceToken).Return(access, nil).AnyTimes()
+
+	// denied requests are audited as owner's events of the service account
+	mockAuditor.EXPECT().Audit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *handler.AuditEvent) error {
+		require.Equal(t, ownerToken, event.UserToken)
+		require.Equal(t, "ci", event.ServiceAccount)
+		require.Equal(t, handler.AuditResultDenied, event.Result)
+
+		return nil
+	}).Times(8)
+
+	mockHandler.EXPECT().ServeHTTP(gomock.Any(), gomock.Any()).DoAndReturn(func(w http.ResponseWriter, r *http.Request) {
+		// handlers work with owner's items
+		require.Equal(t, ownerToken, r.Context().Value(handler.AuthInfo("token")))
+		require.Equal(t, scope, handler.ScopeFromContext(r.Context()))
+		w.WriteHeader(http.StatusOK)
+	}).Times(4)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{method: http.MethodGet, path: endpoint.SecretEndpoint, code: http.StatusOK},
+		{method: http.MethodGet, path: endpoint.SecretsEndpoint, code: http.StatusOK},
+		{method: http.MethodPut, path: endpoint.SecretEndpoint, code: http.StatusForbidden},
+		{method: http.MethodDelete, path: endpoint.SecretEndpoint, code: http.StatusForbidden},
+		{method: http.MethodGet, path: endpoint.BinaryDataEndpoint, code: http.StatusForbidden},
+		{method: http.MethodPut, path: endpoint.ServiceAccountEndpoint, code: http.StatusForbidden},
+		{method: http.MethodGet, path: "/unknown", code: http.StatusForbidden},
+		{method: http.MethodGet, path: endpoint.ItemsV2Endpoint + "/secret/ci%2Fkey", code: http.StatusOK},
+		{method: http.MethodGet, path: endpoint.ItemsV2Endpoint + "/secret", code: http.StatusOK},
+		{method: http.MethodPut, path: endpoint.ItemsV2Endpoint + "/secret/ci%2Fkey", code: http.StatusForbidden},
+		{method: http.MethodGet, path: endpoint.ItemsV2Endpoint + "/data/key", code: http.StatusForbidden},
+		{method: http.MethodGet, path: endpoint.ItemsV2Endpoint + "/unknown/key", code: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		r.Header.Add("token", serviceToken)
+
+		w := httptest.NewRecorder()
+
+		wrappedHandler.ServeHTTP(w, r)
+		require.Equal(t, test.code, w.Code, "%s %s", test.method, test.path)
+	}
+}
+
+func TestAuthTokensV2WithoutToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHandler := NewMockHTTPHandler(ctrl)
+	wrappedHandler := NewAuthMiddleware(NewMockUserChecker(ctrl), handler.NewMockAuditor(ctrl)).Middleware(mockHandler)
+
+	mockHandler.EXPECT().ServeHTTP(gomock.Any(), gomock.Any())
+
+	w := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.ItemsV2Endpoint+"/data", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	resp := &handler.ErrorResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.Equal(t, handler.ErrorCodeBadRequest, resp.Error.Code)
+}
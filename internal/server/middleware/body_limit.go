@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// BodyLimitMiddleware limits size of requests' bodies, handler gets *http.MaxBytesError on reading of a larger body
+func BodyLimitMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -36,6 +36,11 @@ func (l *loggingResponseWriter) Header() http.Header {
 	return l.rw.Header()
 }
 
+// Unwrap is used by http.ResponseController for flushing of streamed responses
+func (l *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return l.rw
+}
+
 func (l *loggingResponseWriter) doRequestWithTimer(h http.Handler, r *http.Request) time.Duration {
 	start := time.Now()
 
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// label of requests which aren't matched by router, so unknown paths don't grow metrics' cardinality
+const unmatchedEndpoint = "unmatched"
+
+// MetricsMiddleware counts requests and measures their latencies per endpoint, method and status
+type MetricsMiddleware struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+}
+
+func NewMetricsMiddleware(registerer prometheus.Registerer) (*MetricsMiddleware, error) {
+	labels := []string{"endpoint", "method", "status"}
+
+	m := &MetricsMiddleware{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gophkeeper",
+			Name:      "http_requests_total",
+			Help:      "Number of handled http requests.",
+		}, labels),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gophkeeper",
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of handled http requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.durations} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Middleware must be used by the root router, route's pattern is known after request is handled
+func (m *MetricsMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lw := newLoggingResponseWriter(w)
+		duration := lw.doRequestWithTimer(h, r)
+
+		status := lw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		endpoint := unmatchedEndpoint
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && len(rctx.RoutePattern()) != 0 {
+			endpoint = rctx.RoutePattern()
+		}
+
+		labels := prometheus.Labels{"endpoint": endpoint, "method": r.Method, "status": strconv.Itoa(status)}
+
+		m.requests.With(labels).Inc()
+		m.durations.With(labels).Observe(duration.Seconds())
+	})
+}
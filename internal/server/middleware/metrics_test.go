@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsScrape(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	metricsMiddleware, err := NewMetricsMiddleware(registry)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(metricsMiddleware.Middleware)
+	router.Handle(endpoint.MetricsEndpoint, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	router.Handle(endpoint.SecretEndpoint, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	srvr := httptest.NewServer(router)
+	defer srvr.Close()
+
+	for _, method := range []string{http.MethodGet, http.MethodGet, http.MethodDelete} {
+		req, err := http.NewRequest(method, srvr.URL+endpoint.SecretEndpoint, nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	resp, err := http.Get(srvr.URL + "/unknown/path")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = http.Get(srvr.URL + endpoint.MetricsEndpoint)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	metrics := string(data)
+	require.Contains(t, metrics, `gophkeeper_http_requests_total{endpoint="/api/data/secret",method="GET",status="200"} 2`)
+	require.Contains(t, metrics, `gophkeeper_http_requests_total{endpoint="/api/data/secret",method="DELETE",status="404"} 1`)
+	require.Contains(t, metrics, `gophkeeper_http_requests_total{endpoint="unmatched",method="GET",status="404"} 1`)
+	require.Contains(t, metrics, `gophkeeper_http_request_duration_seconds_count{endpoint="/api/data/secret",method="GET",status="200"} 2`)
+}
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	handler "github.com/kuzhukin/goph-keeper/internal/server/handler"
 )
 
 // MockUserChecker is a mock of UserChecker interface.
@@ -35,11 +36,12 @@ func (m *MockUserChecker) EXPECT() *MockUserCheckerMockRecorder {
 }
 
 // Check mocks base method.
-func (m *MockUserChecker) Check(ctx context.Context, token string) error {
+func (m *MockUserChecker) Check(ctx context.Context, token string) (*handler.Access, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Check", ctx, token)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*handler.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Check indicates an expected call of Check.
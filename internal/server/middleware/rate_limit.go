@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/ratelimit"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// RateLimitMiddleware throttles requests of every ip and login attempts of every login,
+// it locks out ip and login after failed authentications, so it must wrap AuthMiddleware
+type RateLimitMiddleware struct {
+	ips    *ratelimit.Limiter
+	logins *ratelimit.Limiter
+}
+
+func NewRateLimitMiddleware(ips *ratelimit.Limiter, logins *ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{ips: ips, logins: logins}
+}
+
+func (m *RateLimitMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+
+		login := ""
+		switch r.URL.Path {
+		case endpoint.RegisterEndpoint:
+			login = r.Header.Get("login")
+		case endpoint.TokensV2Endpoint:
+			login = peekLogin(r)
+		}
+
+		if wait, ok := m.ips.Allow(ip); !ok {
+			zlog.Logger().Infof("requests of ip=%s are limited for %s", ip, wait)
+			tooManyRequests(w, wait)
+
+			return
+		}
+
+		if len(login) != 0 {
+			if wait, ok := m.logins.Allow(login); !ok {
+				zlog.Logger().Infof("login attempts of login=%s are limited for %s", login, wait)
+				tooManyRequests(w, wait)
+
+				return
+			}
+		}
+
+		lw := newLoggingResponseWriter(w)
+		h.ServeHTTP(lw, r)
+
+		switch {
+		case lw.status == http.StatusUnauthorized:
+			m.ips.Fail(ip)
+			if len(login) != 0 {
+				m.logins.Fail(login)
+			}
+		case len(login) != 0 && (lw.status == 0 || lw.status == http.StatusOK):
+			// ip's failures aren't reset by success, so a valid token doesn't allow to guess others
+			m.logins.Succeed(login)
+		}
+	})
+}
+
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	handler.WriteError(w, http.StatusTooManyRequests, handler.ErrorCodeTooManyRequests, "too many requests")
+}
+
+// maxPeekedCredentials limits body which is read before the body limit middleware
+const maxPeekedCredentials = 4096
+
+// peekLogin reads login of v2 registration, the read part of body is returned to the request for handler
+func peekLogin(r *http.Request) string {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPeekedCredentials))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+
+	if err != nil {
+		return ""
+	}
+
+	credentials := &handler.TokenRequest{}
+	if err := json.Unmarshal(data, credentials); err != nil {
+		return ""
+	}
+
+	return credentials.Login
+}
+
+// clientIP is the peer's address, forwarded headers aren't trusted because clients set them
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/kuzhukin/goph-keeper/internal/server/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRequests(t *testing.T) {
+	ips := ratelimit.New(ratelimit.Rate{PerSecond: 1, Burst: 2}, ratelimit.Lockout{})
+	logins := ratelimit.New(ratelimit.Rate{}, ratelimit.Lockout{})
+
+	h := NewRateLimitMiddleware(ips, logins).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.SecretsEndpoint, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, endpoint.SecretsEndpoint, nil))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// other ip isn't limited
+	r := httptest.NewRequest(http.MethodGet, endpoint.SecretsEndpoint, nil)
+	r.RemoteAddr = "10.0.0.2:1234"
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimitLockout(t *testing.T) {
+	ips := ratelimit.New(ratelimit.Rate{}, ratelimit.Lockout{Threshold: 3, Base: time.Minute, Max: time.Hour})
+	logins := ratelimit.New(ratelimit.Rate{}, ratelimit.Lockout{Threshold: 2, Base: time.Minute, Max: time.Hour})
+
+	h := NewRateLimitMiddleware(ips, logins).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("password") != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	register := func(login string, password string, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, endpoint.RegisterEndpoint, nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("login", login)
+		r.Header.Set("password", password)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	require.Equal(t, http.StatusUnauthorized, register("user", "bad", "10.0.0.1:1").Code)
+	require.Equal(t, http.StatusUnauthorized, register("user", "bad", "10.0.0.2:1").Code)
+
+	// login is locked out for all ips
+	w := register("user", "password", "10.0.0.3:1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// ip is locked out for all logins
+	require.Equal(t, http.StatusUnauthorized, register("other", "bad", "10.0.0.1:1").Code)
+	require.Equal(t, http.StatusUnauthorized, register("another", "bad", "10.0.0.1:1").Code)
+	require.Equal(t, http.StatusTooManyRequests, register("one-more", "password", "10.0.0.1:1").Code)
+
+	// successful login resets login's failures
+	require.Equal(t, http.StatusUnauthorized, register("third", "bad", "10.0.0.4:1").Code)
+	require.Equal(t, http.StatusOK, register("third", "password", "10.0.0.4:1").Code)
+	require.Equal(t, http.StatusUnauthorized, register("third", "bad", "10.0.0.4:1").Code)
+	require.Equal(t, http.StatusOK, register("third", "password", "10.0.0.4:1").Code)
+}
+
+func TestRateLimitLockoutTokensV2(t *testing.T) {
+	ips := ratelimit.New(ratelimit.Rate{}, ratelimit.Lockout{})
+	logins := ratelimit.New(ratelimit.Rate{}, ratelimit.Lockout{Threshold: 1, Base: time.Minute, Max: time.Hour})
+
+	h := NewRateLimitMiddleware(ips, logins).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// handler reads the whole body after rate limiter
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if !strings.Contains(string(data), `"password":"password"`) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	login := func(body string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, strings.NewReader(body)))
+
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, login(`{"login":"user","password":"password"}`))
+	require.Equal(t, http.StatusUnauthorized, login(`{"login":"user","password":"bad"}`))
+	require.Equal(t, http.StatusTooManyRequests, login(`{"login":"user","password":"password"}`))
+	require.Equal(t, http.StatusOK, login(`{"login":"other","password":"password"}`))
+}
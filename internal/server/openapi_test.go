@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kuzhukin/goph-keeper/internal/server/config"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage/memstorage"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*openAPISchema   `json:"schemas"`
+		Responses map[string]*openAPIResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Responses map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema openAPIProperty `json:"schema"`
+	} `json:"content"`
+}
+
+type openAPISchema struct {
+	Properties map[string]json.RawMessage `json:"properties"`
+	Required   []string                   `json:"required"`
+}
+
+type openAPIProperty struct {
+	Ref  string   `json:"$ref"`
+	Enum []string `json:"enum"`
+}
+
+var openAPIMethods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodPatch, http.MethodDelete}
+
+func newTestRouter(t *testing.T) *chi.Mux {
+	storage := memstorage.New()
+
+	router, err := newRouter(&config.Config{EnableMetrics: true}, storage, handler.NewChangeNotifier(), handler.NewReadinessHandler(storage))
+	require.NoError(t, err)
+
+	return router
+}
+
+func serveTestRequest(router http.Handler, method string, path string, token string) *httptest.ResponseRecorder {
+	// stream of changes is served until request is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	r := httptest.NewRequest(method, path, nil).WithContext(ctx)
+	r.Header.Set("token", token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func readOpenAPIDocument(t *testing.T, router http.Handler) *openAPIDocument {
+	w := serveTestRequest(router, http.MethodGet, endpoint.OpenAPIEndpoint, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	doc := &openAPIDocument{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	return doc
+}
+
+// routePattern converts document's path to router's pattern, v2 items are routed by wildcard
+func routePattern(path string) string {
+	if strings.HasPrefix(path, endpoint.ItemsV2Endpoint+"/") {
+		return endpoint.ItemsV2Endpoint + "/*"
+	}
+
+	return path
+}
+
+func TestOpenAPIPathsMatchRouter(t *testing.T) {
+	router := newTestRouter(t)
+	doc := readOpenAPIDocument(t, router)
+
+	documented := map[string]bool{}
+	for path := range doc.Paths {
+		documented[routePattern(path)] = true
+	}
+
+	routed := map[string]bool{}
+	err := chi.Walk(router, func(_ string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routed[route] = true
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, routed, documented)
+}
+
+func TestOpenAPIOperationsMatchHandlers(t *testing.T) {
+	router := newTestRouter(t)
+	doc := readOpenAPIDocument(t, router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, endpoint.TokensV2Endpoint, strings.NewReader(`{"login":"user","password":"1234"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	registration := &handler.RegistrationResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), registration))
+
+	pathParams := strings.NewReplacer("{kind}", handler.ItemKindSecret, "{key}", "name")
+
+	for path, operations := range doc.Paths {
+		for _, method := range openAPIMethods {
+			// requests don't have bodies, so handlers answer the documented errors
+			w := serveTestRequest(router, method, pathParams.Replace(path), registration.Token)
+			requireDocumentedResponse(t, doc, operations, path, method, w)
+
+			// documented authentication errors are answered to unknown token
+			w = serveTestRequest(router, method, pathParams.Replace(path), "unknown")
+			requireDocumentedResponse(t, doc, operations, path, method, w)
+		}
+	}
+}
+
+// requireDocumentedResponse checks that the response's code is documented
+// and its JSON body matches the documented schema
+func requireDocumentedResponse(
+	t *testing.T,
+	doc *openAPIDocument,
+	operations map[string]*openAPIOperation,
+	path string,
+	method string,
+	w *httptest.ResponseRecorder,
+) {
+	operation, ok := operations[strings.ToLower(method)]
+	if !ok {
+		// prometheus handler answers all methods
+		if path != endpoint.MetricsEndpoint {
+			require.GreaterOrEqual(t, w.Code, http.StatusBadRequest, "%s %s isn't documented", method, path)
+		}
+
+		return
+	}
+
+	require.NotEqual(t, http.StatusMethodNotAllowed, w.Code, "%s %s", method, path)
+	response, documented := operation.Responses[strconv.Itoa(w.Code)]
+	require.True(t, documented, "%s %s answered undocumented %d", method, path, w.Code)
+
+	if ref, ok := strings.CutPrefix(response.Ref, "#/components/responses/"); ok {
+		response = doc.Components.Responses[ref]
+		require.NotNil(t, response, ref)
+	}
+
+	content, ok := response.Content["application/json"]
+	if !ok || w.Code < http.StatusBadRequest {
+		return
+	}
+
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"), "%s %s answered %d", method, path, w.Code)
+
+	var body any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "%s %s answered %d: %s", method, path, w.Code, w.Body.String())
+	requireMatchesSchema(t, doc, content.Schema, body, fmt.Sprintf("%s %s answered %d", method, path, w.Code))
+}
+
+// requireMatchesSchema checks required and unknown properties and enums of the value,
+// schemas are followed by references
+func requireMatchesSchema(t *testing.T, doc *openAPIDocument, property openAPIProperty, value any, msg string) {
+	if len(property.Enum) != 0 {
+		require.Contains(t, property.Enum, value, msg)
+	}
+
+	name, ok := strings.CutPrefix(property.Ref, "#/components/schemas/")
+	if !ok {
+		return
+	}
+
+	schema := doc.Components.Schemas[name]
+	require.NotNil(t, schema, name)
+
+	object, ok := value.(map[string]any)
+	require.True(t, ok, "%s: %s isn't an object", msg, name)
+
+	for _, required := range schema.Required {
+		require.Contains(t, object, required, "%s: %s", msg, name)
+	}
+
+	for key, child := range object {
+		raw, ok := schema.Properties[key]
+		require.True(t, ok, "%s: %s doesn't have property %s", msg, name, key)
+
+		childProperty := openAPIProperty{}
+		require.NoError(t, json.Unmarshal(raw, &childProperty))
+
+		requireMatchesSchema(t, doc, childProperty, child, msg)
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal(handler.OpenAPISpec, &doc))
+
+	var walk func(node any)
+	walk = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			if ref, ok := node["$ref"].(string); ok {
+				target := doc
+				for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					object, ok := target.(map[string]any)
+					require.True(t, ok, ref)
+
+					target, ok = object[name]
+					require.True(t, ok, ref)
+				}
+			}
+
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+
+	walk(doc)
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	types := map[string]any{
+		"ErrorResponse":                handler.ErrorResponse{},
+		"APIError":                     handler.APIError{},
+		"HealthResponse":               handler.HealthResponse{},
+		"RegistrationResponse":         handler.RegistrationResponse{},
+		"TokenRequest":                 handler.TokenRequest{},
+		"GetDataRequest":               handler.GetDataRequest{},
+		"GetDataResponse":              handler.GetDataResponse{},
+		"SaveDataRequest":              handler.SaveDataRequest{},
+		"UpdateDataRequest":            handler.UpdateDataRequest{},
+		"Chunk":                        handler.Chunk{},
+		"PatchDataRequest":             handler.PatchDataRequest{},
+		"DeleteDataRequest":            handler.DeleteDataRequest{},
+		"DataInfo":                     handler.DataInfo{},
+		"ListDataResponse":             handler.ListDataResponse{},
+		"GetSecretDataRequest":         handler.GetSecretDataRequest{},
+		"GetSecretResponse":            handler.GetSecretResponse{},
+		"SaveSecretRequest":            handler.SaveSecretRequest{},
+		"DeleteSecretRequest":          handler.DeleteSecretRequest{},
+		"SecretInfo":                   handler.SecretInfo{},
+		"SecretListResponse":           handler.SecretListResponse{},
+		"GetCardDataRequest":           handler.GetCardDataRequest{},
+		"GetCardDataResponse":          handler.GetCardDataResponse{},
+		"SaveCardDataRequest":          handler.SaveCardDataRequest{},
+		"DeleteCardDataRequest":        handler.DeleteCardDataRequest{},
+		"CardInfo":                     handler.CardInfo{},
+		"GetCardsResponse":             handler.GetCardsResponse{},
+		"GetSSHKeyRequest":             handler.GetSSHKeyRequest{},
+		"GetSSHKeyResponse":            handler.GetSSHKeyResponse{},
+		"SaveSSHKeyRequest":            handler.SaveSSHKeyRequest{},
+		"DeleteSSHKeyRequest":          handler.DeleteSSHKeyRequest{},
+		"SSHKeyInfo":                   handler.SSHKeyInfo{},
+		"SSHKeyListResponse":           handler.SSHKeyListResponse{},
+		"Scope":                        handler.Scope{},
+		"CreateServiceAccountRequest":  handler.CreateServiceAccountRequest{},
+		"CreateServiceAccountResponse": handler.CreateServiceAccountResponse{},
+		"DeleteServiceAccountRequest":  handler.DeleteServiceAccountRequest{},
+		"ServiceAccount":               handler.ServiceAccount{},
+		"ServiceAccountListResponse":   handler.ServiceAccountListResponse{},
+		"AuditEvent":                   handler.AuditEvent{},
+		"AuditResponse":                handler.AuditResponse{},
+		"UsageResponse":                handler.UsageResponse{},
+		"ChangeEvent":                  handler.ChangeEvent{},
+		"Item":                         handler.Item{},
+		"ItemInfo":                     handler.ItemInfo{},
+		"ItemListResponse":             handler.ItemListResponse{},
+		"PutItemRequest":               handler.PutItemRequest{},
+	}
+
+	doc := &openAPIDocument{}
+	require.NoError(t, json.Unmarshal(handler.OpenAPISpec, doc))
+
+	for name, schema := range doc.Components.Schemas {
+		value, ok := types[name]
+		require.True(t, ok, "schema %s doesn't have type", name)
+
+		fields := jsonFields(reflect.TypeOf(value))
+
+		properties := []string{}
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		require.ElementsMatch(t, fields, properties, name)
+
+		for _, required := range schema.Required {
+			require.True(t, slices.Contains(fields, required), "%s.%s", name, required)
+		}
+	}
+
+	require.Len(t, doc.Components.Schemas, len(types))
+}
+
+func jsonFields(t reflect.Type) []string {
+	fields := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
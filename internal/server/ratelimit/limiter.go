@@ -0,0 +1,161 @@
+// Package ratelimit throttles clients by token buckets and locks out clients which fail authentication
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// entries which weren't used during the interval are removed
+const pruneInterval = time.Minute
+
+// Rate is a token bucket, requests are allowed while the bucket has tokens
+type Rate struct {
+	// PerSecond is refill rate of tokens, zero rate isn't limited
+	PerSecond float64
+	// Burst is capacity of the bucket, it's rounded up PerSecond when it's zero
+	Burst int
+}
+
+// Lockout blocks client after Threshold failures for Base which is doubled by every next failure up to Max
+type Lockout struct {
+	// Threshold is a number of allowed failures, zero threshold doesn't lock out
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+type entry struct {
+	tokens   float64
+	updated  time.Time
+	failures int
+	failed   time.Time
+	locked   time.Time
+}
+
+// Limiter keeps buckets and failures of clients' keys, e.g. ip or login
+type Limiter struct {
+	rate    Rate
+	lockout Lockout
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastPrune time.Time
+
+	now func() time.Time
+}
+
+func New(rate Rate, lockout Lockout) *Limiter {
+	// empty bucket would reject all requests
+	if rate.Burst <= 0 {
+		rate.Burst = max(int(math.Ceil(rate.PerSecond)), 1)
+	}
+
+	return &Limiter{
+		rate:    rate,
+		lockout: lockout,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Allow takes the token of the key, it returns time to wait when the key is limited or locked out
+func (l *Limiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	e := l.entry(key, now)
+
+	if now.Before(e.locked) {
+		return e.locked.Sub(now), false
+	}
+
+	if l.rate.PerSecond <= 0 {
+		return 0, true
+	}
+
+	e.tokens = min(float64(l.rate.Burst), e.tokens+now.Sub(e.updated).Seconds()*l.rate.PerSecond)
+	e.updated = now
+
+	if e.tokens < 1 {
+		wait := time.Duration((1 - e.tokens) / l.rate.PerSecond * float64(time.Second))
+
+		return wait, false
+	}
+
+	e.tokens--
+
+	return 0, true
+}
+
+// Fail counts failed authentication of the key and locks the key out when failures exceed the threshold
+func (l *Limiter) Fail(key string) {
+	if l.lockout.Threshold <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e := l.entry(key, now)
+
+	e.failures++
+	e.failed = now
+
+	if e.failures < l.lockout.Threshold {
+		return
+	}
+
+	duration := l.lockout.Base
+	for i := l.lockout.Threshold; i < e.failures && duration < l.lockout.Max; i++ {
+		duration *= 2
+	}
+
+	e.locked = now.Add(min(duration, l.lockout.Max))
+}
+
+// Succeed resets failures of the key
+func (l *Limiter) Succeed(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.entries[key]; ok {
+		e.failures = 0
+		e.locked = time.Time{}
+	}
+}
+
+func (l *Limiter) entry(key string, now time.Time) *entry {
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry{tokens: float64(l.rate.Burst), updated: now}
+		l.entries[key] = e
+	}
+
+	return e
+}
+
+// prune removes entries with full buckets and without failures, they are the same as new entries,
+// failures are forgotten when the key doesn't fail during the longest lockout
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+
+	l.lastPrune = now
+
+	for key, e := range l.entries {
+		if e.failures != 0 && now.Sub(e.failed) < max(l.lockout.Max, pruneInterval) {
+			continue
+		}
+
+		if l.rate.PerSecond <= 0 || e.tokens+now.Sub(e.updated).Seconds()*l.rate.PerSecond >= float64(l.rate.Burst) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(rate Rate, lockout Lockout) (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l := New(rate, lockout)
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func TestLimiterRate(t *testing.T) {
+	l, now := newTestLimiter(Rate{PerSecond: 2, Burst: 2}, Lockout{})
+
+	for i := 0; i < 2; i++ {
+		_, ok := l.Allow("ip")
+		require.True(t, ok)
+	}
+
+	wait, ok := l.Allow("ip")
+	require.False(t, ok)
+	require.Equal(t, time.Millisecond*500, wait)
+
+	// other keys have own buckets
+	_, ok = l.Allow("other-ip")
+	require.True(t, ok)
+
+	*now = now.Add(time.Millisecond * 500)
+
+	_, ok = l.Allow("ip")
+	require.True(t, ok)
+}
+
+func TestLimiterDefaultBurst(t *testing.T) {
+	l, now := newTestLimiter(Rate{PerSecond: 2.5}, Lockout{})
+
+	for i := 0; i < 3; i++ {
+		_, ok := l.Allow("ip")
+		require.True(t, ok)
+	}
+
+	_, ok := l.Allow("ip")
+	require.False(t, ok)
+
+	*now = now.Add(time.Second)
+
+	_, ok = l.Allow("ip")
+	require.True(t, ok)
+}
+
+func TestLimiterLockout(t *testing.T) {
+	l, now := newTestLimiter(Rate{}, Lockout{Threshold: 2, Base: time.Second, Max: time.Second * 3})
+
+	l.Fail("login")
+
+	_, ok := l.Allow("login")
+	require.True(t, ok)
+
+	l.Fail("login")
+
+	wait, ok := l.Allow("login")
+	require.False(t, ok)
+	require.Equal(t, time.Second, wait)
+
+	// every next failure doubles lockout up to max
+	for _, expected := range []time.Duration{time.Second * 2, time.Second * 3, time.Second * 3} {
+		l.Fail("login")
+
+		wait, ok = l.Allow("login")
+		require.False(t, ok)
+		require.Equal(t, expected, wait)
+	}
+
+	*now = now.Add(time.Second * 3)
+
+	_, ok = l.Allow("login")
+	require.True(t, ok)
+
+	l.Succeed("login")
+	l.Fail("login")
+
+	_, ok = l.Allow("login")
+	require.True(t, ok)
+}
+
+func TestLimiterPrune(t *testing.T) {
+	l, now := newTestLimiter(Rate{PerSecond: 1, Burst: 1}, Lockout{Threshold: 1, Base: time.Second, Max: time.Minute * 5})
+
+	l.Allow("ip")
+	l.Fail("login")
+
+	*now = now.Add(pruneInterval)
+	l.Allow("other")
+
+	// failures are kept during the longest lockout
+	require.NotContains(t, l.entries, "ip")
+	require.Contains(t, l.entries, "login")
+
+	*now = now.Add(time.Minute * 5)
+	l.Allow("other")
+
+	require.NotContains(t, l.entries, "login")
+}
@@ -4,57 +4,216 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/kuzhukin/goph-keeper/internal/server/blobstore"
 	"github.com/kuzhukin/goph-keeper/internal/server/config"
 	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
 	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 	"github.com/kuzhukin/goph-keeper/internal/server/middleware"
+	"github.com/kuzhukin/goph-keeper/internal/server/ratelimit"
 	"github.com/kuzhukin/goph-keeper/internal/server/sql"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage/memstorage"
 	"github.com/kuzhukin/goph-keeper/internal/zlog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
 	httpServer http.Server
-	storage    *sql.Storage
+	storage    storage.Storage
+	notifier   *handler.ChangeNotifier
+	readiness  *handler.ReadinessHandler
+	// shutdownDelay is waited by Stop before shutdown of http server
+	shutdownDelay time.Duration
 
 	wait chan struct{}
 }
 
 func StartNew(config *config.Config) (*Server, error) {
-	storage, err := sql.StartNewStorage(config.DataSourceName)
+	storage, err := startStorage(config)
 	if err != nil {
-		return nil, fmt.Errorf("start db storage, err=%w", err)
+		return nil, fmt.Errorf("start %s storage, err=%w", config.StorageDriver, err)
 	}
 
+	notifier := handler.NewChangeNotifier()
+	readiness := handler.NewReadinessHandler(storage)
+
+	router, err := newRouter(config, storage, notifier, readiness)
+	if err != nil {
+		return nil, errors.Join(err, storage.Stop())
+	}
+
+	server := &Server{
+		httpServer: http.Server{Addr: config.Hostport, Handler: router},
+		storage:    storage,
+		notifier:   notifier,
+		readiness:  readiness,
+		wait:       make(chan struct{}),
+
+		shutdownDelay: config.DrainDelay(),
+	}
+
+	server.start()
+
+	return server, nil
+}
+
+func newRouter(
+	config *config.Config, storage storage.Storage, notifier *handler.ChangeNotifier, readiness *handler.ReadinessHandler,
+) (*chi.Mux, error) {
 	router := chi.NewRouter()
 
-	authMiddleware := middleware.NewAuthMiddleware(storage)
+	if config.EnableMetrics {
+		if err := setupMetrics(router, storage); err != nil {
+			return nil, fmt.Errorf("setup metrics, err=%w", err)
+		}
+	}
+
+	// probes are called by orchestrator which doesn't have user's token
+	router.Handle(endpoint.HealthEndpoint, handler.NewHealthHandler())
+	router.Handle(endpoint.ReadyEndpoint, readiness)
+
+	// document is fetched by clients' generators
+	router.Handle(endpoint.OpenAPIEndpoint, handler.NewOpenAPIHandler())
+
+	router.Group(func(router chi.Router) {
+		setupAPI(router, config, storage, notifier)
+	})
+
+	return router, nil
+}
+
+func startStorage(config *config.Config) (storage.Storage, error) {
+	if config.StorageDriver == storage.DriverMemory {
+		return memstorage.New(), nil
+	}
+
+	blobs, err := startBlobStore(&config.BlobStore)
+	if err != nil {
+		return nil, fmt.Errorf("start %s blob store, err=%w", config.BlobStore.Driver, err)
+	}
+
+	switch config.StorageDriver {
+	case storage.DriverPostgres, "":
+		return sql.StartNewStorage(config.DataSourceName, blobs)
+	case storage.DriverSQLite:
+		return sql.StartNewSQLiteStorage(config.DataSourceName, blobs)
+	default:
+		return nil, fmt.Errorf("unknown storage driver=%s", config.StorageDriver)
+	}
+}
+
+// startBlobStore returns nil store when payloads are kept in db
+func startBlobStore(config *config.BlobStore) (blobstore.BlobStore, error) {
+	switch config.Driver {
+	case "":
+		return nil, nil
+	case blobstore.DriverFS:
+		return blobstore.NewFileStore(config.Path)
+	case blobstore.DriverS3:
+		return blobstore.NewS3Store(&blobstore.S3Config{
+			Endpoint:  config.Endpoint,
+			Region:    config.Region,
+			Bucket:    config.Bucket,
+			AccessKey: config.AccessKey,
+			SecretKey: config.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store driver=%s", config.Driver)
+	}
+}
+
+// setupAPI routes user's requests which are authorized by token
+func setupAPI(router chi.Router, config *config.Config, storage storage.Storage, notifier *handler.ChangeNotifier) {
+	authMiddleware := middleware.NewAuthMiddleware(storage, storage)
 
+	// failed authentications are counted by rate limiter, so it wraps auth
+	router.Use(newRateLimitMiddleware(&config.RateLimit).Middleware)
 	router.Use(authMiddleware.Middleware)
+	router.Use(middleware.BodyLimitMiddleware(config.RequestSizeLimit()))
 
-	router.Handle(endpoint.RegisterEndpoint, handler.NewRegistrationHandler(storage))
+	quota := &handler.Quota{
+		MaxBytes:    config.Quota.MaxBytes,
+		MaxItems:    config.Quota.MaxItems,
+		MaxItemSize: config.Quota.MaxItemSize,
+	}
+	quotaController := handler.NewQuotaController(quota, storage)
 
-	router.Handle(endpoint.BinaryDataEndpoint, handler.NewDataHandler(storage))
-	router.Handle(endpoint.BinariesDataEndpoint, handler.NewListDataHandler(storage))
+	router.Handle(endpoint.RegisterEndpoint, handler.NewRegistrationHandler(storage, storage))
 
-	router.Handle(endpoint.WalletEndpoint, handler.NewWalletHandler(storage))
-	router.Handle(endpoint.WalletsEndpoint, handler.NewWalletListHandler(storage))
+	router.Handle(endpoint.BinaryDataEndpoint, handler.NewDataHandler(storage, notifier, storage, quotaController))
+	router.Handle(endpoint.BinariesDataEndpoint, handler.NewListDataHandler(storage, storage))
 
-	router.Handle(endpoint.SecretEndpoint, handler.NewSecretDataHandler(storage))
-	router.Handle(endpoint.SecretsEndpoint, handler.NewSecretListHandler(storage))
+	router.Handle(endpoint.WalletEndpoint, handler.NewWalletHandler(storage, notifier, storage, quotaController))
+	router.Handle(endpoint.WalletsEndpoint, handler.NewWalletListHandler(storage, storage))
 
-	server := &Server{
-		httpServer: http.Server{Addr: config.Hostport, Handler: router},
-		storage:    storage,
-		wait:       make(chan struct{}),
+	router.Handle(endpoint.SecretEndpoint, handler.NewSecretDataHandler(storage, notifier, storage, quotaController))
+	router.Handle(endpoint.SecretsEndpoint, handler.NewSecretListHandler(storage, storage))
+
+	router.Handle(endpoint.SSHKeyEndpoint, handler.NewSSHKeyHandler(storage, notifier, storage, quotaController))
+	router.Handle(endpoint.SSHKeysEndpoint, handler.NewSSHKeyListHandler(storage, storage))
+
+	router.Handle(endpoint.UsageEndpoint, handler.NewUsageHandler(storage, quota))
+
+	router.Handle(endpoint.ServiceAccountEndpoint, handler.NewServiceAccountHandler(storage, storage))
+	router.Handle(endpoint.ServiceAccountsEndpoint, handler.NewServiceAccountListHandler(storage, storage))
+
+	router.Handle(endpoint.AuditEndpoint, handler.NewAuditHandler(storage))
+
+	router.Handle(endpoint.ChangesEndpoint, handler.NewChangesHandler(notifier))
+
+	// v2 addresses items by paths, v1 routes are kept for existing clients
+	router.Handle(endpoint.TokensV2Endpoint, handler.NewTokenHandler(storage, storage))
+	router.Handle(endpoint.ItemsV2Endpoint+"/*", handler.NewItemsHandler(storage, notifier, storage, quotaController))
+}
+
+func newRateLimitMiddleware(config *config.RateLimit) *middleware.RateLimitMiddleware {
+	lockout := ratelimit.Lockout{Threshold: config.LockoutThreshold, Base: config.LockoutBase, Max: config.LockoutMax}
+
+	ips := ratelimit.New(ratelimit.Rate{PerSecond: config.RequestsPerSecond, Burst: config.Burst}, lockout)
+	logins := ratelimit.New(ratelimit.Rate{PerSecond: config.LoginsPerMinute / 60, Burst: int(math.Ceil(config.LoginsPerMinute))}, lockout)
+
+	return middleware.NewRateLimitMiddleware(ips, logins)
+}
+
+// metricsRegisterer is a storage which exposes own metrics
+type metricsRegisterer interface {
+	RegisterMetrics(registerer prometheus.Registerer) error
+}
+
+// setupMetrics exposes metrics on unauthorized endpoint and counts all requests including unauthorized
+func setupMetrics(router chi.Router, storage storage.Storage) error {
+	registry := prometheus.NewRegistry()
+
+	if err := registry.Register(collectors.NewGoCollector()); err != nil {
+		return err
 	}
 
-	server.start()
+	if err := registry.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
+		return err
+	}
 
-	return server, nil
+	if r, ok := storage.(metricsRegisterer); ok {
+		if err := r.RegisterMetrics(registry); err != nil {
+			return err
+		}
+	}
+
+	metricsMiddleware, err := middleware.NewMetricsMiddleware(registry)
+	if err != nil {
+		return err
+	}
+
+	router.Use(metricsMiddleware.Middleware)
+	router.Handle(endpoint.MetricsEndpoint, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	return nil
 }
 
 func (s *Server) start() {
@@ -71,13 +230,20 @@ func (s *Server) start() {
 }
 
 func (s *Server) Stop() error {
+	// orchestrator stops routing requests to the stopping server after the next readiness probe
+	s.readiness.SetStopping()
+	time.Sleep(s.shutdownDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	return errors.Join(
-		s.storage.Stop(),
-		s.httpServer.Shutdown(ctx),
-	)
+	// change streams are never finished by themselves
+	s.notifier.Close()
+
+	// storage is stopped after in-flight requests are finished
+	shutdownErr := s.httpServer.Shutdown(ctx)
+
+	return errors.Join(shutdownErr, s.storage.Stop())
 }
 
 func (s *Server) WaitStop() <-chan struct{} {
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kuzhukin/goph-keeper/internal/server/endpoint"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage/memstorage"
+	"github.com/stretchr/testify/require"
+)
+
+type stopRecorder struct {
+	storage.Storage
+	stopped atomic.Bool
+}
+
+func (s *stopRecorder) Stop() error {
+	s.stopped.Store(true)
+
+	return s.Storage.Stop()
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func TestStopDrainsRequestsBeforeStorage(t *testing.T) {
+	storage := &stopRecorder{Storage: memstorage.New()}
+	readiness := handler.NewReadinessHandler(storage)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	stoppedInRequest := atomic.Bool{}
+
+	router := chi.NewRouter()
+	router.Handle(endpoint.ReadyEndpoint, readiness)
+	router.Get("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(entered)
+		<-release
+		stoppedInRequest.Store(storage.stopped.Load())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	addr := freeAddr(t)
+	server := &Server{
+		httpServer:    http.Server{Addr: addr, Handler: router},
+		storage:       storage,
+		notifier:      handler.NewChangeNotifier(),
+		readiness:     readiness,
+		wait:          make(chan struct{}),
+		shutdownDelay: time.Millisecond * 300,
+	}
+	server.start()
+
+	ready := func() int {
+		resp, err := http.Get("http://" + addr + endpoint.ReadyEndpoint)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+	require.Eventually(t, func() bool { return ready() == http.StatusOK }, time.Second, time.Millisecond*10)
+
+	slow := make(chan int)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			slow <- 0
+
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	<-entered
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- server.Stop()
+	}()
+
+	// probe sees stopping server during the delay
+	require.Eventually(t, func() bool { return ready() == http.StatusServiceUnavailable }, time.Second, time.Millisecond*10)
+	require.False(t, storage.stopped.Load())
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-slow)
+	require.False(t, stoppedInRequest.Load())
+
+	require.NoError(t, <-stopped)
+	require.True(t, storage.stopped.Load())
+	<-server.WaitStop()
+}
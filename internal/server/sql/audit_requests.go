@@ -0,0 +1,24 @@
+package sql
+
+import "github.com/kuzhukin/goph-keeper/internal/server/handler"
+
+const (
+	// user is found by token when login isn't known
+	addAuditEvent = `INSERT INTO audit_log ("user", "time", "service_account", "device", "action", "kind", "key", "ip", "result")
+		SELECT COALESCE(NULLIF($1, ''), (SELECT "login" FROM users WHERE "token" = $2 LIMIT 1)), $3, $4, $5, $6, $7, $8, $9, $10
+		WHERE NULLIF($1, '') IS NOT NULL OR EXISTS (SELECT 1 FROM users WHERE "token" = $2);`
+
+	listAuditEvents = `SELECT "time", "service_account", "device", "action", "kind", "key", "ip", "result"
+		FROM audit_log WHERE "user" = $1 ORDER BY "time" DESC, "id" DESC LIMIT $2;`
+)
+
+func prepareAddAuditEvent(e *handler.AuditEvent) *query {
+	return &query{
+		request: addAuditEvent,
+		args:    []any{e.Login, e.UserToken, e.Time, e.ServiceAccount, e.Device, e.Action, e.Kind, e.Key, e.IP, e.Result},
+	}
+}
+
+func prepareListAuditEvents(user string, limit int) *query {
+	return &query{request: listAuditEvents, args: []any{user, limit}}
+}
@@ -1,38 +1,64 @@
 package sql
 
 const (
-	createBinaryDataTableQuery = `CREATE TABLE IF NOT EXISTS binary_data (
-		"user"			text	NOT NULL,
-		"key"			text	NOT NULL,
-		"value"			text	NOT NULL,
-		"revision"		bigint 	NOT NULL,
-		"metainfo"      text,
-		PRIMARY KEY ( "user", "key" )
-	);`
-
-	addNewBinaryDataQuery = `INSERT INTO binary_data ("user", "key", "value", "revision") VALUES ($1, $2, $3, 1);`
-	updateBinaryDataQuery = `UPDATE binary_data SET "value" = $3, "revision" = "revision" + 1 WHERE "user" = $1 AND "key" = $2;`
-	getBinaryData         = `SELECT "value", "revision" FROM binary_data WHERE "user" = $1 AND "key" = $2;`
-	deleteBinaryData      = `DELETE FROM binary_data WHERE "user" = $1 AND "key" = $2;`
-	listBinaryData        = `SELECT "key", "value", "revision" FROM binary_data WHERE "user" = $1;`
+	addNewBinaryDataQuery = `INSERT INTO binary_data ("user", "key", "revision", "metainfo", "content_hash", "updated_at") VALUES ($1, $2, 1, $3, $4, $5);`
+	updateBinaryDataQuery = `UPDATE binary_data SET "metainfo" = $1, "content_hash" = $2, "updated_at" = $3, "revision" = "revision" + 1
+	WHERE "user" = $4 AND "key" = $5 AND "revision" = $6;`
+	getBinaryData = `SELECT b."value", d."revision", COALESCE(d."metainfo", ''), d."content_hash", b."blob_key", b."encoding"
+	FROM binary_data d JOIN blobs b ON b."user" = d."user" AND b."content_hash" = d."content_hash" WHERE d."user" = $1 AND d."key" = $2;`
+	deleteBinaryData = `DELETE FROM binary_data WHERE "user" = $1 AND "key" = $2;`
+
+	getBlobQuery = `SELECT "value", "size", "blob_key", "encoding" FROM blobs WHERE "user" = $1 AND "content_hash" = $2;`
+	// concurrent upload of the same content only references the stored blob, returned key shows whose blob is kept
+	addBlobQuery = `INSERT INTO blobs ("user", "content_hash", "value", "size", "blob_key", "encoding", "refs") VALUES ($1, $2, $3, $4, $5, $6, 1)
+	ON CONFLICT ("user", "content_hash") DO UPDATE SET "refs" = blobs."refs" + 1 RETURNING "blob_key";`
+	referenceBlobQuery = `UPDATE blobs SET "refs" = "refs" + 1 WHERE "user" = $1 AND "content_hash" = $2;`
+	releaseBlobQuery   = `UPDATE blobs SET "refs" = "refs" - 1 WHERE "user" = $1 AND "content_hash" = $2 RETURNING "refs", "blob_key";`
+	deleteBlobQuery    = `DELETE FROM blobs WHERE "user" = $1 AND "content_hash" = $2 AND "refs" = 0;`
 )
 
-func prepareNewDataQuery(user, key, value string) *query {
-	return &query{request: addNewBinaryDataQuery, args: []any{user, key, value}}
+func prepareNewDataQuery(user string, row *dataRow) *query {
+	return &query{
+		request: addNewBinaryDataQuery,
+		args:    []any{user, row.record.Name, row.record.Metainfo, row.hash, updateTime()},
+	}
 }
 
 func prepareGetDataQuery(user, key string) *query {
 	return &query{request: getBinaryData, args: []any{user, key}}
 }
 
-func prepareUpdateDataQuery(user, key, data string) *query {
-	return &query{request: updateBinaryDataQuery, args: []any{user, key, data}}
+// prepareUpdateDataQuery doesn't change the row when its revision was changed concurrently
+func prepareUpdateDataQuery(user string, row *dataRow) *query {
+	return &query{
+		request: updateBinaryDataQuery,
+		args:    []any{row.record.Metainfo, row.hash, updateTime(), user, row.record.Name, row.record.Revision},
+	}
 }
 
 func prepareDeleteDataQuery(user, key string) *query {
 	return &query{request: deleteBinaryData, args: []any{user, key}}
 }
 
-func prepareListBinaryData(user string) *query {
-	return &query{request: listBinaryData, args: []any{user}}
+func prepareGetBlobQuery(user, hash string) *query {
+	return &query{request: getBlobQuery, args: []any{user, hash}}
+}
+
+func prepareAddBlobQuery(user string, row *dataRow) *query {
+	return &query{
+		request: addBlobQuery,
+		args:    []any{user, row.hash, row.value, row.size, row.blobKey, row.encoding},
+	}
+}
+
+func prepareReferenceBlobQuery(user, hash string) *query {
+	return &query{request: referenceBlobQuery, args: []any{user, hash}}
+}
+
+func prepareReleaseBlobQuery(user, hash string) *query {
+	return &query{request: releaseBlobQuery, args: []any{user, hash}}
+}
+
+func prepareDeleteBlobQuery(user, hash string) *query {
+	return &query{request: deleteBlobQuery, args: []any{user, hash}}
 }
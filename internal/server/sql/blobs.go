@@ -0,0 +1,280 @@
+package sql
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/zlog"
+)
+
+// timeout of blob store's requests, blobs are much bigger than db's rows
+const blobTimeout = time.Minute
+
+// encodingBase64 means that blob keeps decoded bytes of client's base64 ciphertext,
+// ciphertext isn't decoded when client sends data in another format
+const encodingBase64 = "base64"
+
+// dataRow is a row of binary_data with its blob, record's data is kept in value column when blob key is empty
+type dataRow struct {
+	record *handler.Record
+
+	value    string
+	size     int
+	hash     string
+	blobKey  string
+	encoding string
+	// uploaded is set when the record's data was written by the request, otherwise the row references stored blob
+	uploaded bool
+}
+
+// prepareRow makes row of the record, record's data isn't uploaded again when user has blob with the same content,
+// data of the record which has only content hash is read from the stored blob
+func (c *Storage) prepareRow(baseCtx context.Context, ctx context.Context, login string, record *handler.Record) (*dataRow, error) {
+	row, err := c.getBlob(ctx, login, record)
+	if err == nil {
+		if len(record.Data) == 0 {
+			if err := c.loadPayload(baseCtx, row); err != nil {
+				return nil, err
+			}
+		}
+
+		return row, nil
+	}
+
+	if !errors.Is(err, handler.ErrContentNotFound) || len(record.Data) == 0 {
+		return nil, err
+	}
+
+	row = &dataRow{record: record, hash: handler.ContentHash(record)}
+	if err := c.putPayload(baseCtx, login, row); err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+// getBlob reads user's blob with the record's content hash in own transaction
+func (c *Storage) getBlob(ctx context.Context, login string, record *handler.Record) (*dataRow, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	hash := handler.ContentHash(record)
+
+	row, err := doTransactionQuery(ctx, tx, prepareGetBlobQuery(login, hash), func(rows *sql.Rows) (*dataRow, error) {
+		if !rows.Next() {
+			return nil, fmt.Errorf("data=%s, hash=%s, err=%w", record.Name, hash, handler.ErrContentNotFound)
+		}
+
+		row := &dataRow{record: record, hash: hash}
+		if err := rows.Scan(&row.value, &row.size, &row.blobKey, &row.encoding); err != nil {
+			return nil, err
+		}
+
+		return row, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return row, tx.Commit()
+}
+
+// putPayload uploads record's data to blob store when it's configured
+func (c *Storage) putPayload(ctx context.Context, login string, row *dataRow) error {
+	row.uploaded = true
+
+	if c.blobs == nil {
+		row.value = row.record.Data
+		row.size = len(row.record.Data)
+
+		return nil
+	}
+
+	payload, encoding := encodePayload(row.record.Data)
+
+	key, err := newBlobKey(login, row.record.Name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, blobTimeout)
+	defer cancel()
+
+	if err := c.blobs.Put(ctx, key, payload); err != nil {
+		return fmt.Errorf("put blob of data=%s, err=%w", row.record.Name, err)
+	}
+
+	row.size = len(payload)
+	row.blobKey = key
+	row.encoding = encoding
+
+	return nil
+}
+
+// loadPayload sets record's data from blob store when the row's payload isn't kept in db
+func (c *Storage) loadPayload(ctx context.Context, row *dataRow) error {
+	if len(row.blobKey) == 0 {
+		row.record.Data = row.value
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, blobTimeout)
+	defer cancel()
+
+	payload, err := c.blobs.Get(ctx, row.blobKey)
+	if err != nil {
+		return fmt.Errorf("get blob of data=%s, err=%w", row.record.Name, err)
+	}
+
+	row.record.Data = decodePayload(payload, row.encoding)
+
+	return nil
+}
+
+// discardPayload removes blob which was uploaded by the failed request
+func (c *Storage) discardPayload(ctx context.Context, row *dataRow) {
+	if row.uploaded {
+		c.deleteBlob(ctx, row.blobKey)
+	}
+}
+
+// deleteBlob removes blob which isn't referenced by db, failed removing leaves garbage but not broken data
+func (c *Storage) deleteBlob(ctx context.Context, blobKey string) {
+	if len(blobKey) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), blobTimeout)
+	defer cancel()
+
+	if err := c.blobs.Delete(ctx, blobKey); err != nil {
+		zlog.Logger().Errorf("delete blob=%s, err=%s", blobKey, err)
+	}
+}
+
+// referenceBlobInTransaction adds the row's reference to its blob, it returns key of uploaded blob
+// which isn't needed because the same content was stored concurrently
+func referenceBlobInTransaction(ctx context.Context, tx *sql.Tx, login string, row *dataRow) (string, error) {
+	if !row.uploaded {
+		q := prepareReferenceBlobQuery(login, row.hash)
+
+		result, err := doQuery(ctx, func(ctx context.Context) (sql.Result, error) {
+			return tx.ExecContext(ctx, q.request, q.args...)
+		})
+		if err != nil {
+			return "", err
+		}
+
+		referenced, err := result.RowsAffected()
+		if err != nil {
+			return "", err
+		}
+
+		if referenced == 0 {
+			// the blob was released after it had been read
+			return "", fmt.Errorf("data=%s, hash=%s, err=%w", row.record.Name, row.hash, handler.ErrContentNotFound)
+		}
+
+		return "", nil
+	}
+
+	storedKey, err := doTransactionQuery(ctx, tx, prepareAddBlobQuery(login, row), func(rows *sql.Rows) (string, error) {
+		var key string
+		if !rows.Next() {
+			return key, sql.ErrNoRows
+		}
+
+		return key, rows.Scan(&key)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if storedKey != row.blobKey {
+		return row.blobKey, nil
+	}
+
+	return "", nil
+}
+
+// releaseBlobInTransaction removes the row's reference to the blob, it returns key of the blob
+// which isn't referenced anymore, the blob must be deleted after commit
+func releaseBlobInTransaction(ctx context.Context, tx *sql.Tx, login string, hash string) (string, error) {
+	type release struct {
+		refs    int64
+		blobKey string
+	}
+
+	released, err := doTransactionQuery(ctx, tx, prepareReleaseBlobQuery(login, hash), func(rows *sql.Rows) (*release, error) {
+		r := &release{}
+		if !rows.Next() {
+			return nil, fmt.Errorf("blob of hash=%s isn't found, err=%w", hash, handler.ErrInternalProblem)
+		}
+
+		return r, rows.Scan(&r.refs, &r.blobKey)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if released.refs > 0 {
+		return "", nil
+	}
+
+	if err := doTransactionExec(ctx, tx, prepareDeleteBlobQuery(login, hash)); err != nil {
+		return "", err
+	}
+
+	return released.blobKey, nil
+}
+
+func scanDataRow(rows *sql.Rows, row *dataRow) error {
+	return rows.Scan(&row.value, &row.record.Revision, &row.record.Metainfo, &row.hash, &row.blobKey, &row.encoding)
+}
+
+// encodePayload decodes base64 ciphertext, so blob doesn't keep a third more bytes than the ciphertext
+func encodePayload(data string) ([]byte, string) {
+	payload, err := base64.RawStdEncoding.Strict().DecodeString(data)
+	if err != nil {
+		return []byte(data), ""
+	}
+
+	return payload, encodingBase64
+}
+
+func decodePayload(payload []byte, encoding string) string {
+	if encoding == encodingBase64 {
+		return base64.RawStdEncoding.EncodeToString(payload)
+	}
+
+	return string(payload)
+}
+
+// newBlobKey groups user's blobs by hashes of names, so key doesn't depend on names' characters,
+// every write gets a new blob, so the row's blob isn't overwritten until the row references another one
+func newBlobKey(login string, name string) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return hashHex([]byte(login)) + "/" + hashHex([]byte(name)) + "/" + hex.EncodeToString(suffix), nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,258 @@
+package sql
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/blobstore"
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataPayloadInBlobStore(t *testing.T) {
+	ctx := context.Background()
+	blobsDir := filepath.Join(t.TempDir(), "blobs")
+
+	blobs, err := blobstore.NewFileStore(blobsDir)
+	require.NoError(t, err)
+
+	s, err := StartNewSQLiteStorage(filepath.Join(t.TempDir(), "server.db"), blobs)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+
+	ciphertext := []byte("encrypted data")
+	data := base64.RawStdEncoding.EncodeToString(ciphertext)
+
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "file", Data: data}))
+
+	row := loadDataRow(t, s, "file")
+	require.Empty(t, row.value)
+	require.Equal(t, len(ciphertext), row.size)
+	require.Equal(t, handler.ContentHash(&handler.Record{Data: data}), row.hash)
+	require.Equal(t, encodingBase64, row.encoding)
+
+	// blob keeps decoded ciphertext
+	stored, err := os.ReadFile(filepath.Join(blobsDir, filepath.FromSlash(row.blobKey)))
+	require.NoError(t, err)
+	require.Equal(t, ciphertext, stored)
+
+	r, err := s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, data, r.Data)
+
+	// update writes a new blob and removes the previous one
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "file", Data: "not base64!", Revision: 1}))
+
+	_, err = blobs.Get(ctx, row.blobKey)
+	require.ErrorIs(t, err, blobstore.ErrBlobNotFound)
+
+	updated := loadDataRow(t, s, "file")
+	require.Empty(t, updated.encoding)
+
+	r, err = s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, "not base64!", r.Data)
+
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "file"}))
+
+	_, err = blobs.Get(ctx, updated.blobKey)
+	require.ErrorIs(t, err, blobstore.ErrBlobNotFound)
+}
+
+func TestDataPayloadMovedToBlobStore(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "server.db")
+
+	s, err := StartNewSQLiteStorage(dbPath, nil)
+	require.NoError(t, err)
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "file", Data: "v1"}))
+	require.NoError(t, s.Stop())
+
+	blobs, err := blobstore.NewFileStore(filepath.Join(t.TempDir(), "blobs"))
+	require.NoError(t, err)
+
+	s, err = StartNewSQLiteStorage(dbPath, blobs)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	// payload which is kept in db is still readable
+	r, err := s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, "v1", r.Data)
+
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "file", Data: "v2", Revision: 1}))
+
+	row := loadDataRow(t, s, "file")
+	require.Empty(t, row.value)
+	require.NotEmpty(t, row.blobKey)
+
+	r, err = s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, "v2", r.Data)
+}
+
+func TestDataDeduplicatedInBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	blobs, err := blobstore.NewFileStore(filepath.Join(t.TempDir(), "blobs"))
+	require.NoError(t, err)
+
+	s, err := StartNewSQLiteStorage(filepath.Join(t.TempDir(), "server.db"), blobs)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+
+	hash := strings.Repeat("ab", 32)
+	data := base64.RawStdEncoding.EncodeToString([]byte("encrypted certificate"))
+
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "first", Data: data, ContentHash: hash}))
+	// re-upload of the same content doesn't write a new blob
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "second", Data: data, ContentHash: hash}))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "third", ContentHash: hash}))
+
+	first := loadDataRow(t, s, "first")
+	require.Equal(t, first.blobKey, loadDataRow(t, s, "second").blobKey)
+	require.Equal(t, first.blobKey, loadDataRow(t, s, "third").blobKey)
+
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "first"}))
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "second", Data: "other", Revision: 1}))
+
+	r, err := s.LoadData(ctx, u.Token, "third")
+	require.NoError(t, err)
+	require.Equal(t, data, r.Data)
+
+	// blob is removed with the last reference
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "third"}))
+
+	_, err = blobs.Get(ctx, first.blobKey)
+	require.ErrorIs(t, err, blobstore.ErrBlobNotFound)
+
+	var refs int
+	require.NoError(t, s.db.QueryRow(`SELECT count(*) FROM blobs WHERE "content_hash" = $1;`, hash).Scan(&refs))
+	require.Zero(t, refs)
+}
+
+func TestDedupMigrationKeepsData(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := StartNewSQLiteStorage(filepath.Join(t.TempDir(), "server.db"), nil)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "file", Data: "v1"}))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "copy", Data: "v1"}))
+
+	// dedup migration is rolled back after the later ones
+	require.NoError(t, s.migrator.Down(ctx))
+	require.NoError(t, s.migrator.Down(ctx))
+	require.NoError(t, s.migrator.Down(ctx))
+
+	// rows which shared the payload get own values
+	for _, name := range []string{"file", "copy"} {
+		var value string
+		require.NoError(t, s.db.QueryRow(`SELECT "value" FROM binary_data WHERE "key" = $1;`, name).Scan(&value))
+		require.Equal(t, "v1", value)
+	}
+
+	require.NoError(t, s.migrator.Up(ctx))
+
+	r, err := s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, "v1", r.Data)
+	require.Equal(t, "row:file", loadDataRow(t, s, "file").hash)
+}
+
+func TestBlobsMigrationDownKeepsBlobStorePayloads(t *testing.T) {
+	ctx := context.Background()
+
+	blobs, err := blobstore.NewFileStore(filepath.Join(t.TempDir(), "blobs"))
+	require.NoError(t, err)
+
+	s, err := StartNewSQLiteStorage(filepath.Join(t.TempDir(), "server.db"), blobs)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "file", Data: "v1"}))
+
+	// blobs migration is rolled back after the later ones
+	for version := s.migrator.LatestVersion(); version > 5; version-- {
+		require.NoError(t, s.migrator.Down(ctx))
+	}
+
+	// payload in blob store isn't lost by dropping its blob key
+	err = s.migrator.Down(ctx)
+	require.ErrorContains(t, err, "payloads_in_blob_store")
+
+	version, err := s.migrator.Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, version)
+
+	require.NoError(t, s.migrator.Up(ctx))
+
+	r, err := s.LoadData(ctx, u.Token, "file")
+	require.NoError(t, err)
+	require.Equal(t, "v1", r.Data)
+}
+
+func TestDedupMigrationDownKeepsSharedBlobs(t *testing.T) {
+	ctx := context.Background()
+
+	blobs, err := blobstore.NewFileStore(filepath.Join(t.TempDir(), "blobs"))
+	require.NoError(t, err)
+
+	s, err := StartNewSQLiteStorage(filepath.Join(t.TempDir(), "server.db"), blobs)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	u := &handler.User{Login: "user", Password: "password", Token: "token"}
+	require.NoError(t, s.Register(ctx, u))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "file", Data: "v1"}))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "copy", Data: "v1"}))
+
+	// dedup migration is rolled back after the later ones
+	for version := s.migrator.LatestVersion(); version > 6; version-- {
+		require.NoError(t, s.migrator.Down(ctx))
+	}
+
+	// older code removes row's blob on update, so the shared blob isn't given to both rows
+	err = s.migrator.Down(ctx)
+	require.ErrorContains(t, err, "shared_blobs_in_blob_store")
+
+	version, err := s.migrator.Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 6, version)
+
+	require.NoError(t, s.migrator.Up(ctx))
+
+	for _, name := range []string{"file", "copy"} {
+		r, err := s.LoadData(ctx, u.Token, name)
+		require.NoError(t, err)
+		require.Equal(t, "v1", r.Data)
+	}
+}
+
+func loadDataRow(t *testing.T, s *Storage, name string) *dataRow {
+	row := &dataRow{record: &handler.Record{Name: name}}
+
+	q := `SELECT b."value", b."size", d."content_hash", b."blob_key", b."encoding"
+	FROM binary_data d JOIN blobs b ON b."user" = d."user" AND b."content_hash" = d."content_hash" WHERE d."key" = $1;`
+	require.NoError(t, s.db.QueryRow(q, name).Scan(&row.value, &row.size, &row.hash, &row.blobKey, &row.encoding))
+
+	return row
+}
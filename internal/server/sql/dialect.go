@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+// dialect describes differences of supported databases, items' queries are common for all of them
+type dialect struct {
+	driverName    string
+	migrationsDir string
+
+	createMigrationsTableQuery string
+	// lockMigrationsQuery serializes migrations of concurrently started servers, it's empty when db isn't shared
+	lockMigrationsQuery string
+
+	// maxOpenConns limits db's connections, 0 is unlimited
+	maxOpenConns int
+}
+
+var postgresDialect = &dialect{
+	driverName:    "pgx",
+	migrationsDir: "migrations/postgres",
+	createMigrationsTableQuery: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		"version"		integer		PRIMARY KEY,
+		"name"			text		NOT NULL,
+		"applied_at"	timestamptz	NOT NULL DEFAULT now()
+	);`,
+	lockMigrationsQuery: `SELECT pg_advisory_xact_lock(7061696);`,
+}
+
+// sqliteDialect is used by the embedded db, the only connection is used because sqlite locks the whole db on write
+var sqliteDialect = &dialect{
+	driverName:    "sqlite3",
+	migrationsDir: "migrations/sqlite",
+	createMigrationsTableQuery: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		"version"		integer		PRIMARY KEY,
+		"name"			text		NOT NULL,
+		"applied_at"	timestamp	NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	maxOpenConns: 1,
+}
+
+func (d *dialect) open(dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open(d.driverName, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("sql open dataSourceName=%s, err=%w", dataSourceName, err)
+	}
+
+	db.SetMaxOpenConns(d.maxOpenConns)
+
+	return db, nil
+}
+
+func isRetriableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgerrcode.IsConnectionException(pgErr.Code)
+	}
+
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+func isNotUniqueError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgerrcode.UniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+)
+
+// listTable describes user's items for the list query
+type listTable struct {
+	table      string
+	nameColumn string
+	// columns are selected before the name and the update time
+	columns string
+}
+
+var (
+	dataListTable   = &listTable{table: "binary_data", nameColumn: `"key"`, columns: `"revision", COALESCE("metainfo", '')`}
+	walletListTable = &listTable{table: "wallet", nameColumn: `"card_number"`}
+	secretListTable = &listTable{table: "secrets", nameColumn: `"secret_key"`}
+	sshKeyListTable = &listTable{table: "ssh_keys", nameColumn: `"name"`}
+)
+
+// listQueryBuilder numbers arguments in the order of their usage, because sqlite requires it
+type listQueryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (b *listQueryBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// hasPrefix doesn't use LIKE, so '%' and '_' in names don't need escaping
+func (b *listQueryBuilder) hasPrefix(column string, prefix string) string {
+	p := b.arg(prefix)
+
+	return fmt.Sprintf("substr(%s, 1, length(CAST(%s AS text))) = %s", column, p, p)
+}
+
+// prepareListQuery selects the page of user's items which are available in the token's scope,
+// the page starts after the cursor's item (keyset pagination)
+func prepareListQuery(t *listTable, user string, scope *handler.Scope, q *handler.ListQuery) *query {
+	b := &listQueryBuilder{}
+	b.conditions = append(b.conditions, `"user" = `+b.arg(user))
+
+	if scope != nil && len(scope.Prefixes) > 0 {
+		allowed := make([]string, 0, len(scope.Prefixes))
+		for _, prefix := range scope.Prefixes {
+			allowed = append(allowed, b.hasPrefix(t.nameColumn, prefix))
+		}
+
+		b.conditions = append(b.conditions, "("+strings.Join(allowed, " OR ")+")")
+	}
+
+	if len(q.Prefix) > 0 {
+		b.conditions = append(b.conditions, b.hasPrefix(t.nameColumn, q.Prefix))
+	}
+
+	if !q.UpdatedSince.IsZero() {
+		b.conditions = append(b.conditions, `"updated_at" >= `+b.arg(q.UpdatedSince.UTC()))
+	}
+
+	op, order := ">", "ASC"
+	if q.Desc {
+		op, order = "<", "DESC"
+	}
+
+	orderBy := fmt.Sprintf("%s %s", t.nameColumn, order)
+
+	if q.SortBy == handler.SortByUpdated {
+		orderBy = fmt.Sprintf(`"updated_at" %s, %s`, order, orderBy)
+
+		if q.After != nil {
+			updated := b.arg(q.After.Updated.UTC())
+			b.conditions = append(b.conditions, fmt.Sprintf(`("updated_at" %s %s OR ("updated_at" = %s AND %s %s %s))`,
+				op, updated, updated, t.nameColumn, op, b.arg(q.After.Name)))
+		}
+	} else if q.After != nil {
+		b.conditions = append(b.conditions, fmt.Sprintf("%s %s %s", t.nameColumn, op, b.arg(q.After.Name)))
+	}
+
+	columns := t.nameColumn + `, "updated_at"`
+	if len(t.columns) > 0 {
+		columns = t.columns + ", " + columns
+	}
+
+	request := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %s;`,
+		columns, t.table, strings.Join(b.conditions, " AND "), orderBy, b.arg(q.Limit))
+
+	return &query{request: request, args: b.args}
+}
+
+// updateTime is a time of item's change, precision is limited by postgres
+func updateTime() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
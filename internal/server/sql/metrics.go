@@ -0,0 +1,124 @@
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// timeout of storage's queries which are done on metrics' scrape
+const scrapeTimeout = time.Second * 5
+
+// user is active when their items were accessed during the period
+const activeUserPeriod = time.Hour * 24
+
+// queries' metrics are collected by doQuery which doesn't know storage, so they are common for the package
+var (
+	queryDurations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gophkeeper",
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of db queries' attempts.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	queryRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "gophkeeper",
+		Name:      "db_query_retries_total",
+		Help:      "Number of retried db queries' attempts.",
+	})
+)
+
+var itemsQueries = map[string]string{
+	handler.ItemKindData:   countBinaryData,
+	handler.ItemKindCard:   countCards,
+	handler.ItemKindSecret: countSecrets,
+	handler.ItemKindSSHKey: countSSHKeys,
+}
+
+// RegisterMetrics registers queries' metrics and collector of users' and items' numbers
+func (c *Storage) RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{queryDurations, queryRetries, newStorageCollector(c)} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func observeQuery(start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	queryDurations.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+var _ prometheus.Collector = &storageCollector{}
+
+// storageCollector reads users' and items' numbers from db on every scrape
+type storageCollector struct {
+	storage *Storage
+
+	users       *prometheus.Desc
+	activeUsers *prometheus.Desc
+	items       *prometheus.Desc
+	itemsSize   *prometheus.Desc
+}
+
+func newStorageCollector(s *Storage) *storageCollector {
+	return &storageCollector{
+		storage:     s,
+		users:       prometheus.NewDesc("gophkeeper_users", "Number of registered users.", nil, nil),
+		activeUsers: prometheus.NewDesc("gophkeeper_active_users", "Number of users which accessed items during the last day.", nil, nil),
+		items:       prometheus.NewDesc("gophkeeper_storage_items", "Number of stored items.", []string{"kind"}, nil),
+		itemsSize:   prometheus.NewDesc("gophkeeper_storage_size_bytes", "Size of stored items' encrypted data.", []string{"kind"}, nil),
+	}
+}
+
+func (s *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.users
+	ch <- s.activeUsers
+	ch <- s.items
+	ch <- s.itemsSize
+}
+
+func (s *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	counters := []struct {
+		desc  *prometheus.Desc
+		query string
+		args  []any
+	}{
+		{desc: s.users, query: countUsers},
+		{desc: s.activeUsers, query: countActiveUsers, args: []any{time.Now().Add(-activeUserPeriod)}},
+	}
+
+	for _, c := range counters {
+		var count int64
+		if err := s.storage.db.QueryRowContext(ctx, c.query, c.args...).Scan(&count); err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count))
+	}
+
+	for kind, q := range itemsQueries {
+		var count, size int64
+		if err := s.storage.db.QueryRowContext(ctx, q).Scan(&count, &size); err != nil {
+			ch <- prometheus.NewInvalidMetric(s.items, err)
+
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(s.items, prometheus.GaugeValue, float64(count), kind)
+		ch <- prometheus.MustNewConstMetric(s.itemsSize, prometheus.GaugeValue, float64(size), kind)
+	}
+}
@@ -0,0 +1,14 @@
+package sql
+
+const (
+	countUsers = `SELECT count(*) FROM users;`
+
+	// users which have accessed their items since $1
+	countActiveUsers = `SELECT count(DISTINCT "user") FROM audit_log WHERE "time" > $1;`
+
+	// payloads of binary data are deduplicated, so their size is size of blobs
+	countBinaryData = `SELECT (SELECT count(*) FROM binary_data), (SELECT COALESCE(sum("size"), 0) FROM blobs);`
+	countCards      = `SELECT count(*), COALESCE(sum(octet_length("card_data")), 0) FROM wallet;`
+	countSecrets    = `SELECT count(*), COALESCE(sum(octet_length("secret_value")), 0) FROM secrets;`
+	countSSHKeys    = `SELECT count(*), COALESCE(sum(octet_length("data")), 0) FROM ssh_keys;`
+)
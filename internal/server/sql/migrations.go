@@ -0,0 +1,353 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationsFS embed.FS
+
+var ErrUnknownSchemaVersion = errors.New("db schema is newer than server knows")
+
+// migrations' files are named as 0001_name.up.sql and 0001_name.down.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const migrationTimeout = time.Minute
+
+const (
+	getSchemaVersionQuery = `SELECT COALESCE(max("version"), 0) FROM schema_migrations;`
+	listMigrationsQuery   = `SELECT "version", "name", "applied_at" FROM schema_migrations ORDER BY "version";`
+	addMigrationQuery     = `INSERT INTO schema_migrations ("version", "name") VALUES ($1, $2);`
+	deleteMigrationQuery  = `DELETE FROM schema_migrations WHERE "version" = $1;`
+)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus describes known or applied migration
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Unknown migration is applied by the newer server
+	Unknown bool
+}
+
+// Migrator applies numbered migrations which are embedded into the server
+type Migrator struct {
+	db         *sql.DB
+	dialect    *dialect
+	migrations []*migration
+}
+
+// OpenMigrator connects to postgres for migration's commands without starting storage
+func OpenMigrator(dataSourceName string) (*Migrator, error) {
+	return openMigrator(postgresDialect, dataSourceName)
+}
+
+// OpenSQLiteMigrator opens sqlite's db file for migration's commands without starting storage
+func OpenSQLiteMigrator(path string) (*Migrator, error) {
+	return openMigrator(sqliteDialect, path)
+}
+
+func openMigrator(d *dialect, dataSourceName string) (*Migrator, error) {
+	db, err := d.open(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newMigrator(db, d)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
+	return m, nil
+}
+
+func newMigrator(db *sql.DB, d *dialect) (*Migrator, error) {
+	migrations, err := parseMigrations(migrationsFS, d.migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("parse migrations, err=%w", err)
+	}
+
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// LatestVersion returns version of the newest migration which is known by the server
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].version
+}
+
+// Version returns version of the last applied migration
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+	if err := m.db.QueryRowContext(ctx, getSchemaVersionQuery).Scan(&version); err != nil {
+		return 0, fmt.Errorf("get schema version, err=%w", err)
+	}
+
+	return version, nil
+}
+
+// Up applies all pending migrations, every migration is applied in its own transaction
+func (m *Migrator) Up(ctx context.Context) error {
+	if err := m.createTable(ctx); err != nil {
+		return err
+	}
+
+	for _, mig := range m.migrations {
+		if err := m.apply(ctx, mig, true); err != nil {
+			return fmt.Errorf("migration=%04d_%s up, err=%w", mig.version, mig.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	if err := m.createTable(ctx); err != nil {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return errors.New("there aren't applied migrations")
+	}
+
+	mig := m.find(version)
+	if mig == nil {
+		return fmt.Errorf("%w, version=%d latest=%d", ErrUnknownSchemaVersion, version, m.LatestVersion())
+	}
+
+	if err := m.apply(ctx, mig, false); err != nil {
+		return fmt.Errorf("migration=%04d_%s down, err=%w", mig.version, mig.name, err)
+	}
+
+	return nil
+}
+
+// Status returns known migrations and migrations which are applied by newer server
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, listMigrationsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("list migrations, err=%w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]*MigrationStatus)
+	for rows.Next() {
+		s := &MigrationStatus{}
+
+		var appliedAt time.Time
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		s.AppliedAt = &appliedAt
+		applied[s.Version] = s
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := &MigrationStatus{Version: mig.version, Name: mig.name}
+		if a, ok := applied[mig.version]; ok {
+			s.AppliedAt = a.AppliedAt
+			delete(applied, mig.version)
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	for _, s := range applied {
+		s.Unknown = true
+		statuses = append(statuses, s)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// checkVersion refuses to work with schema which is migrated by the newer server
+func (m *Migrator) checkVersion(ctx context.Context) error {
+	if err := m.createTable(ctx); err != nil {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version > m.LatestVersion() {
+		return fmt.Errorf("%w, version=%d latest=%d", ErrUnknownSchemaVersion, version, m.LatestVersion())
+	}
+
+	return nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, m.dialect.createMigrationsTableQuery); err != nil {
+		return fmt.Errorf("create schema_migrations table, err=%w", err)
+	}
+
+	return nil
+}
+
+// apply runs migration under the lock, so migration is skipped when it's already applied by another server
+func (m *Migrator) apply(ctx context.Context, mig *migration, up bool) error {
+	ctx, cancel := context.WithTimeout(ctx, migrationTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if len(m.dialect.lockMigrationsQuery) != 0 {
+		if _, err := tx.ExecContext(ctx, m.dialect.lockMigrationsQuery); err != nil {
+			return fmt.Errorf("lock migrations, err=%w", err)
+		}
+	}
+
+	var version int
+	if err := tx.QueryRowContext(ctx, getSchemaVersionQuery).Scan(&version); err != nil {
+		return fmt.Errorf("get schema version, err=%w", err)
+	}
+
+	if version > m.LatestVersion() {
+		return fmt.Errorf("%w, version=%d latest=%d", ErrUnknownSchemaVersion, version, m.LatestVersion())
+	}
+
+	if up {
+		if version >= mig.version {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, mig.up); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, addMigrationQuery, mig.version, mig.name); err != nil {
+			return err
+		}
+	} else {
+		if version != mig.version {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, mig.down); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteMigrationQuery, mig.version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) find(version int) *migration {
+	for _, mig := range m.migrations {
+		if mig.version == version {
+			return mig
+		}
+	}
+
+	return nil
+}
+
+// parseMigrations reads migrations from dir, versions must go one by one from 1 and have both up and down files
+func parseMigrations(fsys fs.FS, dir string) ([]*migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("bad migration's file name=%s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: match[2]}
+			byVersion[version] = mig
+		} else if mig.name != match[2] {
+			return nil, fmt.Errorf("migration=%d has different names %s and %s", version, mig.name, match[2])
+		}
+
+		if match[3] == "up" {
+			mig.up = string(data)
+		} else {
+			mig.down = string(data)
+		}
+	}
+
+	migrations := make([]*migration, 0, len(byVersion))
+	for version := 1; version <= len(byVersion); version++ {
+		mig, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration=%d is missed", version)
+		}
+
+		if len(mig.up) == 0 || len(mig.down) == 0 {
+			return nil, fmt.Errorf("migration=%04d_%s must have up and down files", mig.version, mig.name)
+		}
+
+		migrations = append(migrations, mig)
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS secrets;
+DROP TABLE IF EXISTS wallet;
+DROP TABLE IF EXISTS binary_data;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS users (
+	"login"		text NOT NULL,
+	"password"	text NOT NULL,
+	"token"		text NOT NULL,
+	PRIMARY KEY ( "token" )
+);
+
+CREATE TABLE IF NOT EXISTS binary_data (
+	"user"		text	NOT NULL,
+	"key"		text	NOT NULL,
+	"value"		text	NOT NULL,
+	"revision"	bigint	NOT NULL,
+	"metainfo"	text,
+	PRIMARY KEY ( "user", "key" )
+);
+
+CREATE TABLE IF NOT EXISTS wallet (
+	"user"			text	NOT NULL,
+	"card_number"	text	NOT NULL,
+	"card_data"		text	NOT NULL,
+	PRIMARY KEY ( "user", "card_number" )
+);
+
+CREATE TABLE IF NOT EXISTS secrets (
+	"user"			text	NOT NULL,
+	"secret_key"	text	NOT NULL,
+	"secret_value"	text	NOT NULL,
+	PRIMARY KEY ( "user", "secret_key" )
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS service_accounts (
+	"user"			text		NOT NULL,
+	"name"			text		NOT NULL,
+	"token_hash"	text		NOT NULL UNIQUE,
+	"scope"			text		NOT NULL,
+	"created_at"	timestamptz	NOT NULL DEFAULT now(),
+	PRIMARY KEY ( "user", "name" )
+);
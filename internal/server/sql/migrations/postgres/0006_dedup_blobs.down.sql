@@ -0,0 +1,17 @@
+-- older code removes row's blob on update, so blob in blob store which is shared by rows can't be split
+-- and downgrade fails while it exists, rows which share payload in db get own copies of the value
+CREATE TEMP TABLE downgrade_guard (
+	"shared_blobs_in_blob_store"	integer	CHECK ("shared_blobs_in_blob_store" = 0)
+);
+INSERT INTO downgrade_guard SELECT count(*) FROM blobs WHERE "refs" > 1 AND "blob_key" <> '';
+DROP TABLE downgrade_guard;
+
+ALTER TABLE binary_data ADD COLUMN IF NOT EXISTS "value"		text	NOT NULL DEFAULT '';
+ALTER TABLE binary_data ADD COLUMN IF NOT EXISTS "size"		bigint	NOT NULL DEFAULT 0;
+ALTER TABLE binary_data ADD COLUMN IF NOT EXISTS "blob_key"	text	NOT NULL DEFAULT '';
+ALTER TABLE binary_data ADD COLUMN IF NOT EXISTS "encoding"	text	NOT NULL DEFAULT '';
+
+UPDATE binary_data SET "value" = blobs."value", "size" = blobs."size", "blob_key" = blobs."blob_key", "encoding" = blobs."encoding"
+	FROM blobs WHERE blobs."user" = binary_data."user" AND blobs."content_hash" = binary_data."content_hash";
+
+DROP TABLE IF EXISTS blobs;
@@ -0,0 +1,23 @@
+-- payloads of binary data are shared by user's rows which have the same content hash,
+-- payload is kept in "value" when "blob_key" is empty, otherwise it's in blob store
+CREATE TABLE IF NOT EXISTS blobs (
+	"user"			text	NOT NULL,
+	"content_hash"	text	NOT NULL,
+	"value"			text	NOT NULL DEFAULT '',
+	"size"			bigint	NOT NULL,
+	"blob_key"		text	NOT NULL DEFAULT '',
+	"encoding"		text	NOT NULL DEFAULT '',
+	"refs"			bigint	NOT NULL,
+	PRIMARY KEY ( "user", "content_hash" )
+);
+
+-- stored rows get unique hashes, so every row keeps own payload and it isn't deduplicated with new data
+UPDATE binary_data SET "content_hash" = 'row:' || "key";
+
+INSERT INTO blobs ("user", "content_hash", "value", "size", "blob_key", "encoding", "refs")
+	SELECT "user", "content_hash", "value", "size", "blob_key", "encoding", 1 FROM binary_data;
+
+ALTER TABLE binary_data DROP COLUMN IF EXISTS "value";
+ALTER TABLE binary_data DROP COLUMN IF EXISTS "size";
+ALTER TABLE binary_data DROP COLUMN IF EXISTS "blob_key";
+ALTER TABLE binary_data DROP COLUMN IF EXISTS "encoding";
@@ -0,0 +1,8 @@
+-- time of item's last change is used by list endpoints for filtering and sorting
+ALTER TABLE binary_data ADD COLUMN IF NOT EXISTS "updated_at"	timestamptz	NOT NULL DEFAULT now();
+ALTER TABLE wallet ADD COLUMN IF NOT EXISTS "updated_at"		timestamptz	NOT NULL DEFAULT now();
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS "updated_at"		timestamptz	NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS binary_data_user_updated_at ON binary_data ("user", "updated_at");
+CREATE INDEX IF NOT EXISTS wallet_user_updated_at ON wallet ("user", "updated_at");
+CREATE INDEX IF NOT EXISTS secrets_user_updated_at ON secrets ("user", "updated_at");
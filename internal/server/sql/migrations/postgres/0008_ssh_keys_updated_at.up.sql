@@ -0,0 +1,4 @@
+-- ssh keys are listed by clients which download keys added on other devices
+ALTER TABLE ssh_keys ADD COLUMN IF NOT EXISTS "updated_at"	timestamptz	NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS ssh_keys_user_updated_at ON ssh_keys ("user", "updated_at");
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS ssh_keys (
+	"user"	text	NOT NULL,
+	"name"	text	NOT NULL,
+	"data"	text	NOT NULL,
+	PRIMARY KEY ( "user", "name" )
+);
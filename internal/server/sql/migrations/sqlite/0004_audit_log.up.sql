@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	"id"				integer		PRIMARY KEY AUTOINCREMENT,
+	"user"				text		NOT NULL,
+	"time"				timestamp	NOT NULL,
+	"service_account"	text		NOT NULL DEFAULT '',
+	"device"			text		NOT NULL DEFAULT '',
+	"action"			text		NOT NULL,
+	"kind"				text		NOT NULL DEFAULT '',
+	"key"				text		NOT NULL DEFAULT '',
+	"ip"				text		NOT NULL,
+	"result"			text		NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_user_time ON audit_log ("user", "time" DESC);
@@ -0,0 +1,11 @@
+-- payloads which were moved to blob store can't be returned to db, so downgrade fails while they exist
+CREATE TEMP TABLE downgrade_guard (
+	"payloads_in_blob_store"	integer	CHECK ("payloads_in_blob_store" = 0)
+);
+INSERT INTO downgrade_guard SELECT count(*) FROM binary_data WHERE "blob_key" <> '';
+DROP TABLE downgrade_guard;
+
+ALTER TABLE binary_data DROP COLUMN "encoding";
+ALTER TABLE binary_data DROP COLUMN "blob_key";
+ALTER TABLE binary_data DROP COLUMN "content_hash";
+ALTER TABLE binary_data DROP COLUMN "size";
@@ -0,0 +1,7 @@
+-- payload is kept in "value" when "blob_key" is empty, otherwise it's in blob store
+ALTER TABLE binary_data ADD COLUMN "size"			integer	NOT NULL DEFAULT 0;
+ALTER TABLE binary_data ADD COLUMN "content_hash"	text	NOT NULL DEFAULT '';
+ALTER TABLE binary_data ADD COLUMN "blob_key"		text	NOT NULL DEFAULT '';
+ALTER TABLE binary_data ADD COLUMN "encoding"		text	NOT NULL DEFAULT '';
+
+UPDATE binary_data SET "size" = octet_length("value");
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS binary_data_user_updated_at;
+DROP INDEX IF EXISTS wallet_user_updated_at;
+DROP INDEX IF EXISTS secrets_user_updated_at;
+
+ALTER TABLE binary_data DROP COLUMN "updated_at";
+ALTER TABLE wallet DROP COLUMN "updated_at";
+ALTER TABLE secrets DROP COLUMN "updated_at";
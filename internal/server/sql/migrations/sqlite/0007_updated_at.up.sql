@@ -0,0 +1,13 @@
+-- time of item's last change is used by list endpoints for filtering and sorting,
+-- times are compared as text, so stored rows get the driver's format of UTC time
+ALTER TABLE binary_data ADD COLUMN "updated_at"	timestamp	NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE wallet ADD COLUMN "updated_at"		timestamp	NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE secrets ADD COLUMN "updated_at"		timestamp	NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+UPDATE binary_data SET "updated_at" = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now');
+UPDATE wallet SET "updated_at" = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now');
+UPDATE secrets SET "updated_at" = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now');
+
+CREATE INDEX IF NOT EXISTS binary_data_user_updated_at ON binary_data ("user", "updated_at");
+CREATE INDEX IF NOT EXISTS wallet_user_updated_at ON wallet ("user", "updated_at");
+CREATE INDEX IF NOT EXISTS secrets_user_updated_at ON secrets ("user", "updated_at");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ssh_keys_user_updated_at;
+
+ALTER TABLE ssh_keys DROP COLUMN "updated_at";
@@ -0,0 +1,7 @@
+-- ssh keys are listed by clients which download keys added on other devices,
+-- times are compared as text, so stored rows get the driver's format of UTC time
+ALTER TABLE ssh_keys ADD COLUMN "updated_at"	timestamp	NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+UPDATE ssh_keys SET "updated_at" = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now');
+
+CREATE INDEX IF NOT EXISTS ssh_keys_user_updated_at ON ssh_keys ("user", "updated_at");
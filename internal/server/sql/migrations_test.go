@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := parseMigrations(migrationsFS, postgresDialect.migrationsDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, postgres)
+
+	sqlite, err := parseMigrations(migrationsFS, sqliteDialect.migrationsDir)
+	require.NoError(t, err)
+
+	// dialects have the same schema's versions
+	require.Len(t, sqlite, len(postgres))
+
+	for i := range postgres {
+		require.Equal(t, i+1, postgres[i].version)
+		require.Equal(t, postgres[i].name, sqlite[i].name)
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"m/0001_init.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"m/0002_items.up.sql":   {Data: []byte("CREATE TABLE items ();")},
+		"m/0001_init.down.sql":  {Data: []byte("DROP TABLE users;")},
+	}
+
+	migrations, err := parseMigrations(fsys, "m")
+	require.NoError(t, err)
+	require.Equal(t, []*migration{
+		{version: 1, name: "init", up: "CREATE TABLE users ();", down: "DROP TABLE users;"},
+		{version: 2, name: "items", up: "CREATE TABLE items ();", down: "DROP TABLE items;"},
+	}, migrations)
+}
+
+func TestParseBadMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missed version",
+			fsys: fstest.MapFS{
+				"m/0001_init.up.sql":    {Data: []byte("1")},
+				"m/0001_init.down.sql":  {Data: []byte("1")},
+				"m/0003_items.up.sql":   {Data: []byte("3")},
+				"m/0003_items.down.sql": {Data: []byte("3")},
+			},
+		},
+		{
+			name: "missed down",
+			fsys: fstest.MapFS{
+				"m/0001_init.up.sql": {Data: []byte("1")},
+			},
+		},
+		{
+			name: "different names",
+			fsys: fstest.MapFS{
+				"m/0001_init.up.sql":    {Data: []byte("1")},
+				"m/0001_other.down.sql": {Data: []byte("1")},
+			},
+		},
+		{
+			name: "bad file name",
+			fsys: fstest.MapFS{
+				"m/init.sql": {Data: []byte("1")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMigrations(tt.fsys, "m")
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package sql
+
+const (
+	addServiceAccount    = `INSERT INTO service_accounts ("user", "name", "token_hash", "scope") VALUES ($1, $2, $3, $4);`
+	listServiceAccounts  = `SELECT "name", "scope", "created_at" FROM service_accounts WHERE "user" = $1 ORDER BY "name";`
+	deleteServiceAccount = `DELETE FROM service_accounts WHERE "user" = $1 AND "name" = $2;`
+	getServiceAccount    = `SELECT users."token", service_accounts."name", service_accounts."scope" FROM service_accounts
+		JOIN users ON users."login" = service_accounts."user"
+		WHERE service_accounts."token_hash" = $1 LIMIT 1;`
+)
+
+func prepareAddServiceAccount(user, name, tokenHash, scope string) *query {
+	return &query{request: addServiceAccount, args: []any{user, name, tokenHash, scope}}
+}
+
+func prepareListServiceAccounts(user string) *query {
+	return &query{request: listServiceAccounts, args: []any{user}}
+}
+
+func prepareDeleteServiceAccount(user, name string) *query {
+	return &query{request: deleteServiceAccount, args: []any{user, name}}
+}
+
+func prepareGetServiceAccount(tokenHash string) *query {
+	return &query{request: getServiceAccount, args: []any{tokenHash}}
+}
@@ -0,0 +1,19 @@
+package sql
+
+const (
+	addSSHKey    = `INSERT INTO ssh_keys ("user", "name", "data", "updated_at") VALUES ($1, $2, $3, $4);`
+	getSSHKey    = `SELECT "data" FROM ssh_keys WHERE "user" = $1 AND "name" = $2;`
+	deleteSSHKey = `DELETE FROM ssh_keys WHERE "user" = $1 AND "name" = $2;`
+)
+
+func prepareAddSSHKey(user, name, data string) *query {
+	return &query{request: addSSHKey, args: []any{user, name, data, updateTime()}}
+}
+
+func prepareGetSSHKey(user, name string) *query {
+	return &query{request: getSSHKey, args: []any{user, name}}
+}
+
+func prepareDeleteSSHKey(user, name string) *query {
+	return &query{request: deleteSSHKey, args: []any{user, name}}
+}
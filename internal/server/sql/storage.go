@@ -3,13 +3,13 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/kuzhukin/goph-keeper/internal/server/blobstore"
 	"github.com/kuzhukin/goph-keeper/internal/server/handler"
 	"github.com/kuzhukin/goph-keeper/internal/zlog"
 )
@@ -18,20 +18,44 @@ var _ handler.DataStorage = &Storage{}
 var _ handler.WalletStorage = &Storage{}
 var _ handler.Registrator = &Storage{}
 var _ handler.SecretStorage = &Storage{}
+var _ handler.SSHKeyStorage = &Storage{}
+var _ handler.ServiceAccountStorage = &Storage{}
+var _ handler.Auditor = &Storage{}
+var _ handler.AuditStorage = &Storage{}
+var _ handler.ReadinessChecker = &Storage{}
+var _ handler.UsageStorage = &Storage{}
 
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB
+	migrator *Migrator
+	// blobs keeps binary data's payloads, they are kept in db when it's nil
+	blobs blobstore.BlobStore
 }
 
-func StartNewStorage(dataSourceName string) (*Storage, error) {
-	db, err := sql.Open("pgx", dataSourceName)
+// StartNewStorage connects to postgres and applies pending migrations
+func StartNewStorage(dataSourceName string, blobs blobstore.BlobStore) (*Storage, error) {
+	return startStorage(postgresDialect, dataSourceName, blobs)
+}
+
+// StartNewSQLiteStorage opens embedded db's file and applies pending migrations
+func StartNewSQLiteStorage(path string, blobs blobstore.BlobStore) (*Storage, error) {
+	return startStorage(sqliteDialect, path, blobs)
+}
+
+func startStorage(d *dialect, dataSourceName string, blobs blobstore.BlobStore) (*Storage, error) {
+	db, err := d.open(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := newMigrator(db, d)
 	if err != nil {
-		return nil, fmt.Errorf("sql open dataSourceName=%s, err=%w", dataSourceName, err)
+		return nil, errors.Join(err, db.Close())
 	}
 
-	ctrl := &Storage{db: db}
+	ctrl := &Storage{db: db, migrator: migrator, blobs: blobs}
 	if err := ctrl.init(); err != nil {
-		return nil, fmt.Errorf("init, err=%w", err)
+		return nil, errors.Join(fmt.Errorf("init, err=%w", err), db.Close())
 	}
 
 	return ctrl, nil
@@ -45,153 +69,241 @@ func (c *Storage) Stop() error {
 	return nil
 }
 
-func (c *Storage) init() error {
-	createTableQueries := []string{
-		createUsersTableQuery,
-		createBinaryDataTableQuery,
-		createWalletTableQuery,
-		createSecretTableQuery,
-	}
-
-	for _, q := range createTableQueries {
-		if err := c.exec(q); err != nil {
-			return fmt.Errorf("exec query=%s, err=%w", q, err)
-		}
-	}
-
-	return nil
+func (c *Storage) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
 }
 
-func (c *Storage) exec(query string) error {
-	const createTablesTimeout = time.Second * 10
+func (c *Storage) MigrationVersion(ctx context.Context) (int, error) {
+	return c.migrator.Version(ctx)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), createTablesTimeout)
+// init applies pending migrations, server doesn't start with schema which is migrated by the newer server
+func (c *Storage) init() error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
 	defer cancel()
 
-	_, err := c.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("exec create table query, err=%w", err)
+	if err := c.migrator.checkVersion(ctx); err != nil {
+		return err
 	}
 
-	return nil
+	return c.migrator.Up(ctx)
 }
 
-func (c *Storage) Check(ctx context.Context, token string) error {
+func (c *Storage) Check(ctx context.Context, token string) (*handler.Access, error) {
 	userQuery := prepareGetUserQuery(token)
 
 	rows, err := doQuery(ctx, func(ctx context.Context) (*sql.Rows, error) {
 		return c.db.QueryContext(ctx, userQuery.request, userQuery.args...)
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
 	storedUser := &handler.User{}
 
 	if !rows.Next() {
-		return handler.ErrUnknownUser
+		return c.checkServiceAccount(ctx, token)
 	}
 
 	if err := rows.Scan(&storedUser.Login, &storedUser.Password, &storedUser.Token); err != nil {
-		return err
+		return nil, err
 	}
 
 	if storedUser.Token != token {
-		return handler.ErrUnknownUser
+		return nil, handler.ErrUnknownUser
 	}
 
-	return nil
+	return &handler.Access{UserToken: token}, nil
 }
 
-func (c *Storage) CreateData(ctx context.Context, userToken string, d *handler.Record) error {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+func (c *Storage) checkServiceAccount(ctx context.Context, token string) (*handler.Access, error) {
+	accountQuery := prepareGetServiceAccount(handler.HashServiceAccountToken(token))
+
+	rows, err := doQuery(ctx, func(ctx context.Context) (*sql.Rows, error) {
+		return c.db.QueryContext(ctx, accountQuery.request, accountQuery.args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, handler.ErrUnknownUser
+	}
+
+	access := &handler.Access{}
+	scope := ""
+
+	if err := rows.Scan(&access.UserToken, &access.ServiceAccount, &scope); err != nil {
+		return nil, err
+	}
+
+	access.Scope = &handler.Scope{}
+	if err := json.Unmarshal([]byte(scope), access.Scope); err != nil {
+		return nil, fmt.Errorf("unmarshal service account's scope, err=%w", err)
+	}
+
+	return access, nil
+}
+
+func (c *Storage) CreateData(baseCtx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(baseCtx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
 	defer cancel()
 
-	tx, err := c.db.BeginTx(ctx, nil)
+	u, err := c.getUser(ctx, userToken)
 	if err != nil {
 		return err
 	}
-	defer recoverAndRollBack(tx)
 
-	u, err := getUserInTransaction(ctx, tx, userToken)
+	row, err := c.prepareRow(baseCtx, ctx, u.Login, d)
+	if err != nil {
+		return err
+	}
+
+	garbage, err := c.insertData(ctx, u, row)
 	if err != nil {
+		c.discardPayload(baseCtx, row)
+
 		return err
 	}
 
-	err = doTransactionExec(ctx, tx, prepareNewDataQuery(u.Login, d.Name, d.Data))
+	c.deleteBlob(baseCtx, garbage)
+
+	return nil
+}
+
+func (c *Storage) insertData(ctx context.Context, u *handler.User, row *dataRow) (string, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer recoverAndRollBack(tx)
+
+	garbage, err := referenceBlobInTransaction(ctx, tx, u.Login, row)
+	if err != nil {
+		return "", err
+	}
+
+	err = doTransactionExec(ctx, tx, prepareNewDataQuery(u.Login, row))
 	if err != nil {
 		if isNotUniqueError(err) {
-			return handler.ErrDataAlreadyExist
+			return "", handler.ErrDataAlreadyExist
 		}
 
-		return fmt.Errorf("add user=%s data=%s, err=%w", u.Login, d.Data, err)
+		return "", fmt.Errorf("add user=%s data=%s, err=%w", u.Login, row.record.Name, err)
 	}
 
-	return tx.Commit()
+	return garbage, tx.Commit()
 }
 
 func (c *Storage) UpdateData(baseCtx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(baseCtx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
 	defer cancel()
 
-	tx, err := c.db.BeginTx(ctx, nil)
+	u, storedRow, err := c.getData(ctx, userToken, d.Name)
 	if err != nil {
 		return err
 	}
-	defer recoverAndRollBack(tx)
 
-	u, err := getUserInTransaction(ctx, tx, userToken)
+	if storedRow.record.Revision != d.Revision {
+		return fmt.Errorf("user=%s data=%s err=%w", u.Login, d.Name, handler.ErrBadRevision)
+	}
+
+	row, err := c.prepareRow(baseCtx, ctx, u.Login, d)
 	if err != nil {
 		return err
 	}
 
-	storedData, err := getDataInTransaction(ctx, tx, u, d.Name)
+	garbage, err := c.updateData(ctx, u, row, storedRow.hash)
 	if err != nil {
+		c.discardPayload(baseCtx, row)
+
 		return err
 	}
 
-	if storedData.Revision != d.Revision {
-		return fmt.Errorf("user=%s data=%s err=%w", u.Login, d.Name, handler.ErrBadRevision)
+	for _, key := range garbage {
+		c.deleteBlob(baseCtx, key)
 	}
 
-	if storedData.Data == d.Data {
-		// doesn't need in changes
-		return nil
+	return nil
+}
+
+// updateData moves the row's reference from the previous blob to the new one,
+// it returns keys of blobs which must be deleted after commit
+func (c *Storage) updateData(ctx context.Context, u *handler.User, row *dataRow, previousHash string) ([]string, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer recoverAndRollBack(tx)
 
-	err = doTransactionExec(ctx, tx, prepareUpdateDataQuery(u.Login, d.Name, d.Data))
+	duplicate, err := referenceBlobInTransaction(ctx, tx, u.Login, row)
 	if err != nil {
-		return fmt.Errorf("do update user=%s, data=%s, rev=%d, err=%w", u.Login, d.Name, d.Revision, err)
+		return nil, err
 	}
 
-	return tx.Commit()
-}
+	q := prepareUpdateDataQuery(u.Login, row)
 
-func (c *Storage) LoadData(baseCtx context.Context, userToken string, dataKey string) (*handler.Record, error) {
-	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
-	defer cancel()
+	result, err := doQuery(ctx, func(ctx context.Context) (sql.Result, error) {
+		return tx.ExecContext(ctx, q.request, q.args...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("do update user=%s, data=%s, rev=%d, err=%w", u.Login, row.record.Name, row.record.Revision, err)
+	}
 
-	tx, err := c.db.BeginTx(ctx, nil)
+	updated, err := result.RowsAffected()
 	if err != nil {
 		return nil, err
 	}
-	defer recoverAndRollBack(tx)
 
-	u, err := getUserInTransaction(ctx, tx, userToken)
+	if updated == 0 {
+		// the row was updated or deleted after it had been read
+		return nil, fmt.Errorf("user=%s data=%s err=%w", u.Login, row.record.Name, handler.ErrBadRevision)
+	}
+
+	released, err := releaseBlobInTransaction(ctx, tx, u.Login, previousHash)
 	if err != nil {
 		return nil, err
 	}
 
-	d, err := getDataInTransaction(ctx, tx, u, dataKey)
+	return []string{duplicate, released}, tx.Commit()
+}
+
+func (c *Storage) LoadData(baseCtx context.Context, userToken string, dataKey string) (*handler.Record, error) {
+	if err := checkScope(baseCtx, handler.ItemKindData, dataKey, false); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
+	defer cancel()
+
+	_, row, err := c.getData(ctx, userToken, dataKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return d, tx.Commit()
+	if err := c.loadPayload(baseCtx, row); err != nil {
+		return nil, err
+	}
+
+	return row.record, nil
 }
 
-func (c *Storage) ListData(baseCtx context.Context, userToken string) ([]*handler.Record, error) {
+func (c *Storage) ListData(baseCtx context.Context, userToken string, q *handler.ListQuery) ([]*handler.Record, error) {
+	scope := handler.ScopeFromContext(baseCtx)
+	if !scope.AllowsKind(handler.ItemKindData, false) {
+		return nil, handler.ErrForbidden
+	}
+
 	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
 	defer cancel()
 
@@ -206,24 +318,22 @@ func (c *Storage) ListData(baseCtx context.Context, userToken string) ([]*handle
 		return nil, err
 	}
 
-	q := prepareListBinaryData(u.Login)
+	query := prepareListQuery(dataListTable, u.Login, scope, q)
 
-	rows, err := tx.QueryContext(ctx, q.request, q.args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	records, err := doTransactionQuery(ctx, tx, query, func(rows *sql.Rows) ([]*handler.Record, error) {
+		list := make([]*handler.Record, 0, q.Limit)
+		for rows.Next() {
+			r := &handler.Record{}
+			if err := rows.Scan(&r.Revision, &r.Metainfo, &r.Name, &r.Updated); err != nil {
+				return nil, err
+			}
 
-	records := make([]*handler.Record, 0, 10)
-	for rows.Next() {
-		r := &handler.Record{}
-		if err = rows.Scan(&r.Name, &r.Data, &r.Revision); err != nil {
-			return nil, err
+			list = append(list, r)
 		}
 
-		records = append(records, r)
-	}
-	if err = rows.Err(); err != nil {
+		return list, rows.Err()
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -232,14 +342,17 @@ func (c *Storage) ListData(baseCtx context.Context, userToken string) ([]*handle
 	}
 
 	return records, nil
-
 }
 
-func (c *Storage) DeleteData(ctx context.Context, userToken string, d *handler.Record) error {
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
+func (c *Storage) DeleteData(baseCtx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(baseCtx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(baseCtx, time.Second*5)
 	defer cancel()
 
-	tx, err := c.db.BeginTx(ctxWithTimeout, nil)
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -250,12 +363,68 @@ func (c *Storage) DeleteData(ctx context.Context, userToken string, d *handler.R
 		return err
 	}
 
-	err = deleteDataInTransaction(ctx, tx, u, d.Name)
+	row, err := getDataInTransaction(ctx, tx, u, d.Name)
 	if err != nil {
+		if errors.Is(err, handler.ErrDataNotFound) {
+			// deletion of deleted data is successful
+			return nil
+		}
+
 		return err
 	}
 
-	return tx.Commit()
+	if err = doTransactionExec(ctx, tx, prepareDeleteDataQuery(u.Login, d.Name)); err != nil {
+		return err
+	}
+
+	released, err := releaseBlobInTransaction(ctx, tx, u.Login, row.hash)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	c.deleteBlob(baseCtx, released)
+
+	return nil
+}
+
+// getData reads data's row in own transaction, so blob store's requests are done without opened transaction
+func (c *Storage) getData(ctx context.Context, userToken string, dataKey string) (*handler.User, *dataRow, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	u, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	row, err := getDataInTransaction(ctx, tx, u, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, row, tx.Commit()
+}
+
+func (c *Storage) getUser(ctx context.Context, userToken string) (*handler.User, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	u, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, tx.Commit()
 }
 
 func (c *Storage) Register(ctx context.Context, u *handler.User) error {
@@ -268,7 +437,7 @@ func (c *Storage) Register(ctx context.Context, u *handler.User) error {
 	}
 	defer recoverAndRollBack(tx)
 
-	query := prepareGetUserQuery(u.Login)
+	query := prepareGetUserByLoginQuery(u.Login)
 
 	user, err := doTransactionQuery(ctxWithTimeout, tx, query, func(rows *sql.Rows) (*handler.User, error) {
 		if !rows.Next() {
@@ -305,6 +474,10 @@ func (c *Storage) Register(ctx context.Context, u *handler.User) error {
 }
 
 func (c *Storage) CreateCard(ctx context.Context, userToken string, card *handler.CardData) error {
+	if err := checkScope(ctx, handler.ItemKindCard, card.Number, true); err != nil {
+		return err
+	}
+
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -317,13 +490,61 @@ func (c *Storage) CreateCard(ctx context.Context, userToken string, card *handle
 	}
 
 	if err = doTransactionExec(ctx, tx, prepareAddCard(u.Login, card.Number, card.Data)); err != nil {
-		return nil
+		if isNotUniqueError(err) {
+			return handler.ErrDataAlreadyExist
+		}
+
+		return fmt.Errorf("add card user=%s, err=%w", u.Login, err)
 	}
 
 	return tx.Commit()
 }
 
-func (c *Storage) ListCard(ctx context.Context, userToken string) ([]*handler.CardData, error) {
+func (c *Storage) GetCard(ctx context.Context, userToken string, number string) (*handler.CardData, error) {
+	if err := checkScope(ctx, handler.ItemKindCard, number, false); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	u, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := doTransactionQuery(ctx, tx, prepareGetCard(u.Login, number), func(rows *sql.Rows) (*handler.CardData, error) {
+		if !rows.Next() {
+			return nil, fmt.Errorf("card=%s, err=%w", number, handler.ErrDataNotFound)
+		}
+
+		card := &handler.CardData{Number: number}
+		if err := rows.Scan(&card.Data); err != nil {
+			return nil, err
+		}
+
+		return card, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+func (c *Storage) ListCard(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.CardData, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindCard, false) {
+		return nil, handler.ErrForbidden
+	}
+
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -335,19 +556,21 @@ func (c *Storage) ListCard(ctx context.Context, userToken string) ([]*handler.Ca
 		return nil, err
 	}
 
-	cards, err := doTransactionQuery(ctx, tx, prepareListCard(u.Login), func(rows *sql.Rows) ([]*handler.CardData, error) {
-		list := make([]*handler.CardData, 0, 10)
+	query := prepareListQuery(walletListTable, u.Login, scope, q)
+
+	cards, err := doTransactionQuery(ctx, tx, query, func(rows *sql.Rows) ([]*handler.CardData, error) {
+		list := make([]*handler.CardData, 0, q.Limit)
 
 		for rows.Next() {
 			card := &handler.CardData{}
-			if err = rows.Scan(&card.Data, &card.Number); err != nil {
+			if err = rows.Scan(&card.Number, &card.Updated); err != nil {
 				return nil, err
 			}
 
 			list = append(list, card)
 		}
 
-		return list, nil
+		return list, rows.Err()
 	})
 	if err != nil {
 		return nil, err
@@ -361,6 +584,10 @@ func (c *Storage) ListCard(ctx context.Context, userToken string) ([]*handler.Ca
 }
 
 func (c *Storage) DeleteCard(ctx context.Context, userToken string, card *handler.CardData) error {
+	if err := checkScope(ctx, handler.ItemKindCard, card.Number, true); err != nil {
+		return err
+	}
+
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -372,7 +599,11 @@ func (c *Storage) DeleteCard(ctx context.Context, userToken string, card *handle
 		return err
 	}
 
-	return doTransactionExec(ctx, tx, prepareDeleteCard(u.Login, card.Number))
+	if err = doTransactionExec(ctx, tx, prepareDeleteCard(u.Login, card.Number)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func recoverAndRollBack(tx *sql.Tx) {
@@ -384,6 +615,10 @@ func recoverAndRollBack(tx *sql.Tx) {
 }
 
 func (c *Storage) CreateSecret(ctx context.Context, userToken string, secret *handler.Secret) error {
+	if err := checkScope(ctx, handler.ItemKindSecret, secret.Key, true); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
@@ -402,6 +637,10 @@ func (c *Storage) CreateSecret(ctx context.Context, userToken string, secret *ha
 
 	err = doTransactionExec(ctx, tx, query)
 	if err != nil {
+		if isNotUniqueError(err) {
+			return handler.ErrDataAlreadyExist
+		}
+
 		return fmt.Errorf("add secret user=%s data=%s, err=%w", user.Login, secret.Key, err)
 	}
 
@@ -409,6 +648,10 @@ func (c *Storage) CreateSecret(ctx context.Context, userToken string, secret *ha
 }
 
 func (c *Storage) GetSecret(ctx context.Context, userToken, secretKey string) (*handler.Secret, error) {
+	if err := checkScope(ctx, handler.ItemKindSecret, secretKey, false); err != nil {
+		return nil, err
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
@@ -429,7 +672,7 @@ func (c *Storage) GetSecret(ctx context.Context, userToken, secretKey string) (*
 		}
 
 		storedData := &handler.Secret{Key: secretKey}
-		if err := rows.Scan(&storedData.Key); err != nil {
+		if err := rows.Scan(&storedData.Value); err != nil {
 			return nil, err
 		}
 
@@ -448,6 +691,10 @@ func (c *Storage) GetSecret(ctx context.Context, userToken, secretKey string) (*
 }
 
 func (c *Storage) DeleteSecret(ctx context.Context, userToken, secretKey string) error {
+	if err := checkScope(ctx, handler.ItemKindSecret, secretKey, true); err != nil {
+		return err
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
@@ -470,7 +717,12 @@ func (c *Storage) DeleteSecret(ctx context.Context, userToken, secretKey string)
 	return tx.Commit()
 }
 
-func (c *Storage) ListSecret(ctx context.Context, userToken string) ([]*handler.Secret, error) {
+func (c *Storage) ListSecret(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.Secret, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindSecret, false) {
+		return nil, handler.ErrForbidden
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
@@ -485,18 +737,20 @@ func (c *Storage) ListSecret(ctx context.Context, userToken string) ([]*handler.
 		return nil, err
 	}
 
-	list, err := doTransactionQuery(ctx, tx, prepareListSecret(user.Login), func(rows *sql.Rows) ([]*handler.Secret, error) {
-		secrets := make([]*handler.Secret, 0, 10)
+	query := prepareListQuery(secretListTable, user.Login, scope, q)
+
+	list, err := doTransactionQuery(ctx, tx, query, func(rows *sql.Rows) ([]*handler.Secret, error) {
+		secrets := make([]*handler.Secret, 0, q.Limit)
 		for rows.Next() {
 			s := &handler.Secret{}
-			if err = rows.Scan(&s.Key, &s.Value); err != nil {
+			if err = rows.Scan(&s.Key, &s.Updated); err != nil {
 				return nil, err
 			}
 
 			secrets = append(secrets, s)
 		}
 
-		return secrets, nil
+		return secrets, rows.Err()
 	})
 	if err != nil {
 		return nil, err
@@ -509,33 +763,363 @@ func (c *Storage) ListSecret(ctx context.Context, userToken string) ([]*handler.
 	return list, nil
 }
 
-func getDataInTransaction(
-	ctx context.Context,
-	tx *sql.Tx,
-	u *handler.User,
-	dataKey string,
-) (*handler.Record, error) {
-	return doTransactionQuery(ctx, tx, prepareGetDataQuery(u.Login, dataKey), func(rows *sql.Rows) (*handler.Record, error) {
-		if !rows.Next() {
-			return nil, fmt.Errorf("key=%s, err=%w", dataKey, handler.ErrDataNotFound)
-		}
+func (c *Storage) CreateSSHKey(ctx context.Context, userToken string, key *handler.SSHKey) error {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, key.Name, true); err != nil {
+		return err
+	}
 
-		storedData := &handler.Record{Name: dataKey}
-		if err := rows.Scan(&storedData.Data, &storedData.Revision); err != nil {
-			return nil, err
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return err
+	}
+
+	err = doTransactionExec(ctx, tx, prepareAddSSHKey(user.Login, key.Name, key.Data))
+	if err != nil {
+		if isNotUniqueError(err) {
+			return handler.ErrDataAlreadyExist
 		}
 
-		return storedData, nil
+		return fmt.Errorf("add ssh key user=%s name=%s, err=%w", user.Login, key.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+func (c *Storage) GetSSHKey(ctx context.Context, userToken string, name string) (*handler.SSHKey, error) {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, name, false); err != nil {
+		return nil, err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctxWithTimeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := doTransactionQuery(ctx, tx, prepareGetSSHKey(user.Login, name), func(rows *sql.Rows) (*handler.SSHKey, error) {
+		if !rows.Next() {
+			return nil, fmt.Errorf("ssh key=%s, err=%w", name, handler.ErrDataNotFound)
+		}
+
+		key := &handler.SSHKey{Name: name}
+		if err := rows.Scan(&key.Data); err != nil {
+			return nil, err
+		}
+
+		return key, nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
 }
 
-func deleteDataInTransaction(
+func (c *Storage) ListSSHKey(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.SSHKey, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindSSHKey, false) {
+		return nil, handler.ErrForbidden
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctxWithTimeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	query := prepareListQuery(sshKeyListTable, user.Login, scope, q)
+
+	list, err := doTransactionQuery(ctx, tx, query, func(rows *sql.Rows) ([]*handler.SSHKey, error) {
+		keys := make([]*handler.SSHKey, 0, q.Limit)
+		for rows.Next() {
+			k := &handler.SSHKey{}
+			if err = rows.Scan(&k.Name, &k.Updated); err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, k)
+		}
+
+		return keys, rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (c *Storage) DeleteSSHKey(ctx context.Context, userToken string, name string) error {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, name, true); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return err
+	}
+
+	if err = doTransactionExec(ctx, tx, prepareDeleteSSHKey(user.Login, name)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (c *Storage) CreateServiceAccount(ctx context.Context, userToken string, account *handler.ServiceAccount) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	scope, err := json.Marshal(account.Scope)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return err
+	}
+
+	err = doTransactionExec(ctx, tx, prepareAddServiceAccount(user.Login, account.Name, account.TokenHash, string(scope)))
+	if err != nil {
+		if isNotUniqueError(err) {
+			return handler.ErrDataAlreadyExist
+		}
+
+		return fmt.Errorf("add service account user=%s name=%s, err=%w", user.Login, account.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+func (c *Storage) ListServiceAccounts(ctx context.Context, userToken string) ([]*handler.ServiceAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	q := prepareListServiceAccounts(user.Login)
+
+	list, err := doTransactionQuery(ctx, tx, q, func(rows *sql.Rows) ([]*handler.ServiceAccount, error) {
+		accounts := make([]*handler.ServiceAccount, 0, 10)
+		for rows.Next() {
+			account := &handler.ServiceAccount{Scope: &handler.Scope{}}
+			scope := ""
+
+			if err := rows.Scan(&account.Name, &scope, &account.CreatedAt); err != nil {
+				return nil, err
+			}
+
+			if err := json.Unmarshal([]byte(scope), account.Scope); err != nil {
+				return nil, err
+			}
+
+			accounts = append(accounts, account)
+		}
+
+		return accounts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (c *Storage) DeleteServiceAccount(ctx context.Context, userToken string, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return err
+	}
+
+	if err = doTransactionExec(ctx, tx, prepareDeleteServiceAccount(user.Login, name)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Audit writes event to audit log, event of unknown user isn't written
+func (c *Storage) Audit(ctx context.Context, event *handler.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	q := prepareAddAuditEvent(event)
+
+	_, err := doQuery(ctx, func(ctx context.Context) (sql.Result, error) {
+		return c.db.ExecContext(ctx, q.request, q.args...)
+	})
+
+	return err
+}
+
+func (c *Storage) ListAuditEvents(ctx context.Context, userToken string, limit int) ([]*handler.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := doTransactionQuery(ctx, tx, prepareListAuditEvents(user.Login, limit), func(rows *sql.Rows) ([]*handler.AuditEvent, error) {
+		events := make([]*handler.AuditEvent, 0, limit)
+		for rows.Next() {
+			e := &handler.AuditEvent{}
+			if err := rows.Scan(&e.Time, &e.ServiceAccount, &e.Device, &e.Action, &e.Kind, &e.Key, &e.IP, &e.Result); err != nil {
+				return nil, err
+			}
+
+			events = append(events, e)
+		}
+
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (c *Storage) Usage(ctx context.Context, userToken string) (*handler.Usage, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverAndRollBack(tx)
+
+	user, err := getUserInTransaction(ctx, tx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := doTransactionQuery(ctx, tx, prepareGetUserUsage(user.Login), func(rows *sql.Rows) (*handler.Usage, error) {
+		usage := &handler.Usage{}
+		for rows.Next() {
+			var items, bytes int64
+			if err := rows.Scan(&items, &bytes); err != nil {
+				return nil, err
+			}
+
+			usage.Items += int(items)
+			usage.Bytes += bytes
+		}
+
+		return usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func getDataInTransaction(
 	ctx context.Context,
 	tx *sql.Tx,
 	u *handler.User,
 	dataKey string,
-) error {
-	return doTransactionExec(ctx, tx, prepareDeleteDataQuery(u.Login, dataKey))
+) (*dataRow, error) {
+	return doTransactionQuery(ctx, tx, prepareGetDataQuery(u.Login, dataKey), func(rows *sql.Rows) (*dataRow, error) {
+		if !rows.Next() {
+			return nil, fmt.Errorf("key=%s, err=%w", dataKey, handler.ErrDataNotFound)
+		}
+
+		row := &dataRow{record: &handler.Record{Name: dataKey}}
+		if err := scanDataRow(rows, row); err != nil {
+			return nil, err
+		}
+
+		return row, nil
+	})
 }
 
 func getUserInTransaction(
@@ -565,6 +1149,11 @@ func getUserInTransaction(
 	return storedUser, nil
 }
 
+// checkScope enforces scope of service account's token which is set by auth middleware
+func checkScope(ctx context.Context, kind string, name string, write bool) error {
+	return handler.ScopeFromContext(ctx).Check(kind, name, write)
+}
+
 // ----------------------------------------------------------------------------------------------
 // -------------------------------------- Internal Methods --------------------------------------
 // ----------------------------------------------------------------------------------------------
@@ -583,11 +1172,15 @@ func doQuery[T any](ctx context.Context, queryFunc func(context.Context) (T, err
 	var err error
 
 	for trying := 0; trying <= max; trying++ {
+		start := time.Now()
 		result, err = queryFunc(ctx)
+		observeQuery(start, err)
+
 		if err != nil {
 			commonErr = errors.Join(commonErr, err)
 
 			if trying < max && isRetriableError(err) {
+				queryRetries.Inc()
 				time.Sleep(tryingIntervals[trying])
 				continue
 			}
@@ -601,18 +1194,6 @@ func doQuery[T any](ctx context.Context, queryFunc func(context.Context) (T, err
 	return result, commonErr
 }
 
-func isRetriableError(err error) bool {
-	var pgErr *pgconn.PgError
-
-	return errors.As(err, &pgErr) && pgerrcode.IsConnectionException(pgErr.Code)
-}
-
-func isNotUniqueError(err error) bool {
-	var pgErr *pgconn.PgError
-
-	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation
-}
-
 func doTransactionExec(ctx context.Context, tx *sql.Tx, query *query) error {
 	_, err := doQuery(ctx, func(ctx context.Context) (sql.Result, error) {
 		return tx.ExecContext(ctx, query.request, query.args...)
@@ -628,7 +1209,7 @@ func doTransactionQuery[T any](ctx context.Context, tx *sql.Tx, query *query, pa
 		return tx.QueryContext(ctx, query.request, query.args...)
 	})
 	if err != nil {
-		return result, nil
+		return result, err
 	}
 
 	defer func() {
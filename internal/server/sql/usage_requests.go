@@ -0,0 +1,11 @@
+package sql
+
+// user's items of all kinds, size of binary data is size of its deduplicated payloads
+const getUserUsage = `SELECT (SELECT count(*) FROM binary_data WHERE "user" = $1), (SELECT COALESCE(sum("size"), 0) FROM blobs WHERE "user" = $1)
+	UNION ALL SELECT count(*), COALESCE(sum(octet_length("card_data")), 0) FROM wallet WHERE "user" = $1
+	UNION ALL SELECT count(*), COALESCE(sum(octet_length("secret_value")), 0) FROM secrets WHERE "user" = $1
+	UNION ALL SELECT count(*), COALESCE(sum(octet_length("data")), 0) FROM ssh_keys WHERE "user" = $1;`
+
+func prepareGetUserUsage(user string) *query {
+	return &query{request: getUserUsage, args: []any{user}}
+}
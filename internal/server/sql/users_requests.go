@@ -1,15 +1,9 @@
 package sql
 
 const (
-	createUsersTableQuery = `CREATE TABLE IF NOT EXISTS users (
-		"login"		text NOT NULL,
-		"password"  text NOT NULL,
-		"token"		text NOT NULL,
-		PRIMARY KEY ( "token" )
-	);`
-
 	createUserQuery = `INSERT INTO users (login, password, token) VALUES ($1, $2, $3);`
 	getUserByToken  = `SELECT * FROM users WHERE token = $1;`
+	getUserByLogin  = `SELECT "login", "password" FROM users WHERE "login" = $1;`
 )
 
 func prepareCreateUserQuery(login, password, token string) *query {
@@ -19,3 +13,7 @@ func prepareCreateUserQuery(login, password, token string) *query {
 func prepareGetUserQuery(token string) *query {
 	return &query{request: getUserByToken, args: []any{token}}
 }
+
+func prepareGetUserByLoginQuery(login string) *query {
+	return &query{request: getUserByLogin, args: []any{login}}
+}
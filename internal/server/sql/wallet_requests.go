@@ -1,24 +1,17 @@
 package sql
 
 const (
-	createWalletTableQuery = `CREATE TABLE IF NOT EXISTS wallet (
-		"user"				text	NOT NULL,
-		"card_number"		text	NOT NULL,
-		"card_data"			text	NOT NULL,
-		PRIMARY KEY ( "user", "card_number" )
-	);`
-
-	addCard    = `INSERT INTO wallet ("user", "card_number", "card_data") VALUES ($1, $2, $3);`
-	listCard   = `SELECT "card_number", "card_data" FROM wallet WHERE "user" = $1;`
+	addCard    = `INSERT INTO wallet ("user", "card_number", "card_data", "updated_at") VALUES ($1, $2, $3, $4);`
+	getCard    = `SELECT "card_data" FROM wallet WHERE "user" = $1 AND "card_number" = $2;`
 	deleteCard = `DELETE FROM wallet WHERE "user" = $1 AND "card_number" = $2;`
 )
 
 func prepareAddCard(user, number, data string) *query {
-	return &query{request: addCard, args: []any{user, number, data}}
+	return &query{request: addCard, args: []any{user, number, data, updateTime()}}
 }
 
-func prepareListCard(user string) *query {
-	return &query{request: listCard, args: []any{user}}
+func prepareGetCard(user, number string) *query {
+	return &query{request: getCard, args: []any{user, number}}
 }
 
 func prepareDeleteCard(user, number string) *query {
@@ -0,0 +1,654 @@
+// Package memstorage keeps server's items in memory, it's used for tests and short-living servers
+package memstorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+)
+
+var _ storage.Storage = &Storage{}
+
+type serviceAccount struct {
+	login   string
+	account *handler.ServiceAccount
+}
+
+// userItems are items of the one user
+type userItems struct {
+	user            *handler.User
+	data            map[string]*handler.Record
+	cards           map[string]*handler.CardData
+	secrets         map[string]*handler.Secret
+	sshKeys         map[string]*handler.SSHKey
+	serviceAccounts map[string]*handler.ServiceAccount
+	audit           []*handler.AuditEvent
+}
+
+type Storage struct {
+	mu sync.RWMutex
+
+	// users by login
+	users map[string]*userItems
+	// logins by token
+	tokens map[string]string
+	// service accounts by token's hash
+	serviceAccounts map[string]*serviceAccount
+}
+
+func New() *Storage {
+	return &Storage{
+		users:           make(map[string]*userItems),
+		tokens:          make(map[string]string),
+		serviceAccounts: make(map[string]*serviceAccount),
+	}
+}
+
+func (s *Storage) Stop() error {
+	return nil
+}
+
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
+}
+
+// MigrationVersion returns 0, memory storage doesn't have schema
+func (s *Storage) MigrationVersion(_ context.Context) (int, error) {
+	return 0, nil
+}
+
+func (s *Storage) Check(_ context.Context, token string) (*handler.Access, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[token]; ok {
+		return &handler.Access{UserToken: token}, nil
+	}
+
+	sa, ok := s.serviceAccounts[handler.HashServiceAccountToken(token)]
+	if !ok {
+		return nil, handler.ErrUnknownUser
+	}
+
+	scope := *sa.account.Scope
+
+	return &handler.Access{UserToken: s.users[sa.login].user.Token, Scope: &scope, ServiceAccount: sa.account.Name}, nil
+}
+
+func (s *Storage) Register(_ context.Context, u *handler.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if items, ok := s.users[u.Login]; ok {
+		if items.user.Password != u.Password {
+			return handler.ErrBadPassword
+		}
+
+		return nil
+	}
+
+	user := *u
+
+	s.users[u.Login] = &userItems{
+		user:            &user,
+		data:            make(map[string]*handler.Record),
+		cards:           make(map[string]*handler.CardData),
+		secrets:         make(map[string]*handler.Secret),
+		sshKeys:         make(map[string]*handler.SSHKey),
+		serviceAccounts: make(map[string]*handler.ServiceAccount),
+	}
+	s.tokens[u.Token] = u.Login
+
+	return nil
+}
+
+func (s *Storage) CreateData(ctx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(ctx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		if _, ok := items.data[d.Name]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		if err := items.fillContent(d); err != nil {
+			return err
+		}
+
+		items.data[d.Name] = &handler.Record{
+			Name: d.Name, Data: d.Data, Revision: 1, Metainfo: d.Metainfo, ContentHash: handler.ContentHash(d), Updated: time.Now(),
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) UpdateData(ctx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(ctx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		stored, ok := items.data[d.Name]
+		if !ok {
+			return fmt.Errorf("key=%s, err=%w", d.Name, handler.ErrDataNotFound)
+		}
+
+		if stored.Revision != d.Revision {
+			return fmt.Errorf("data=%s err=%w", d.Name, handler.ErrBadRevision)
+		}
+
+		if err := items.fillContent(d); err != nil {
+			return err
+		}
+
+		items.data[d.Name] = &handler.Record{
+			Name:        d.Name,
+			Data:        d.Data,
+			Revision:    stored.Revision + 1,
+			Metainfo:    d.Metainfo,
+			ContentHash: handler.ContentHash(d),
+			Updated:     time.Now(),
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) LoadData(ctx context.Context, userToken string, dataKey string) (*handler.Record, error) {
+	if err := checkScope(ctx, handler.ItemKindData, dataKey, false); err != nil {
+		return nil, err
+	}
+
+	var record *handler.Record
+
+	err := s.read(userToken, func(items *userItems) error {
+		stored, ok := items.data[dataKey]
+		if !ok {
+			return fmt.Errorf("key=%s, err=%w", dataKey, handler.ErrDataNotFound)
+		}
+
+		record = copyRecord(stored)
+
+		return nil
+	})
+
+	return record, err
+}
+
+func (s *Storage) ListData(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.Record, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindData, false) {
+		return nil, handler.ErrForbidden
+	}
+
+	records := make([]*handler.Record, 0, 10)
+
+	err := s.read(userToken, func(items *userItems) error {
+		for _, stored := range items.data {
+			if !scope.Allows(handler.ItemKindData, stored.Name, false) || !handler.MatchListQuery(q, stored.Name, stored.Updated) {
+				continue
+			}
+
+			records = append(records, &handler.Record{
+				Name: stored.Name, Revision: stored.Revision, Metainfo: stored.Metainfo, Updated: stored.Updated,
+			})
+		}
+
+		return nil
+	})
+
+	return page(q, records, func(r *handler.Record) (string, time.Time) { return r.Name, r.Updated }), err
+}
+
+func (s *Storage) DeleteData(ctx context.Context, userToken string, d *handler.Record) error {
+	if err := checkScope(ctx, handler.ItemKindData, d.Name, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		delete(items.data, d.Name)
+
+		return nil
+	})
+}
+
+// copyRecord returns record like sql storage, which doesn't return content hashes and update times
+func copyRecord(stored *handler.Record) *handler.Record {
+	r := *stored
+	r.ContentHash = ""
+	r.Updated = time.Time{}
+
+	return &r
+}
+
+// fillContent sets data of the record which has only content hash from user's data with the same hash
+func (items *userItems) fillContent(d *handler.Record) error {
+	if len(d.Data) > 0 {
+		return nil
+	}
+
+	for _, stored := range items.data {
+		if stored.ContentHash == d.ContentHash {
+			d.Data = stored.Data
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data=%s, hash=%s, err=%w", d.Name, d.ContentHash, handler.ErrContentNotFound)
+}
+
+func (s *Storage) CreateCard(ctx context.Context, userToken string, card *handler.CardData) error {
+	if err := checkScope(ctx, handler.ItemKindCard, card.Number, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		if _, ok := items.cards[card.Number]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		items.cards[card.Number] = &handler.CardData{Number: card.Number, Data: card.Data, Updated: time.Now()}
+
+		return nil
+	})
+}
+
+func (s *Storage) GetCard(ctx context.Context, userToken string, number string) (*handler.CardData, error) {
+	if err := checkScope(ctx, handler.ItemKindCard, number, false); err != nil {
+		return nil, err
+	}
+
+	var card *handler.CardData
+
+	err := s.read(userToken, func(items *userItems) error {
+		stored, ok := items.cards[number]
+		if !ok {
+			return fmt.Errorf("card=%s, err=%w", number, handler.ErrDataNotFound)
+		}
+
+		card = &handler.CardData{Number: stored.Number, Data: stored.Data}
+
+		return nil
+	})
+
+	return card, err
+}
+
+func (s *Storage) ListCard(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.CardData, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindCard, false) {
+		return nil, handler.ErrForbidden
+	}
+
+	cards := make([]*handler.CardData, 0, 10)
+
+	err := s.read(userToken, func(items *userItems) error {
+		for _, stored := range items.cards {
+			if !scope.Allows(handler.ItemKindCard, stored.Number, false) || !handler.MatchListQuery(q, stored.Number, stored.Updated) {
+				continue
+			}
+
+			cards = append(cards, &handler.CardData{Number: stored.Number, Updated: stored.Updated})
+		}
+
+		return nil
+	})
+
+	return page(q, cards, func(c *handler.CardData) (string, time.Time) { return c.Number, c.Updated }), err
+}
+
+func (s *Storage) DeleteCard(ctx context.Context, userToken string, card *handler.CardData) error {
+	if err := checkScope(ctx, handler.ItemKindCard, card.Number, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		delete(items.cards, card.Number)
+
+		return nil
+	})
+}
+
+func (s *Storage) CreateSecret(ctx context.Context, userToken string, secret *handler.Secret) error {
+	if err := checkScope(ctx, handler.ItemKindSecret, secret.Key, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		if _, ok := items.secrets[secret.Key]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		items.secrets[secret.Key] = &handler.Secret{Key: secret.Key, Value: secret.Value, Updated: time.Now()}
+
+		return nil
+	})
+}
+
+func (s *Storage) GetSecret(ctx context.Context, userToken, secretKey string) (*handler.Secret, error) {
+	if err := checkScope(ctx, handler.ItemKindSecret, secretKey, false); err != nil {
+		return nil, err
+	}
+
+	var secret *handler.Secret
+
+	err := s.read(userToken, func(items *userItems) error {
+		stored, ok := items.secrets[secretKey]
+		if !ok {
+			return fmt.Errorf("secretKey=%s, err=%w", secretKey, handler.ErrDataNotFound)
+		}
+
+		secret = &handler.Secret{Key: stored.Key, Value: stored.Value}
+
+		return nil
+	})
+
+	return secret, err
+}
+
+func (s *Storage) DeleteSecret(ctx context.Context, userToken, secretKey string) error {
+	if err := checkScope(ctx, handler.ItemKindSecret, secretKey, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		delete(items.secrets, secretKey)
+
+		return nil
+	})
+}
+
+func (s *Storage) ListSecret(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.Secret, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindSecret, false) {
+		return nil, handler.ErrForbidden
+	}
+
+	secrets := make([]*handler.Secret, 0, 10)
+
+	err := s.read(userToken, func(items *userItems) error {
+		for _, stored := range items.secrets {
+			if !scope.Allows(handler.ItemKindSecret, stored.Key, false) || !handler.MatchListQuery(q, stored.Key, stored.Updated) {
+				continue
+			}
+
+			secrets = append(secrets, &handler.Secret{Key: stored.Key, Updated: stored.Updated})
+		}
+
+		return nil
+	})
+
+	return page(q, secrets, func(s *handler.Secret) (string, time.Time) { return s.Key, s.Updated }), err
+}
+
+// page sorts matched items in the query's order and cuts them to the query's limit
+func page[T any](q *handler.ListQuery, items []T, position func(T) (string, time.Time)) []T {
+	slices.SortFunc(items, func(a, b T) int {
+		aName, aUpdated := position(a)
+		bName, bUpdated := position(b)
+
+		return handler.CompareListPosition(q, aName, aUpdated, bName, bUpdated)
+	})
+
+	if len(items) > q.Limit {
+		items = items[:q.Limit]
+	}
+
+	return items
+}
+
+func (s *Storage) CreateSSHKey(ctx context.Context, userToken string, key *handler.SSHKey) error {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, key.Name, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		if _, ok := items.sshKeys[key.Name]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		items.sshKeys[key.Name] = &handler.SSHKey{Name: key.Name, Data: key.Data, Updated: time.Now()}
+
+		return nil
+	})
+}
+
+func (s *Storage) GetSSHKey(ctx context.Context, userToken string, name string) (*handler.SSHKey, error) {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, name, false); err != nil {
+		return nil, err
+	}
+
+	var key *handler.SSHKey
+
+	err := s.read(userToken, func(items *userItems) error {
+		stored, ok := items.sshKeys[name]
+		if !ok {
+			return fmt.Errorf("ssh key=%s, err=%w", name, handler.ErrDataNotFound)
+		}
+
+		key = &handler.SSHKey{Name: stored.Name, Data: stored.Data}
+
+		return nil
+	})
+
+	return key, err
+}
+
+func (s *Storage) ListSSHKey(ctx context.Context, userToken string, q *handler.ListQuery) ([]*handler.SSHKey, error) {
+	scope := handler.ScopeFromContext(ctx)
+	if !scope.AllowsKind(handler.ItemKindSSHKey, false) {
+		return nil, handler.ErrForbidden
+	}
+
+	keys := make([]*handler.SSHKey, 0, 10)
+
+	err := s.read(userToken, func(items *userItems) error {
+		for _, stored := range items.sshKeys {
+			if !scope.Allows(handler.ItemKindSSHKey, stored.Name, false) || !handler.MatchListQuery(q, stored.Name, stored.Updated) {
+				continue
+			}
+
+			keys = append(keys, &handler.SSHKey{Name: stored.Name, Updated: stored.Updated})
+		}
+
+		return nil
+	})
+
+	return page(q, keys, func(k *handler.SSHKey) (string, time.Time) { return k.Name, k.Updated }), err
+}
+
+func (s *Storage) DeleteSSHKey(ctx context.Context, userToken string, name string) error {
+	if err := checkScope(ctx, handler.ItemKindSSHKey, name, true); err != nil {
+		return err
+	}
+
+	return s.write(userToken, func(items *userItems) error {
+		delete(items.sshKeys, name)
+
+		return nil
+	})
+}
+
+func (s *Storage) CreateServiceAccount(_ context.Context, userToken string, account *handler.ServiceAccount) error {
+	return s.write(userToken, func(items *userItems) error {
+		if _, ok := items.serviceAccounts[account.Name]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		if _, ok := s.serviceAccounts[account.TokenHash]; ok {
+			return handler.ErrDataAlreadyExist
+		}
+
+		scope := *account.Scope
+		stored := &handler.ServiceAccount{Name: account.Name, TokenHash: account.TokenHash, Scope: &scope, CreatedAt: time.Now()}
+
+		items.serviceAccounts[account.Name] = stored
+		s.serviceAccounts[account.TokenHash] = &serviceAccount{login: items.user.Login, account: stored}
+
+		return nil
+	})
+}
+
+func (s *Storage) ListServiceAccounts(_ context.Context, userToken string) ([]*handler.ServiceAccount, error) {
+	accounts := make([]*handler.ServiceAccount, 0, 10)
+
+	err := s.read(userToken, func(items *userItems) error {
+		for _, stored := range items.serviceAccounts {
+			scope := *stored.Scope
+			accounts = append(accounts, &handler.ServiceAccount{Name: stored.Name, Scope: &scope, CreatedAt: stored.CreatedAt})
+		}
+
+		return nil
+	})
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+
+	return accounts, err
+}
+
+func (s *Storage) DeleteServiceAccount(_ context.Context, userToken string, name string) error {
+	return s.write(userToken, func(items *userItems) error {
+		stored, ok := items.serviceAccounts[name]
+		if !ok {
+			return nil
+		}
+
+		delete(items.serviceAccounts, name)
+		delete(s.serviceAccounts, stored.TokenHash)
+
+		return nil
+	})
+}
+
+// Audit writes event to audit log, event of unknown user isn't written
+func (s *Storage) Audit(_ context.Context, event *handler.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	login := event.Login
+	if len(login) == 0 {
+		login = s.tokens[event.UserToken]
+	}
+
+	items, ok := s.users[login]
+	if !ok {
+		return nil
+	}
+
+	// listed events don't have user's credentials
+	e := *event
+	e.Login = ""
+	e.UserToken = ""
+
+	items.audit = append(items.audit, &e)
+
+	return nil
+}
+
+func (s *Storage) ListAuditEvents(_ context.Context, userToken string, limit int) ([]*handler.AuditEvent, error) {
+	events := make([]*handler.AuditEvent, 0, limit)
+
+	err := s.read(userToken, func(items *userItems) error {
+		// the newest event is the first, events with the same time are ordered by writing
+		for i := len(items.audit) - 1; i >= 0; i-- {
+			e := *items.audit[i]
+			events = append(events, &e)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(events, func(a, b *handler.AuditEvent) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	return events[:min(limit, len(events))], nil
+}
+
+func (s *Storage) Usage(_ context.Context, userToken string) (*handler.Usage, error) {
+	usage := &handler.Usage{}
+
+	err := s.read(userToken, func(items *userItems) error {
+		// data with the same content is counted once like deduplicated data of sql storage
+		contents := make(map[string]struct{}, len(items.data))
+		for _, d := range items.data {
+			if _, ok := contents[d.ContentHash]; ok {
+				continue
+			}
+
+			contents[d.ContentHash] = struct{}{}
+			usage.Bytes += int64(len(d.Data))
+		}
+
+		for _, card := range items.cards {
+			usage.Bytes += int64(len(card.Data))
+		}
+
+		for _, secret := range items.secrets {
+			usage.Bytes += int64(len(secret.Value))
+		}
+
+		for _, key := range items.sshKeys {
+			usage.Bytes += int64(len(key.Data))
+		}
+
+		usage.Items = len(items.data) + len(items.cards) + len(items.secrets) + len(items.sshKeys)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (s *Storage) read(userToken string, fn func(items *userItems) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items, err := s.userItems(userToken)
+	if err != nil {
+		return err
+	}
+
+	return fn(items)
+}
+
+func (s *Storage) write(userToken string, fn func(items *userItems) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.userItems(userToken)
+	if err != nil {
+		return err
+	}
+
+	return fn(items)
+}
+
+func (s *Storage) userItems(userToken string) (*userItems, error) {
+	login, ok := s.tokens[userToken]
+	if !ok {
+		return nil, fmt.Errorf("user isn't registred err=%w", handler.ErrUnknownUser)
+	}
+
+	return s.users[login], nil
+}
+
+// checkScope enforces scope of service account's token which is set by auth middleware
+func checkScope(ctx context.Context, kind string, name string, write bool) error {
+	return handler.ScopeFromContext(ctx).Check(kind, name, write)
+}
@@ -0,0 +1,14 @@
+package memstorage
+
+import (
+	"testing"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return New()
+	})
+}
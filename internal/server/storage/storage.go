@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/middleware"
+)
+
+// storage's drivers which are selected by server's config
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Storage is a backend of all server's handlers, every driver must pass storagetest's conformance suite
+type Storage interface {
+	middleware.UserChecker
+	handler.Registrator
+	handler.DataStorage
+	handler.WalletStorage
+	handler.SecretStorage
+	handler.SSHKeyStorage
+	handler.ServiceAccountStorage
+	handler.Auditor
+	handler.AuditStorage
+	handler.ReadinessChecker
+	handler.UsageStorage
+
+	Stop() error
+}
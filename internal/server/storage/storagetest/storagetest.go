@@ -0,0 +1,578 @@
+// Package storagetest is a conformance suite which every server's storage driver must pass
+package storagetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuzhukin/goph-keeper/internal/server/handler"
+	"github.com/kuzhukin/goph-keeper/internal/server/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs conformance suite, newStorage must return empty storage which is stopped by the suite
+func Run(t *testing.T, newStorage func(t *testing.T) storage.Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Storage)
+	}{
+		{name: "register", test: testRegister},
+		{name: "check", test: testCheck},
+		{name: "data", test: testData},
+		{name: "cards", test: testCards},
+		{name: "secrets", test: testSecrets},
+		{name: "ssh keys", test: testSSHKeys},
+		{name: "users isolation", test: testUsersIsolation},
+		{name: "service accounts", test: testServiceAccounts},
+		{name: "scope", test: testScope},
+		{name: "audit", test: testAudit},
+		{name: "readiness", test: testReadiness},
+		{name: "usage", test: testUsage},
+		{name: "dedup", test: testDedup},
+		{name: "list", test: testList},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			defer func() {
+				require.NoError(t, s.Stop())
+			}()
+
+			tt.test(t, s)
+		})
+	}
+}
+
+func registerUser(t *testing.T, s storage.Storage, login string) *handler.User {
+	u := &handler.User{Login: login, Password: login + "-password", Token: login + "-token"}
+	require.NoError(t, s.Register(context.Background(), u))
+
+	return u
+}
+
+func withScope(ctx context.Context, scope *handler.Scope) context.Context {
+	return context.WithValue(ctx, handler.AuthInfo("scope"), scope)
+}
+
+// listAll is a query of the one page with all user's items
+func listAll() *handler.ListQuery {
+	return &handler.ListQuery{SortBy: handler.SortByName, Limit: handler.MaxPageSize}
+}
+
+// resetUpdateTimes checks update times of listed items and resets them, so items are compared with expected ones
+func resetUpdateTimes[T any](t *testing.T, items []T, updated func(T) *time.Time) {
+	for _, item := range items {
+		require.False(t, updated(item).IsZero())
+		*updated(item) = time.Time{}
+	}
+}
+
+func recordUpdated(r *handler.Record) *time.Time { return &r.Updated }
+
+func cardUpdated(c *handler.CardData) *time.Time { return &c.Updated }
+
+func secretUpdated(s *handler.Secret) *time.Time { return &s.Updated }
+
+func sshKeyUpdated(k *handler.SSHKey) *time.Time { return &k.Updated }
+
+func testRegister(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	// login of the registered user
+	require.NoError(t, s.Register(ctx, &handler.User{Login: u.Login, Password: u.Password, Token: "other-token"}))
+
+	err := s.Register(ctx, &handler.User{Login: u.Login, Password: "bad", Token: "bad-token"})
+	require.ErrorIs(t, err, handler.ErrBadPassword)
+}
+
+func testCheck(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	access, err := s.Check(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Access{UserToken: u.Token}, access)
+
+	_, err = s.Check(ctx, "unknown-token")
+	require.ErrorIs(t, err, handler.ErrUnknownUser)
+}
+
+func testData(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	_, err := s.LoadData(ctx, u.Token, "data")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "data", Data: "v1", Metainfo: "m1"}))
+
+	err = s.CreateData(ctx, u.Token, &handler.Record{Name: "data", Data: "v1"})
+	require.ErrorIs(t, err, handler.ErrDataAlreadyExist)
+
+	r, err := s.LoadData(ctx, u.Token, "data")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Record{Name: "data", Data: "v1", Revision: 1, Metainfo: "m1"}, r)
+
+	// update is based on the stored revision
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "data", Data: "v2", Revision: 1, Metainfo: "m2"}))
+
+	err = s.UpdateData(ctx, u.Token, &handler.Record{Name: "data", Data: "v3", Revision: 1})
+	require.ErrorIs(t, err, handler.ErrBadRevision)
+
+	// the same content with new metainfo is a new revision
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "data", Data: "v2", Revision: 2, Metainfo: "m3"}))
+
+	r, err = s.LoadData(ctx, u.Token, "data")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Record{Name: "data", Data: "v2", Revision: 3, Metainfo: "m3"}, r)
+
+	err = s.UpdateData(ctx, u.Token, &handler.Record{Name: "unknown", Data: "v", Revision: 1})
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "other", Data: "o"}))
+
+	// list doesn't return data
+	list, err := s.ListData(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, list, recordUpdated)
+	require.Equal(t, []*handler.Record{
+		{Name: "data", Revision: 3, Metainfo: "m3"},
+		{Name: "other", Revision: 1},
+	}, list)
+
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "data"}))
+
+	_, err = s.LoadData(ctx, u.Token, "data")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	// deletion is idempotent
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "data"}))
+}
+
+func testCards(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	require.NoError(t, s.CreateCard(ctx, u.Token, &handler.CardData{Number: "1111", Data: "d1"}))
+	require.NoError(t, s.CreateCard(ctx, u.Token, &handler.CardData{Number: "2222", Data: "d2"}))
+
+	err := s.CreateCard(ctx, u.Token, &handler.CardData{Number: "1111", Data: "d"})
+	require.ErrorIs(t, err, handler.ErrDataAlreadyExist)
+
+	card, err := s.GetCard(ctx, u.Token, "1111")
+	require.NoError(t, err)
+	require.Equal(t, &handler.CardData{Number: "1111", Data: "d1"}, card)
+
+	cards, err := s.ListCard(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, cards, cardUpdated)
+	require.Equal(t, []*handler.CardData{{Number: "1111"}, {Number: "2222"}}, cards)
+
+	require.NoError(t, s.DeleteCard(ctx, u.Token, &handler.CardData{Number: "1111"}))
+
+	_, err = s.GetCard(ctx, u.Token, "1111")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	cards, err = s.ListCard(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, cards, cardUpdated)
+	require.Equal(t, []*handler.CardData{{Number: "2222"}}, cards)
+}
+
+func testSecrets(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	_, err := s.GetSecret(ctx, u.Token, "secret")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "secret", Value: "v"}))
+	require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "other", Value: "o"}))
+
+	err = s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "secret", Value: "v"})
+	require.ErrorIs(t, err, handler.ErrDataAlreadyExist)
+
+	secret, err := s.GetSecret(ctx, u.Token, "secret")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Secret{Key: "secret", Value: "v"}, secret)
+
+	list, err := s.ListSecret(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, list, secretUpdated)
+	require.Equal(t, []*handler.Secret{{Key: "other"}, {Key: "secret"}}, list)
+
+	require.NoError(t, s.DeleteSecret(ctx, u.Token, "secret"))
+
+	_, err = s.GetSecret(ctx, u.Token, "secret")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+}
+
+func testSSHKeys(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	_, err := s.GetSSHKey(ctx, u.Token, "key")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	require.NoError(t, s.CreateSSHKey(ctx, u.Token, &handler.SSHKey{Name: "key", Data: "d"}))
+	require.NoError(t, s.CreateSSHKey(ctx, u.Token, &handler.SSHKey{Name: "deploy", Data: "o"}))
+
+	err = s.CreateSSHKey(ctx, u.Token, &handler.SSHKey{Name: "key", Data: "d"})
+	require.ErrorIs(t, err, handler.ErrDataAlreadyExist)
+
+	key, err := s.GetSSHKey(ctx, u.Token, "key")
+	require.NoError(t, err)
+	require.Equal(t, &handler.SSHKey{Name: "key", Data: "d"}, key)
+
+	list, err := s.ListSSHKey(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, list, sshKeyUpdated)
+	require.Equal(t, []*handler.SSHKey{{Name: "deploy"}, {Name: "key"}}, list)
+
+	require.NoError(t, s.DeleteSSHKey(ctx, u.Token, "key"))
+
+	_, err = s.GetSSHKey(ctx, u.Token, "key")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	require.NoError(t, s.CreateSSHKey(ctx, u.Token, &handler.SSHKey{Name: "key", Data: "d"}))
+}
+
+func testUsersIsolation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	first := registerUser(t, s, "first")
+	second := registerUser(t, s, "second")
+
+	require.NoError(t, s.CreateData(ctx, first.Token, &handler.Record{Name: "data", Data: "first"}))
+	require.NoError(t, s.CreateData(ctx, second.Token, &handler.Record{Name: "data", Data: "second"}))
+	require.NoError(t, s.CreateSecret(ctx, first.Token, &handler.Secret{Key: "secret", Value: "first"}))
+
+	r, err := s.LoadData(ctx, second.Token, "data")
+	require.NoError(t, err)
+	require.Equal(t, "second", r.Data)
+
+	_, err = s.GetSecret(ctx, second.Token, "secret")
+	require.ErrorIs(t, err, handler.ErrDataNotFound)
+
+	secrets, err := s.ListSecret(ctx, second.Token, listAll())
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+
+	_, err = s.ListData(ctx, "unknown-token", listAll())
+	require.ErrorIs(t, err, handler.ErrUnknownUser)
+}
+
+func testServiceAccounts(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	scope := &handler.Scope{Kinds: []string{handler.ItemKindSecret}, Prefixes: []string{"ci/"}, ReadOnly: true}
+	account := &handler.ServiceAccount{Name: "ci", TokenHash: handler.HashServiceAccountToken("ci-token"), Scope: scope}
+
+	require.NoError(t, s.CreateServiceAccount(ctx, u.Token, account))
+
+	err := s.CreateServiceAccount(ctx, u.Token, &handler.ServiceAccount{
+		Name:      "ci",
+		TokenHash: handler.HashServiceAccountToken("other-token"),
+		Scope:     &handler.Scope{},
+	})
+	require.ErrorIs(t, err, handler.ErrDataAlreadyExist)
+
+	access, err := s.Check(ctx, "ci-token")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Access{UserToken: u.Token, Scope: scope, ServiceAccount: "ci"}, access)
+
+	accounts, err := s.ListServiceAccounts(ctx, u.Token)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "ci", accounts[0].Name)
+	require.Equal(t, scope, accounts[0].Scope)
+	require.Empty(t, accounts[0].TokenHash)
+	require.False(t, accounts[0].CreatedAt.IsZero())
+
+	require.NoError(t, s.DeleteServiceAccount(ctx, u.Token, "ci"))
+
+	_, err = s.Check(ctx, "ci-token")
+	require.ErrorIs(t, err, handler.ErrUnknownUser)
+}
+
+func testScope(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "ci/token", Value: "v"}))
+	require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "prod/token", Value: "v"}))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "ci/data", Data: "d"}))
+
+	scoped := withScope(ctx, &handler.Scope{Kinds: []string{handler.ItemKindSecret}, Prefixes: []string{"ci/"}, ReadOnly: true})
+
+	_, err := s.GetSecret(scoped, u.Token, "ci/token")
+	require.NoError(t, err)
+
+	_, err = s.GetSecret(scoped, u.Token, "prod/token")
+	require.ErrorIs(t, err, handler.ErrForbidden)
+
+	list, err := s.ListSecret(scoped, u.Token, listAll())
+	require.NoError(t, err)
+	resetUpdateTimes(t, list, secretUpdated)
+	require.Equal(t, []*handler.Secret{{Key: "ci/token"}}, list)
+
+	err = s.CreateSecret(scoped, u.Token, &handler.Secret{Key: "ci/new", Value: "v"})
+	require.ErrorIs(t, err, handler.ErrForbidden)
+
+	err = s.DeleteSecret(scoped, u.Token, "ci/token")
+	require.ErrorIs(t, err, handler.ErrForbidden)
+
+	_, err = s.LoadData(scoped, u.Token, "ci/data")
+	require.ErrorIs(t, err, handler.ErrForbidden)
+
+	_, err = s.ListData(scoped, u.Token, listAll())
+	require.ErrorIs(t, err, handler.ErrForbidden)
+}
+
+func testAudit(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+	registerUser(t, s, "other")
+
+	start := time.Now().Truncate(time.Second)
+
+	events := []*handler.AuditEvent{
+		{Time: start, Login: u.Login, Action: handler.AuditActionLogin, IP: "10.0.0.1", Result: handler.AuditResultOK},
+		{Time: start.Add(time.Second), UserToken: u.Token, Device: "laptop", Action: handler.AuditActionRead, Kind: handler.ItemKindSecret, Key: "s", IP: "10.0.0.1", Result: handler.AuditResultNotFound},
+		{Time: start.Add(time.Second * 2), UserToken: u.Token, ServiceAccount: "ci", Action: handler.AuditActionCreate, Kind: handler.ItemKindData, Key: "d", IP: "10.0.0.2", Result: handler.AuditResultOK},
+		{Time: start, Login: "other", Action: handler.AuditActionLogin, IP: "10.0.0.3", Result: handler.AuditResultOK},
+	}
+
+	for _, e := range events {
+		require.NoError(t, s.Audit(ctx, e))
+	}
+
+	// event of unknown user isn't written
+	require.NoError(t, s.Audit(ctx, &handler.AuditEvent{Time: start, UserToken: "unknown-token", Action: handler.AuditActionRead, Result: handler.AuditResultError}))
+
+	list, err := s.ListAuditEvents(ctx, u.Token, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	require.Equal(t, handler.AuditActionCreate, list[0].Action)
+	require.Equal(t, "ci", list[0].ServiceAccount)
+	require.Equal(t, "d", list[0].Key)
+	require.True(t, events[2].Time.Equal(list[0].Time))
+
+	require.Equal(t, handler.AuditActionRead, list[1].Action)
+	require.Equal(t, "laptop", list[1].Device)
+	require.Equal(t, handler.AuditResultNotFound, list[1].Result)
+
+	list, err = s.ListAuditEvents(ctx, u.Token, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, handler.AuditActionLogin, list[2].Action)
+}
+
+func testReadiness(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Ping(ctx))
+
+	_, err := s.MigrationVersion(ctx)
+	require.NoError(t, err)
+}
+
+func testUsage(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+	other := registerUser(t, s, "other")
+
+	usage, err := s.Usage(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Usage{}, usage)
+
+	// payloads aren't base64, so stored sizes are equal to sizes of sent data
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "data", Data: "data!"}))
+	require.NoError(t, s.CreateCard(ctx, u.Token, &handler.CardData{Number: "1111", Data: "card!"}))
+	require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: "secret", Value: "secret!"}))
+	require.NoError(t, s.CreateSSHKey(ctx, u.Token, &handler.SSHKey{Name: "key", Data: "key!"}))
+	require.NoError(t, s.CreateData(ctx, other.Token, &handler.Record{Name: "data", Data: "other's data"}))
+
+	usage, err = s.Usage(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Usage{Bytes: 21, Items: 4}, usage)
+
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "data", Data: "new data!", Revision: 1}))
+	require.NoError(t, s.DeleteCard(ctx, u.Token, &handler.CardData{Number: "1111"}))
+
+	usage, err = s.Usage(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Usage{Bytes: 20, Items: 3}, usage)
+
+	_, err = s.Usage(ctx, "unknown-token")
+	require.ErrorIs(t, err, handler.ErrUnknownUser)
+}
+
+func testDedup(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+	other := registerUser(t, s, "other")
+
+	hash := strings.Repeat("a1", 32)
+	unknownHash := strings.Repeat("b2", 32)
+
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "first", Data: "content!", ContentHash: hash}))
+
+	// record without data references stored content and gets its data
+	second := &handler.Record{Name: "second", ContentHash: hash}
+	require.NoError(t, s.CreateData(ctx, u.Token, second))
+	require.Equal(t, "content!", second.Data)
+
+	err := s.CreateData(ctx, u.Token, &handler.Record{Name: "third", ContentHash: unknownHash})
+	require.ErrorIs(t, err, handler.ErrContentNotFound)
+
+	// users' contents are isolated
+	err = s.CreateData(ctx, other.Token, &handler.Record{Name: "first", ContentHash: hash})
+	require.ErrorIs(t, err, handler.ErrContentNotFound)
+
+	r, err := s.LoadData(ctx, u.Token, "second")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Record{Name: "second", Data: "content!", Revision: 1}, r)
+
+	usage, err := s.Usage(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Usage{Bytes: 8, Items: 2}, usage)
+
+	// content is kept while it's referenced
+	require.NoError(t, s.DeleteData(ctx, u.Token, &handler.Record{Name: "first"}))
+
+	r, err = s.LoadData(ctx, u.Token, "second")
+	require.NoError(t, err)
+	require.Equal(t, "content!", r.Data)
+
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "second", Data: "new content!", Revision: 1}))
+
+	err = s.UpdateData(ctx, u.Token, &handler.Record{Name: "second", ContentHash: hash, Revision: 2})
+	require.ErrorIs(t, err, handler.ErrContentNotFound)
+
+	// update by hash of the stored content gets its data
+	third := &handler.Record{Name: "second", ContentHash: handler.ContentHash(&handler.Record{Data: "new content!"}), Revision: 2, Metainfo: "m"}
+	require.NoError(t, s.UpdateData(ctx, u.Token, third))
+	require.Equal(t, "new content!", third.Data)
+
+	r, err = s.LoadData(ctx, u.Token, "second")
+	require.NoError(t, err)
+	require.Equal(t, &handler.Record{Name: "second", Data: "new content!", Revision: 3, Metainfo: "m"}, r)
+
+	usage, err = s.Usage(ctx, u.Token)
+	require.NoError(t, err)
+	require.Equal(t, &handler.Usage{Bytes: 12, Items: 1}, usage)
+}
+
+func testList(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	u := registerUser(t, s, "user")
+
+	for _, name := range []string{"b/2", "a/1", "b/1", "a%2", "c"} {
+		require.NoError(t, s.CreateSecret(ctx, u.Token, &handler.Secret{Key: name, Value: "v"}))
+	}
+
+	names := func(list []*handler.Secret) []string {
+		result := make([]string, 0, len(list))
+		for _, secret := range list {
+			result = append(result, secret.Key)
+		}
+
+		return result
+	}
+
+	// pages are continued after the last item of the previous page
+	q := &handler.ListQuery{SortBy: handler.SortByName, Limit: 2}
+	var listed []string
+
+	for {
+		page, err := s.ListSecret(ctx, u.Token, q)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), q.Limit)
+
+		listed = append(listed, names(page)...)
+		if len(page) < q.Limit {
+			break
+		}
+
+		last := page[len(page)-1]
+		q.After = &handler.ListCursor{Name: last.Key, Updated: last.Updated}
+	}
+
+	require.Equal(t, []string{"a%2", "a/1", "b/1", "b/2", "c"}, listed)
+
+	list, err := s.ListSecret(ctx, u.Token, &handler.ListQuery{SortBy: handler.SortByName, Desc: true, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "b/2", "b/1", "a/1", "a%2"}, names(list))
+
+	// prefix isn't a pattern
+	list, err = s.ListSecret(ctx, u.Token, &handler.ListQuery{Prefix: "a/", SortBy: handler.SortByName, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a/1"}, names(list))
+
+	list, err = s.ListSecret(ctx, u.Token, &handler.ListQuery{Prefix: "b", SortBy: handler.SortByName, Desc: true, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b/2", "b/1"}, names(list))
+
+	// items which were changed at the same time are ordered by names
+	list, err = s.ListSecret(ctx, u.Token, &handler.ListQuery{SortBy: handler.SortByUpdated, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 5)
+
+	for i := 1; i < len(list); i++ {
+		require.False(t, list[i].Updated.Before(list[i-1].Updated))
+	}
+
+	after := list[2]
+	rest, err := s.ListSecret(ctx, u.Token, &handler.ListQuery{
+		SortBy: handler.SortByUpdated,
+		After:  &handler.ListCursor{Name: after.Key, Updated: after.Updated},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, names(list[3:]), names(rest))
+
+	since := list[len(list)-1].Updated.Add(time.Second)
+	list, err = s.ListSecret(ctx, u.Token, &handler.ListQuery{UpdatedSince: since, SortBy: handler.SortByName, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	// update of data changes its update time
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "first", Data: "v1"}))
+	require.NoError(t, s.CreateData(ctx, u.Token, &handler.Record{Name: "second", Data: "v1"}))
+
+	records, err := s.ListData(ctx, u.Token, listAll())
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	since = records[0].Updated
+	if records[1].Updated.After(since) {
+		since = records[1].Updated
+	}
+
+	time.Sleep(time.Millisecond * 10)
+	require.NoError(t, s.UpdateData(ctx, u.Token, &handler.Record{Name: "first", Data: "v2", Revision: 1}))
+
+	records, err = s.ListData(ctx, u.Token, &handler.ListQuery{
+		UpdatedSince: since.Add(time.Millisecond),
+		SortBy:       handler.SortByUpdated,
+		Desc:         true,
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "first", records[0].Name)
+	require.Equal(t, uint64(2), records[0].Revision)
+
+	// items which aren't available in the scope don't take places of the page
+	scoped := withScope(ctx, &handler.Scope{Prefixes: []string{"b/", "c"}})
+
+	list, err = s.ListSecret(scoped, u.Token, &handler.ListQuery{SortBy: handler.SortByName, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b/1", "b/2"}, names(list))
+}